@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"curswork-trpo/internal/handlers"
+	"curswork-trpo/internal/migrations"
+	"curswork-trpo/internal/models"
 	"curswork-trpo/internal/repository"
 	"curswork-trpo/internal/service"
 	"curswork-trpo/pkg/adapters/postgres"
+	"curswork-trpo/pkg/adapters/storage"
 
 	_ "curswork-trpo/docs"
 )
@@ -38,28 +47,193 @@ func main() {
 	}
 	defer dbClient.Close(ctx)
 
-	// Initialize database schema
-	if err = initSchema(ctx, dbClient); err != nil {
-		log.Fatalf("Failed to initialize schema: %v", err)
+	migrator, err := migrations.NewMigrator(dbClient)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	registrationConfig, err := registrationConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure registration: %v", err)
+	}
+
+	// Maintenance commands, e.g. `app migrate status`, run instead of the server
+	if len(os.Args) > 1 {
+		if err = runCommand(ctx, dbClient, migrator, registrationConfig, os.Args[1:]); err != nil {
+			log.Fatalf("%s: %v", strings.Join(os.Args[1:], " "), err)
+		}
+		return
+	}
+
+	// Bring the database schema up to date
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
 	}
 
 	// Initialize repositories
 	expenseRepo := repository.NewExpenseRepository(dbClient)
 	userRepo := repository.NewUserRepository(dbClient)
 	budgetRepo := repository.NewBudgetRepository(dbClient)
+	approvalRepo := repository.NewApprovalRepository(dbClient)
+	attachmentRepo := repository.NewAttachmentRepository(dbClient)
+	revisionRepo := repository.NewRevisionRepository(dbClient)
+	sessionRepo := repository.NewSessionRepository(dbClient)
+	invitationRepo := repository.NewInvitationRepository(dbClient)
+	roleRepo := repository.NewRoleRepository(dbClient)
+	delegationRepo := repository.NewDelegationRepository(dbClient)
+	departmentRepo := repository.NewDepartmentRepository(dbClient)
+	categoryRepo := repository.NewCategoryRepository(dbClient)
+	policyRepo := repository.NewPolicyRepository(dbClient)
+	autoApprovalRepo := repository.NewAutoApprovalRepository(dbClient)
+	flagRepo := repository.NewFlagRepository(dbClient)
+	exchangeRateRepo := repository.NewExchangeRateRepository(dbClient)
+
+	// Initialize attachment storage
+	attachmentStorage, err := storage.NewStorage(ctx)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+
+	attachmentMaxSize, err := strconv.ParseInt(getEnv("ATTACHMENT_MAX_SIZE", "10485760"), 10, 64)
+	if err != nil {
+		log.Fatalf("Failed to parse ATTACHMENT_MAX_SIZE: %v", err)
+	}
+
+	attachmentTypes := service.DefaultAttachmentTypes
+	if value := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); value != "" {
+		attachmentTypes = strings.Split(value, ",")
+	}
+
+	approvalChain, err := service.ParseApprovalChain(os.Getenv("APPROVAL_CHAIN"))
+	if err != nil {
+		log.Fatalf("Failed to parse approval chain: %v", err)
+	}
+	if err = service.CheckApprovalChain(ctx, roleRepo, approvalChain); err != nil {
+		log.Fatalf("Invalid approval chain: %v", err)
+	}
+
+	defaultBudget, err := strconv.ParseFloat(getEnv("DEFAULT_MONTHLY_BUDGET", "100000"), 64)
+	if err != nil {
+		log.Fatalf("Failed to parse DEFAULT_MONTHLY_BUDGET: %v", err)
+	}
+
+	fiscalYearStart, err := strconv.Atoi(getEnv("FISCAL_YEAR_START_MONTH", "1"))
+	if err != nil {
+		log.Fatalf("Failed to parse FISCAL_YEAR_START_MONTH: %v", err)
+	}
+
+	budgetConfig, err := service.NewBudgetConfig(
+		getEnv("BUDGET_PERIOD", "monthly"),
+		fiscalYearStart,
+		getEnv("BUDGET_CHARGE_DATE", "expense"),
+		defaultBudget,
+	)
+	if err != nil {
+		log.Fatalf("Failed to configure budget periods: %v", err)
+	}
+
+	fourEyesAmount, err := strconv.ParseFloat(getEnv("FOUR_EYES_AMOUNT", "0"), 64)
+	if err != nil {
+		log.Fatalf("Failed to parse FOUR_EYES_AMOUNT: %v", err)
+	}
+	duties, err := service.NewDutiesPolicy(fourEyesAmount)
+	if err != nil {
+		log.Fatalf("Failed to configure segregation of duties: %v", err)
+	}
+
+	duplicateDays, err := strconv.Atoi(getEnv("DUPLICATE_WINDOW_DAYS", "30"))
+	if err != nil {
+		log.Fatalf("Failed to parse DUPLICATE_WINDOW_DAYS: %v", err)
+	}
+	splitDays, err := strconv.Atoi(getEnv("SPLIT_WINDOW_DAYS", "7"))
+	if err != nil {
+		log.Fatalf("Failed to parse SPLIT_WINDOW_DAYS: %v", err)
+	}
+	detection, err := service.NewDetectionConfig(duplicateDays, splitDays)
+	if err != nil {
+		log.Fatalf("Failed to configure duplicate detection: %v", err)
+	}
+
+	currencyConfig, err := service.NewCurrencyConfig(getEnv("EXCHANGE_RATE_DATE", "submission"))
+	if err != nil {
+		log.Fatalf("Failed to configure currency conversion: %v", err)
+	}
+
+	refreshTokenTTL, err := time.ParseDuration(getEnv("REFRESH_TOKEN_TTL", "720h"))
+	if err != nil {
+		log.Fatalf("Failed to parse REFRESH_TOKEN_TTL: %v", err)
+	}
 
 	// Initialize services
-	expenseService := service.NewExpenseService(expenseRepo, budgetRepo, userRepo)
-	userService := service.NewUserService(userRepo)
-	budgetService := service.NewBudgetService(budgetRepo)
+	auditService, err := auditServiceFromEnv(dbClient)
+	if err != nil {
+		log.Fatalf("Failed to configure the audit log: %v", err)
+	}
+	expenseService := service.NewExpenseService(service.ExpenseServiceDeps{
+		DB:             dbClient,
+		ExpenseRepo:    expenseRepo,
+		BudgetRepo:     budgetRepo,
+		UserRepo:       userRepo,
+		ApprovalRepo:   approvalRepo,
+		AttachmentRepo: attachmentRepo,
+		RevisionRepo:   revisionRepo,
+		DelegationRepo: delegationRepo,
+		RoleRepo:       roleRepo,
+		DepartmentRepo: departmentRepo,
+		CategoryRepo:   categoryRepo,
+		PolicyRepo:     policyRepo,
+		AutoRepo:       autoApprovalRepo,
+		FlagRepo:       flagRepo,
+		RateRepo:       exchangeRateRepo,
+		Audit:          auditService,
+		ApprovalChain:  approvalChain,
+		BudgetConfig:   budgetConfig,
+		Duties:         duties,
+		Detection:      detection,
+		Currency:       currencyConfig,
+	})
+	userService := service.NewUserService(
+		dbClient, userRepo, invitationRepo, sessionRepo, roleRepo, departmentRepo, auditService, registrationConfig,
+	)
+	roleService := service.NewRoleService(dbClient, roleRepo, auditService)
+	delegationService := service.NewDelegationService(dbClient, delegationRepo, userRepo, auditService)
+	departmentService := service.NewDepartmentService(dbClient, departmentRepo, auditService)
+	categoryService := service.NewCategoryService(dbClient, categoryRepo, auditService)
+	policyService := service.NewPolicyService(dbClient, policyRepo, auditService)
+	autoApprovalService := service.NewAutoApprovalService(dbClient, autoApprovalRepo, categoryRepo, auditService)
+	exchangeRateService := service.NewExchangeRateService(dbClient, exchangeRateRepo, auditService)
+	sessionService := service.NewSessionService(dbClient, sessionRepo, userRepo, auditService, refreshTokenTTL)
+	budgetService := service.NewBudgetService(dbClient, budgetRepo, departmentRepo, auditService, budgetConfig)
+	attachmentService := service.NewAttachmentService(
+		dbClient, attachmentRepo, expenseRepo, attachmentStorage, auditService,
+		service.AttachmentConfig{MaxSize: attachmentMaxSize, AllowedTypes: attachmentTypes},
+	)
 
 	// Initialize handlers
 	expenseHandler := handlers.NewExpenseHandler(expenseService, userService, budgetService)
-	authHandler := handlers.NewAuthHandler(userService)
+	authHandler := handlers.NewAuthHandler(userService, sessionService)
 	budgetHandler := handlers.NewBudgetHandler(budgetService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	userHandler := handlers.NewUserHandler(userService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	delegationHandler := handlers.NewDelegationHandler(delegationService)
+	departmentHandler := handlers.NewDepartmentHandler(departmentService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	policyHandler := handlers.NewPolicyHandler(policyService)
+	autoApprovalHandler := handlers.NewAutoApprovalHandler(autoApprovalService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
 
 	// Setup router
-	router := handlers.SetupRouter(expenseHandler, authHandler, budgetHandler)
+	router := handlers.SetupRouter(
+		expenseHandler, authHandler, budgetHandler, attachmentHandler, auditHandler, userHandler, roleHandler,
+		delegationHandler, departmentHandler, categoryHandler, policyHandler, autoApprovalHandler, exchangeRateHandler,
+		sessionService, roleService,
+	)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -70,57 +244,134 @@ func main() {
 	}
 }
 
-func initSchema(ctx context.Context, client *postgres.Client) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		email VARCHAR(255) UNIQUE NOT NULL,
-		password VARCHAR(255) NOT NULL,
-		first_name VARCHAR(100) NOT NULL,
-		last_name VARCHAR(100) NOT NULL,
-		role VARCHAR(20) NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-	);
-
-	CREATE TABLE IF NOT EXISTS expense_requests (
-		id SERIAL PRIMARY KEY,
-		title VARCHAR(255) NOT NULL,
-		category VARCHAR(100) NOT NULL,
-		amount DECIMAL(10, 2) NOT NULL,
-		vendor VARCHAR(255) NOT NULL,
-		description TEXT NOT NULL,
-		status VARCHAR(20) NOT NULL DEFAULT 'pending',
-		employee_id INTEGER NOT NULL REFERENCES users(id),
-		reviewer_id INTEGER REFERENCES users(id),
-		comments TEXT,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		reviewed_at TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS budgets (
-		id SERIAL PRIMARY KEY,
-		year INTEGER NOT NULL,
-		month INTEGER NOT NULL,
-		total DECIMAL(12, 2) NOT NULL,
-		spent DECIMAL(12, 2) NOT NULL DEFAULT 0,
-		remaining DECIMAL(12, 2) NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		UNIQUE(year, month)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_expense_requests_employee_id ON expense_requests(employee_id);
-	CREATE INDEX IF NOT EXISTS idx_expense_requests_status ON expense_requests(status);
-	CREATE INDEX IF NOT EXISTS idx_budgets_year_month ON budgets(year, month);
-	`
-
-	_, err := client.Exec(ctx, schema)
+// runCommand runs a maintenance command given on the command line
+func runCommand(
+	ctx context.Context,
+	dbClient *postgres.Client,
+	migrator *migrations.Migrator,
+	registrationConfig service.RegistrationConfig,
+	args []string,
+) error {
+	// `invite <email> <role>` bootstraps the first management accounts
+	if args[0] == "invite" {
+		return inviteCommand(ctx, dbClient, registrationConfig, args[1:])
+	}
+
+	switch strings.Join(args, " ") {
+	case "migrate up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return nil
+	case "migrate down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println("no migrations to revert")
+			return nil
+		}
+		fmt.Printf("reverted %04d_%s\n", reverted.Version, reverted.Name)
+		return nil
+	case "migrate status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+	case "audit verify":
+		auditService, err := auditServiceFromEnv(dbClient)
+		if err != nil {
+			return err
+		}
+		report, err := auditService.VerifyChain(ctx)
+		if err != nil {
+			return err
+		}
+
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		if !report.Valid {
+			return errors.New("audit log hash chain is broken")
+		}
+		return nil
+	default:
+		return errors.New("unknown command, available: migrate up|down|status, audit verify, invite <email> <role>")
+	}
+}
+
+// inviteCommand creates an invitation from the command line and prints its token
+func inviteCommand(ctx context.Context, dbClient *postgres.Client, registrationConfig service.RegistrationConfig, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: invite <email> <role>")
+	}
+
+	auditService, err := auditServiceFromEnv(dbClient)
+	if err != nil {
+		return err
+	}
+	userService := service.NewUserService(
+		dbClient, repository.NewUserRepository(dbClient), repository.NewInvitationRepository(dbClient),
+		repository.NewSessionRepository(dbClient), repository.NewRoleRepository(dbClient),
+		repository.NewDepartmentRepository(dbClient), auditService, registrationConfig,
+	)
+	invitation, err := userService.CreateInvitation(
+		ctx, &models.CreateInvitationDTO{Email: args[0], Role: models.UserRole(args[1])}, nil,
+	)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Database schema initialized successfully")
+	fmt.Printf(
+		"invitation for %s as %s, valid until %s:\n%s\n",
+		invitation.Invitation.Email, invitation.Invitation.Role,
+		invitation.Invitation.ExpiresAt.Format(time.RFC3339), invitation.Token,
+	)
 	return nil
 }
+
+// registrationConfigFromEnv reads REGISTRATION_MODE and INVITATION_TTL
+func registrationConfigFromEnv() (service.RegistrationConfig, error) {
+	invitationTTL, err := time.ParseDuration(getEnv("INVITATION_TTL", "168h"))
+	if err != nil {
+		return service.RegistrationConfig{}, fmt.Errorf("failed to parse INVITATION_TTL: %w", err)
+	}
+	return service.NewRegistrationConfig(getEnv("REGISTRATION_MODE", "open"), invitationTTL)
+}
+
+// auditServiceFromEnv creates the audit service with the key from AUDIT_HMAC_KEY
+func auditServiceFromEnv(dbClient *postgres.Client) (*service.AuditService, error) {
+	auditService, err := service.NewAuditService(
+		dbClient, repository.NewAuditRepository(dbClient), []byte(os.Getenv("AUDIT_HMAC_KEY")),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("AUDIT_HMAC_KEY: %w", err)
+	}
+	return auditService, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
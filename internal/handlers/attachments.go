@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentHandler handles receipts and invoices of expense requests
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService}
+}
+
+// UploadAttachment godoc
+// @Summary Upload attachment
+// @Description Attach a receipt or invoice (PDF, JPEG, PNG, WebP) to your own pending expense request
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Param file formData file true "Receipt or invoice file"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /api/expenses/{id}/attachments [post]
+// @Security BearerAuth
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	requestID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest, ErrorResponse{
+				Error: "file is required",
+			},
+		)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			},
+		)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadAttachment(
+		c.Request.Context(), requestID, c.GetUint("userID"), fileHeader.Filename, file, fileHeader.Size,
+	)
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments godoc
+// @Summary List attachments
+// @Description List attachments of an expense request (owner or management)
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Success 200 {array} models.Attachment
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/expenses/{id}/attachments [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	requestID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(
+		c.Request.Context(), requestID, c.GetUint("userID"), permissionsOf(c),
+	)
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment godoc
+// @Summary Download attachment
+// @Description Download an attachment of an expense request (owner or management)
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Expense request ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/expenses/{id}/attachments/{attachmentId} [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	requestID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	attachmentID, ok := parseIDParam(c, "attachmentId")
+	if !ok {
+		return
+	}
+
+	attachment, content, err := h.attachmentService.OpenAttachment(
+		c.Request.Context(), requestID, attachmentID, c.GetUint("userID"), permissionsOf(c),
+	)
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(
+		http.StatusOK, attachment.Size, attachment.ContentType, content,
+		map[string]string{
+			"Content-Disposition": fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+		},
+	)
+}
+
+// DeleteAttachment godoc
+// @Summary Delete attachment
+// @Description Delete an attachment from your own pending expense request
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/expenses/{id}/attachments/{attachmentId} [delete]
+// @Security BearerAuth
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	requestID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	attachmentID, ok := parseIDParam(c, "attachmentId")
+	if !ok {
+		return
+	}
+
+	err := h.attachmentService.DeleteAttachment(c.Request.Context(), requestID, attachmentID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "attachment deleted successfully"})
+}
+
+// permissionsOf returns the permissions AuthMiddleware loaded for the current user
+func permissionsOf(c *gin.Context) models.PermissionSet {
+	permissions, _ := c.Get("permissions")
+	set, _ := permissions.(models.PermissionSet)
+	return set
+}
+
+// parseIDParam reads a numeric path param, writing 400 on failure
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest, ErrorResponse{
+				Error: "invalid " + name,
+			},
+		)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func attachmentErrorStatus(err error) int {
+	var transitionErr *service.TransitionError
+	switch {
+	case errors.Is(err, service.ErrRequestNotFound), errors.Is(err, service.ErrAttachmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRequestNotEditable), errors.Is(err, service.ErrRequestAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrAttachmentTypeForbidden):
+		return http.StatusUnsupportedMediaType
+	case errors.As(err, &transitionErr):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
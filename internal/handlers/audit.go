@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler handles the audit log
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAuditEntries godoc
+// @Summary List audit log
+// @Description List audit records, newest first (audit.view)
+// @Tags audit
+// @Produce json
+// @Param entityType query string false "Entity type" Enums(expense_request, attachment, budget, user)
+// @Param entityId query int false "Entity ID"
+// @Param actorId query int false "ID of the user who made the change"
+// @Param action query string false "Action, e.g. expense.approved"
+// @Param from query string false "From date, inclusive (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "To date, exclusive (YYYY-MM-DD or RFC 3339)"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param offset query int false "Number of records to skip"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/audit [get]
+// @Security BearerAuth
+func (h *AuditHandler) ListAuditEntries(c *gin.Context) {
+	filter := models.AuditFilter{
+		EntityType: models.AuditEntity(c.Query("entityType")),
+		Action:     models.AuditAction(c.Query("action")),
+	}
+
+	var err error
+	if filter.EntityID, err = queryUint(c, "entityId"); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid entityId"})
+		return
+	}
+	if filter.ActorID, err = queryUint(c, "actorId"); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid actorId"})
+		return
+	}
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid from"})
+		return
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid to"})
+		return
+	}
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid limit"})
+		return
+	}
+	if filter.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid offset"})
+		return
+	}
+
+	entries, err := h.auditService.ListEntries(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError, ErrorResponse{
+				Error: err.Error(),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// VerifyAuditChain godoc
+// @Summary Verify audit log
+// @Description Walk the audit log hash chain and report the first record that was edited, removed or inserted outside the application (audit.view)
+// @Tags audit
+// @Produce json
+// @Success 200 {object} models.AuditChainReport
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} models.AuditChainReport
+// @Router /api/audit/verify [get]
+// @Security BearerAuth
+func (h *AuditHandler) VerifyAuditChain(c *gin.Context) {
+	report, err := h.auditService.VerifyChain(c.Request.Context())
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError, ErrorResponse{
+				Error: err.Error(),
+			},
+		)
+		return
+	}
+
+	if !report.Valid {
+		c.JSON(http.StatusConflict, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// queryUint reads an optional numeric query param
+func queryUint(c *gin.Context, name string) (*uint, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	result := uint(id)
+	return &result, nil
+}
+
+// queryTime reads an optional query param holding a date or an RFC 3339 timestamp
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
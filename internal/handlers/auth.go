@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"curswork-trpo/internal/middleware"
@@ -12,22 +13,29 @@ import (
 
 // AuthHandler handles authentication
 type AuthHandler struct {
-	userService *service.UserService
+	userService    *service.UserService
+	sessionService *service.SessionService
 }
 
-func NewAuthHandler(userService *service.UserService) *AuthHandler {
-	return &AuthHandler{userService: userService}
+func NewAuthHandler(userService *service.UserService, sessionService *service.SessionService) *AuthHandler {
+	return &AuthHandler{
+		userService:    userService,
+		sessionService: sessionService,
+	}
 }
 
 // Register godoc
 // @Summary Register user
-// @Description Register a new user
+// @Description Register a new user. The role and email are taken from the invitation token;
+// @Description without a token the user becomes an employee, if registration is open.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body models.RegisterUserDTO true "Registration data"
 // @Success 201 {object} models.User
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var dto models.RegisterUserDTO
@@ -40,7 +48,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	user, err := h.userService.RegisterUser(c.Request.Context(), &dto)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		c.JSON(registrationErrorStatus(err), ErrorResponse{
 			Error: err.Error(),
 		})
 		return
@@ -51,7 +59,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login user
-// @Description Login and get JWT token
+// @Description Login and get a short-lived JWT access token with a refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -76,7 +84,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(user.ID, string(user.Role))
+	session, err := h.sessionService.StartSession(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to start session",
+		})
+		return
+	}
+
+	token, expiresAt, err := middleware.GenerateToken(user.ID, session.SessionID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error: "failed to generate token",
@@ -85,14 +101,88 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		User:  *user,
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+		ExpiresAt:    expiresAt,
+		User:         *user,
 	})
 }
 
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token.
+// @Description Every refresh token works once; using one again revokes its whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenDTO true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var dto models.RefreshTokenDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	session, err := h.sessionService.Refresh(c.Request.Context(), dto.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to refresh session",
+		})
+		return
+	}
+
+	token, expiresAt, err := middleware.GenerateToken(session.User.ID, session.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TokenResponse{
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+		ExpiresAt:    expiresAt,
+	})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current session and its tokens
+// @Tags auth
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/auth/logout [post]
+// @Security BearerAuth
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.sessionService.Logout(
+		c.Request.Context(), c.GetString("sessionID"), c.GetUint("userID"),
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "failed to logout",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "logged out successfully"})
+}
+
 // GetCurrentUser godoc
 // @Summary Get current user
-// @Description Get current authenticated user
+// @Description Get current authenticated user with the permissions granted by all of their roles
 // @Tags auth
 // @Produce json
 // @Success 200 {object} models.User
@@ -115,6 +205,20 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		})
 		return
 	}
+	user.Permissions = permissionsOf(c)
 
 	c.JSON(http.StatusOK, user)
 }
+
+func registrationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvitationRequired):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUserExists):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidInvitation):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
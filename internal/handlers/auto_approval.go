@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AutoApprovalHandler handles the rules low-risk requests are approved automatically by
+type AutoApprovalHandler struct {
+	autoApprovalService *service.AutoApprovalService
+}
+
+func NewAutoApprovalHandler(autoApprovalService *service.AutoApprovalService) *AutoApprovalHandler {
+	return &AutoApprovalHandler{autoApprovalService: autoApprovalService}
+}
+
+// ListAutoApprovalRules godoc
+// @Summary List auto-approval rules
+// @Description Get the auto-approval rules in the order they are tried (policies.manage)
+// @Tags policies
+// @Produce json
+// @Param all query bool false "Include inactive rules"
+// @Success 200 {array} models.AutoApprovalRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/auto-approval-rules [get]
+// @Security BearerAuth
+func (h *AutoApprovalHandler) ListAutoApprovalRules(c *gin.Context) {
+	all, err := strconv.ParseBool(c.DefaultQuery("all", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid all"})
+		return
+	}
+
+	rules, err := h.autoApprovalService.ListAutoApprovalRules(c.Request.Context(), all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetAutoApprovalRule godoc
+// @Summary Get auto-approval rule
+// @Description Get an auto-approval rule (policies.manage)
+// @Tags policies
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} models.AutoApprovalRule
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/auto-approval-rules/{id} [get]
+// @Security BearerAuth
+func (h *AutoApprovalHandler) GetAutoApprovalRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	rule, err := h.autoApprovalService.GetAutoApprovalRule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(autoApprovalErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateAutoApprovalRule godoc
+// @Summary Create auto-approval rule
+// @Description Add a rule approving requests right after they are sent to review (policies.manage):
+// @Description requests of the category (any when omitted) up to maxAmount, by employees trusted at least minTrustLevel,
+// @Description while every budget charged keeps minBudgetRemaining percent of its total after the expense.
+// @Tags policies
+// @Accept json
+// @Produce json
+// @Param request body models.AutoApprovalRuleDTO true "Rule data"
+// @Success 201 {object} models.AutoApprovalRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/auto-approval-rules [post]
+// @Security BearerAuth
+func (h *AutoApprovalHandler) CreateAutoApprovalRule(c *gin.Context) {
+	var dto models.AutoApprovalRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rule, err := h.autoApprovalService.CreateAutoApprovalRule(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(autoApprovalErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAutoApprovalRule godoc
+// @Summary Update auto-approval rule
+// @Description Change an auto-approval rule or deactivate it with isActive (policies.manage)
+// @Tags policies
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param request body models.AutoApprovalRuleDTO true "Rule data"
+// @Success 200 {object} models.AutoApprovalRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/auto-approval-rules/{id} [put]
+// @Security BearerAuth
+func (h *AutoApprovalHandler) UpdateAutoApprovalRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.AutoApprovalRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rule, err := h.autoApprovalService.UpdateAutoApprovalRule(c.Request.Context(), id, &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(autoApprovalErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteAutoApprovalRule godoc
+// @Summary Delete auto-approval rule
+// @Description Delete an auto-approval rule (policies.manage)
+// @Tags policies
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/auto-approval-rules/{id} [delete]
+// @Security BearerAuth
+func (h *AutoApprovalHandler) DeleteAutoApprovalRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.autoApprovalService.DeleteAutoApprovalRule(c.Request.Context(), id, c.GetUint("userID")); err != nil {
+		c.JSON(autoApprovalErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "auto-approval rule deleted successfully"})
+}
+
+func autoApprovalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAutoApprovalRuleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUnknownCategory):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
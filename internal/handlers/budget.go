@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BudgetHandler handles budget operations
+type BudgetHandler struct {
+	budgetService *service.BudgetService
+}
+
+func NewBudgetHandler(budgetService *service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+	}
+}
+
+// GetCurrentBudget godoc
+// @Summary Get current budget
+// @Description Get budget for the current period (month, quarter or fiscal year): the company budget or the budget of a department.
+// @Description With breakdown the budgets of the departments below are listed in departments.
+// @Tags budget
+// @Produce json
+// @Param department query int false "Department ID (company budget by default)"
+// @Param breakdown query bool false "Break the budget down by department"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/budget/current [get]
+// @Security BearerAuth
+func (h *BudgetHandler) GetCurrentBudget(c *gin.Context) {
+	departmentID, ok := parseDepartmentQuery(c)
+	if !ok {
+		return
+	}
+	breakdown, err := strconv.ParseBool(c.DefaultQuery("breakdown", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid breakdown"})
+		return
+	}
+
+	budget, err := h.budgetService.GetCurrentBudget(c.Request.Context(), departmentID, breakdown)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// ListBudgets godoc
+// @Summary List budgets
+// @Description List company and department budgets whose period starts in a year (budget.view)
+// @Tags budget
+// @Produce json
+// @Param year query int false "Year, current year by default"
+// @Success 200 {array} models.Budget
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/budget [get]
+// @Security BearerAuth
+func (h *BudgetHandler) ListBudgets(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		c.JSON(
+			http.StatusBadRequest, ErrorResponse{
+				Error: "invalid year",
+			},
+		)
+		return
+	}
+
+	budgets, err := h.budgetService.ListBudgets(c.Request.Context(), year)
+	if err != nil {
+		c.JSON(
+			http.StatusInternalServerError, ErrorResponse{
+				Error: err.Error(),
+			},
+		)
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+// CreateBudget godoc
+// @Summary Create budget
+// @Description Create a budget for the period containing a month (budget.edit).
+// @Description A department budget has to fit into the budget of its parent department, or of the company for a top-level department.
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param request body models.CreateBudgetDTO true "Budget data"
+// @Success 201 {object} models.Budget
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/budget [post]
+// @Security BearerAuth
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	var dto models.CreateBudgetDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(
+			http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			},
+		)
+		return
+	}
+
+	budget, err := h.budgetService.CreateBudget(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+// GetBudget godoc
+// @Summary Get budget by month
+// @Description Get budget of the period containing a specific month
+// @Tags budget
+// @Produce json
+// @Param year path int true "Year"
+// @Param month path int true "Month (1-12)"
+// @Param department query int false "Department ID (company budget by default)"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/budget/{year}/{month} [get]
+// @Security BearerAuth
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	year, month, ok := parseBudgetMonth(c)
+	if !ok {
+		return
+	}
+
+	departmentID, ok := parseDepartmentQuery(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.budgetService.GetBudgetByMonth(c.Request.Context(), year, month, departmentID)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget godoc
+// @Summary Update budget total
+// @Description Change the total of the budget of the period containing a month to no less than the spent amount
+// @Description nor below what is allocated to the departments below it (budget.edit)
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param year path int true "Year"
+// @Param month path int true "Month (1-12)"
+// @Param department query int false "Department ID (company budget by default)"
+// @Param request body models.UpdateBudgetDTO true "New total"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/budget/{year}/{month} [put]
+// @Security BearerAuth
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	year, month, ok := parseBudgetMonth(c)
+	if !ok {
+		return
+	}
+
+	departmentID, ok := parseDepartmentQuery(c)
+	if !ok {
+		return
+	}
+
+	var dto models.UpdateBudgetDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(
+			http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
+			},
+		)
+		return
+	}
+
+	budget, err := h.budgetService.UpdateBudgetTotal(
+		c.Request.Context(), year, month, departmentID, dto.Total, c.GetUint("userID"),
+	)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// GetBudgetHistory godoc
+// @Summary Get budget history
+// @Description Get every change of the budget of the period containing a month: creation, total changes and spending (budget.view)
+// @Tags budget
+// @Produce json
+// @Param year path int true "Year"
+// @Param month path int true "Month (1-12)"
+// @Param department query int false "Department ID (company budget by default)"
+// @Success 200 {array} models.BudgetHistoryEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/budget/{year}/{month}/history [get]
+// @Security BearerAuth
+func (h *BudgetHandler) GetBudgetHistory(c *gin.Context) {
+	year, month, ok := parseBudgetMonth(c)
+	if !ok {
+		return
+	}
+
+	departmentID, ok := parseDepartmentQuery(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.budgetService.GetBudgetHistory(c.Request.Context(), year, month, departmentID)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// parseBudgetMonth reads year and month path params, writing 400 on failure
+func parseBudgetMonth(c *gin.Context) (int, int, bool) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid year"})
+		return 0, 0, false
+	}
+
+	month, err := strconv.Atoi(c.Param("month"))
+	if err != nil || month < 1 || month > 12 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid month"})
+		return 0, 0, false
+	}
+
+	return year, month, true
+}
+
+// parseDepartmentQuery reads the optional department query param, writing 400 on failure
+func parseDepartmentQuery(c *gin.Context) (*uint, bool) {
+	departmentID, err := queryUint(c, "department")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid department"})
+		return nil, false
+	}
+	return departmentID, true
+}
+
+func budgetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBudgetNotFound), errors.Is(err, service.ErrDepartmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrBudgetExists),
+		errors.Is(err, service.ErrBudgetBelowSpent),
+		errors.Is(err, service.ErrBudgetBelowAllocated),
+		errors.Is(err, service.ErrBudgetOverAllocated),
+		errors.Is(err, service.ErrParentBudgetMissing):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidBudgetDate):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
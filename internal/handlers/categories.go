@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryHandler handles the expense category registry
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+// ListCategories godoc
+// @Summary List categories
+// @Description Get the expense categories requests may use, ordered by code. The tree is given by parentId.
+// @Tags categories
+// @Produce json
+// @Param all query bool false "Include inactive categories"
+// @Success 200 {array} models.Category
+// @Failure 400 {object} ErrorResponse
+// @Router /api/categories [get]
+// @Security BearerAuth
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	all, err := strconv.ParseBool(c.DefaultQuery("all", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid all"})
+		return
+	}
+
+	categories, err := h.categoryService.ListCategories(c.Request.Context(), all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary Get category
+// @Description Get an expense category with its limits
+// @Tags categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} models.Category
+// @Failure 404 {object} ErrorResponse
+// @Router /api/categories/{id} [get]
+// @Security BearerAuth
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.GetCategory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory godoc
+// @Summary Create category
+// @Description Add an expense category with optional limits: maxAmount per request, monthlyCap per employee
+// @Description and calendar month, receiptRequired to demand an attachment (categories.manage)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param request body models.CreateCategoryDTO true "Category data"
+// @Success 201 {object} models.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/categories [post]
+// @Security BearerAuth
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var dto models.CreateCategoryDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory godoc
+// @Summary Update category
+// @Description Change a category except its code (categories.manage).
+// @Description Inactive categories cannot be chosen for new requests.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param request body models.UpdateCategoryDTO true "Category data"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/categories/{id} [put]
+// @Security BearerAuth
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.UpdateCategoryDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(c.Request.Context(), id, &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary Delete category
+// @Description Delete a category no request, delegation or sub-category refers to (categories.manage)
+// @Tags categories
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/categories/{id} [delete]
+// @Security BearerAuth
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(c.Request.Context(), id, c.GetUint("userID")); err != nil {
+		c.JSON(categoryErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "category deleted successfully"})
+}
+
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCategoryExists), errors.Is(err, service.ErrCategoryInUse):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrCategoryCycle):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
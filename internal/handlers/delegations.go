@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DelegationHandler handles approval delegations
+type DelegationHandler struct {
+	delegationService *service.DelegationService
+}
+
+func NewDelegationHandler(delegationService *service.DelegationService) *DelegationHandler {
+	return &DelegationHandler{delegationService: delegationService}
+}
+
+// CreateDelegation godoc
+// @Summary Delegate approvals
+// @Description Let another user approve and reject requests on your behalf for a range of days,
+// @Description optionally only for one category and up to an amount (expenses.approve)
+// @Tags delegations
+// @Accept json
+// @Produce json
+// @Param request body models.CreateDelegationDTO true "Delegation data"
+// @Success 201 {object} models.Delegation
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/delegations [post]
+// @Security BearerAuth
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	var dto models.CreateDelegationDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	delegation, err := h.delegationService.CreateDelegation(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(delegationErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, delegation)
+}
+
+// ListDelegations godoc
+// @Summary List delegations
+// @Description Get the delegations the current user gave or received, latest start first
+// @Tags delegations
+// @Produce json
+// @Success 200 {array} models.Delegation
+// @Failure 401 {object} ErrorResponse
+// @Router /api/delegations [get]
+// @Security BearerAuth
+func (h *DelegationHandler) ListDelegations(c *gin.Context) {
+	delegations, err := h.delegationService.ListDelegations(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delegations)
+}
+
+// RevokeDelegation godoc
+// @Summary Revoke delegation
+// @Description End a delegation you gave before its end date
+// @Tags delegations
+// @Produce json
+// @Param id path int true "Delegation ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/delegations/{id} [delete]
+// @Security BearerAuth
+func (h *DelegationHandler) RevokeDelegation(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.delegationService.RevokeDelegation(c.Request.Context(), id, c.GetUint("userID")); err != nil {
+		c.JSON(delegationErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "delegation revoked successfully"})
+}
+
+func delegationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDelegationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidDelegation):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
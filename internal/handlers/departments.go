@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DepartmentHandler handles departments and cost centers
+type DepartmentHandler struct {
+	departmentService *service.DepartmentService
+}
+
+func NewDepartmentHandler(departmentService *service.DepartmentService) *DepartmentHandler {
+	return &DepartmentHandler{departmentService: departmentService}
+}
+
+// ListDepartments godoc
+// @Summary List departments
+// @Description Get all departments ordered by cost center code
+// @Tags departments
+// @Produce json
+// @Success 200 {array} models.Department
+// @Router /api/departments [get]
+// @Security BearerAuth
+func (h *DepartmentHandler) ListDepartments(c *gin.Context) {
+	departments, err := h.departmentService.ListDepartments(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, departments)
+}
+
+// GetDepartment godoc
+// @Summary Get department
+// @Description Get a department
+// @Tags departments
+// @Produce json
+// @Param id path int true "Department ID"
+// @Success 200 {object} models.Department
+// @Failure 404 {object} ErrorResponse
+// @Router /api/departments/{id} [get]
+// @Security BearerAuth
+func (h *DepartmentHandler) GetDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	department, err := h.departmentService.GetDepartment(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(departmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, department)
+}
+
+// CreateDepartment godoc
+// @Summary Create department
+// @Description Add a department below the company or below another department (users.manage)
+// @Tags departments
+// @Accept json
+// @Produce json
+// @Param request body models.DepartmentDTO true "Department data"
+// @Success 201 {object} models.Department
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/departments [post]
+// @Security BearerAuth
+func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
+	var dto models.DepartmentDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	department, err := h.departmentService.CreateDepartment(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(departmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, department)
+}
+
+// UpdateDepartment godoc
+// @Summary Update department
+// @Description Rename a department or move it with its sub-departments elsewhere in the tree (users.manage)
+// @Tags departments
+// @Accept json
+// @Produce json
+// @Param id path int true "Department ID"
+// @Param request body models.DepartmentDTO true "Department data"
+// @Success 200 {object} models.Department
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/departments/{id} [put]
+// @Security BearerAuth
+func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.DepartmentDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	department, err := h.departmentService.UpdateDepartment(c.Request.Context(), id, &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(departmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, department)
+}
+
+// DeleteDepartment godoc
+// @Summary Delete department
+// @Description Delete a department without sub-departments, members or budgets (users.manage)
+// @Tags departments
+// @Produce json
+// @Param id path int true "Department ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/departments/{id} [delete]
+// @Security BearerAuth
+func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.departmentService.DeleteDepartment(c.Request.Context(), id, c.GetUint("userID")); err != nil {
+		c.JSON(departmentErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "department deleted successfully"})
+}
+
+func departmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrDepartmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDepartmentExists), errors.Is(err, service.ErrDepartmentInUse):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrDepartmentCycle):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
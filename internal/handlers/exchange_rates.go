@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRatesFileSize limits CSV uploads of exchange rates
+const maxRatesFileSize = 1 << 20
+
+// ExchangeRateHandler handles the exchange rates of foreign currencies to the base currency
+type ExchangeRateHandler struct {
+	exchangeRateService *service.ExchangeRateService
+}
+
+func NewExchangeRateHandler(exchangeRateService *service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{exchangeRateService: exchangeRateService}
+}
+
+// ListExchangeRates godoc
+// @Summary List exchange rates
+// @Description Get the exchange rates to the base currency (rates.manage)
+// @Tags exchange-rates
+// @Produce json
+// @Param currency query string false "ISO 4217 currency code"
+// @Param from query string false "Rate date from, inclusive (YYYY-MM-DD)"
+// @Param to query string false "Rate date to, inclusive (YYYY-MM-DD)"
+// @Success 200 {array} models.ExchangeRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/exchange-rates [get]
+// @Security BearerAuth
+func (h *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+	var dto models.ListExchangeRatesDTO
+	if err := c.ShouldBindQuery(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rates, err := h.exchangeRateService.ListExchangeRates(c.Request.Context(), &dto)
+	if err != nil {
+		c.JSON(exchangeRateErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetExchangeRate godoc
+// @Summary Set exchange rate
+// @Description Set the price of one unit of a currency in the base currency (RUB) from a date on,
+// @Description replacing the rate of that date if there is one. Requests already converted keep their rate (rates.manage).
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param request body models.SetExchangeRateDTO true "Rate data"
+// @Success 200 {object} models.ExchangeRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/exchange-rates [put]
+// @Security BearerAuth
+func (h *ExchangeRateHandler) SetExchangeRate(c *gin.Context) {
+	var dto models.SetExchangeRateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rate, err := h.exchangeRateService.SetExchangeRate(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(exchangeRateErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// ImportExchangeRates godoc
+// @Summary Import exchange rates
+// @Description Set exchange rates from a CSV file of currency,date,rate lines (header line optional, at most 1 MB).
+// @Description The file is imported as a whole or not at all (rates.manage).
+// @Tags exchange-rates
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Success 200 {object} models.ExchangeRateImport
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /api/admin/exchange-rates/import [post]
+// @Security BearerAuth
+func (h *ExchangeRateHandler) ImportExchangeRates(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "file is required"})
+		return
+	}
+	if fileHeader.Size > maxRatesFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "file is larger than 1 MB"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer file.Close()
+
+	imported, err := h.exchangeRateService.ImportExchangeRates(c.Request.Context(), file, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(exchangeRateErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ExchangeRateImport{Imported: imported})
+}
+
+func exchangeRateErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidExchangeRate) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -32,7 +32,13 @@ func NewExpenseHandler(
 
 // CreateExpenseRequest godoc
 // @Summary Create expense request
-// @Description Create a new expense request
+// @Description Create a new expense request. The category has to be an active code from /api/categories
+// @Description and the amount has to keep within its per-request and monthly limits.
+// @Description The amount is in currency (RUB when omitted) and is converted to RUB with the rate of today,
+// @Description the limits, budgets and statistics use the converted amount. A currency without a rate is refused (400).
+// @Description Requests refused by the policy rules of the submit stage are not created (422).
+// @Description A new request has no attachments yet, requests needing them go through a draft.
+// @Description Requests covered by an auto-approval rule come back approved, with the system user as reviewer.
 // @Tags expenses
 // @Accept json
 // @Produce json
@@ -40,6 +46,7 @@ func NewExpenseHandler(
 // @Success 201 {object} models.ExpenseRequest
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Router /api/expenses [post]
 // @Security BearerAuth
 func (h *ExpenseHandler) CreateExpenseRequest(c *gin.Context) {
@@ -65,7 +72,7 @@ func (h *ExpenseHandler) CreateExpenseRequest(c *gin.Context) {
 
 	request, err := h.expenseService.CreateExpenseRequest(c.Request.Context(), &dto, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -79,49 +86,100 @@ func (h *ExpenseHandler) CreateExpenseRequest(c *gin.Context) {
 	c.JSON(http.StatusCreated, request)
 }
 
-// GetExpenseRequests godoc
-// @Summary Get expense requests
-// @Description Get expense requests for current user or all (for management)
+// CreateDraftExpenseRequest godoc
+// @Summary Create draft expense request
+// @Description Save an expense request as a draft.
+// @Description Drafts are visible to their owner only, can be edited freely and are checked in full on submit.
 // @Tags expenses
+// @Accept json
 // @Produce json
-// @Param status query string false "Status filter (all, pending, approved, rejected)"
-// @Success 200 {array} models.ExpenseRequest
+// @Param request body models.CreateDraftExpenseDTO true "Draft data"
+// @Success 201 {object} models.ExpenseRequest
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
-// @Router /api/expenses [get]
+// @Router /api/expenses/drafts [post]
 // @Security BearerAuth
-func (h *ExpenseHandler) GetExpenseRequests(c *gin.Context) {
-	userID := c.GetUint("userID")
-	userRole := c.GetString("userRole")
-	status := c.DefaultQuery("status", "all")
-
-	var requests []models.ExpenseRequest
-	var err error
+func (h *ExpenseHandler) CreateDraftExpenseRequest(c *gin.Context) {
+	var dto models.CreateDraftExpenseDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	if userRole == string(models.RoleManagement) {
-		requests, err = h.expenseService.GetAllExpenseRequests(c.Request.Context(), status)
-	} else {
-		requests, err = h.expenseService.GetExpenseRequestsByEmployee(c.Request.Context(), userID, status)
+	request, err := h.expenseService.CreateDraftExpenseRequest(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
 	}
 
+	employee, err := h.userService.GetUserByID(c.Request.Context(), request.EmployeeID)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	request.Employee = *employee
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// GetExpenseRequests godoc
+// @Summary Get expense requests
+// @Description Get a page of expense requests: your own, or all for management.
+// @Description Pass nextCursor of a page as cursor to get the next one, keeping the same filters and sort.
+// @Tags expenses
+// @Produce json
+// @Param status query string false "Status filter (all, draft, pending, returned_for_changes, approved, scheduled_for_payment, paid, rejected, withdrawn)"
+// @Param category query string false "Category"
+// @Param vendor query string false "Vendor, substring match"
+// @Param employeeId query int false "Employee ID (with expenses.view_all)"
+// @Param reviewerId query int false "Reviewer ID"
+// @Param minAmount query number false "Minimum amount, inclusive"
+// @Param maxAmount query number false "Maximum amount, inclusive"
+// @Param from query string false "Expense date from, inclusive (YYYY-MM-DD)"
+// @Param to query string false "Expense date to, inclusive (YYYY-MM-DD)"
+// @Param sort query string false "Sort by, anomaly is the anomaly score" Enums(created, amount, reviewed, anomaly) default(created)
+// @Param order query string false "Sort order" Enums(asc, desc) default(desc)
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} models.ExpensePage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/expenses [get]
+// @Security BearerAuth
+func (h *ExpenseHandler) GetExpenseRequests(c *gin.Context) {
+	var dto models.ListExpensesDTO
+	if err := c.ShouldBindQuery(&dto); err != nil {
 		c.JSON(
-			http.StatusInternalServerError, ErrorResponse{
+			http.StatusBadRequest, ErrorResponse{
 				Error: err.Error(),
 			},
 		)
 		return
 	}
 
-	c.JSON(http.StatusOK, requests)
+	page, err := h.expenseService.ListExpenseRequests(
+		c.Request.Context(), &dto, c.GetUint("userID"), permissionsOf(c),
+	)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidExpenseList) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 }
 
 // GetExpenseRequest godoc
 // @Summary Get expense request by ID
-// @Description Get a specific expense request
+// @Description Get a specific expense request with its approval steps, attachments, policy results, flags and anomaly score
 // @Tags expenses
 // @Produce json
 // @Param id path int true "Expense request ID"
 // @Success 200 {object} models.ExpenseRequest
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/expenses/{id} [get]
 // @Security BearerAuth
@@ -136,22 +194,136 @@ func (h *ExpenseHandler) GetExpenseRequest(c *gin.Context) {
 		return
 	}
 
-	request, err := h.expenseService.GetExpenseRequest(c.Request.Context(), uint(id))
+	request, err := h.expenseService.GetExpenseRequest(
+		c.Request.Context(), uint(id), c.GetUint("userID"), permissionsOf(c),
+	)
 	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// UpdateExpenseRequest godoc
+// @Summary Edit expense request
+// @Description Change fields of your own draft, pending or returned expense request, omitted fields are kept.
+// @Description Every edit is stored as a revision and restarts the approval chain.
+// @Description A new amount or currency is converted to RUB with the rate of today.
+// @Description Pending requests are checked against the submit policy rules again.
+// @Tags expenses
+// @Accept json
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Param request body models.UpdateExpenseRequestDTO true "Changed fields"
+// @Success 200 {object} models.ExpenseRequest
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /api/expenses/{id} [patch]
+// @Security BearerAuth
+func (h *ExpenseHandler) UpdateExpenseRequest(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.UpdateExpenseRequestDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
 		c.JSON(
-			http.StatusNotFound, ErrorResponse{
-				Error: "request not found",
+			http.StatusBadRequest, ErrorResponse{
+				Error: err.Error(),
 			},
 		)
 		return
 	}
 
+	request, err := h.expenseService.UpdateExpenseRequest(c.Request.Context(), id, c.GetUint("userID"), &dto)
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, request)
 }
 
+// WithdrawExpenseRequest godoc
+// @Summary Withdraw expense request
+// @Description Cancel your own draft, pending or returned expense request
+// @Tags expenses
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/expenses/{id}/withdraw [post]
+// @Security BearerAuth
+func (h *ExpenseHandler) WithdrawExpenseRequest(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.expenseService.WithdrawExpenseRequest(
+		c.Request.Context(), id, c.GetUint("userID"), permissionsOf(c),
+	); err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "request withdrawn successfully"})
+}
+
+// GetExpenseRevisions godoc
+// @Summary Get expense request revisions
+// @Description Get the edits of an expense request (owner or management).
+// @Description Pass lastViewedRevision of the request as since to see only what changed since your previous visit.
+// @Tags expenses
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Param since query int false "Only revisions newer than this one"
+// @Success 200 {array} models.ExpenseRevision
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/expenses/{id}/revisions [get]
+// @Security BearerAuth
+func (h *ExpenseHandler) GetExpenseRevisions(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	since, err := strconv.Atoi(c.DefaultQuery("since", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid since"})
+		return
+	}
+
+	revisions, err := h.expenseService.GetExpenseRevisions(
+		c.Request.Context(), id, c.GetUint("userID"), permissionsOf(c), since,
+	)
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
 // UpdateExpenseRequestStatus godoc
 // @Summary Update expense request status
-// @Description Approve or reject an expense request (management only)
+// @Description Approve or reject the current approval step of an expense request (expenses.approve,
+// @Description or a delegation from a reviewer named in onBehalfOf).
+// @Description The request becomes approved once every step of its approval chain is approved.
+// @Description A step bound to a role can only be decided by holders of that role (403 otherwise).
+// @Description Segregation of duties violations fail with 403 and are audited.
+// @Description A missing required receipt fails with 409.
+// @Description Refusals by the policy rules of the approve stage fail with 422.
+// @Description The final approval fails with 409 when a budget of the request has not enough left.
 // @Tags expenses
 // @Accept json
 // @Produce json
@@ -160,6 +332,9 @@ func (h *ExpenseHandler) GetExpenseRequest(c *gin.Context) {
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Router /api/expenses/{id}/status [put]
 // @Security BearerAuth
 func (h *ExpenseHandler) UpdateExpenseRequestStatus(c *gin.Context) {
@@ -172,7 +347,6 @@ func (h *ExpenseHandler) UpdateExpenseRequestStatus(c *gin.Context) {
 		)
 		return
 	}
-	fmt.Println(id)
 
 	var dto models.UpdateExpenseStatusDTO
 	if err = c.ShouldBindJSON(&dto); err != nil {
@@ -183,20 +357,23 @@ func (h *ExpenseHandler) UpdateExpenseRequestStatus(c *gin.Context) {
 		)
 		return
 	}
-	fmt.Println(dto)
 
 	reviewerID := c.GetUint("userID")
-	fmt.Println(reviewerID)
+	perms := permissionsOf(c)
 
 	if dto.Status == models.StatusApproved {
-		err = h.expenseService.ApproveExpenseRequest(c.Request.Context(), uint(id), reviewerID, dto.Comments)
+		err = h.expenseService.ApproveExpenseRequest(
+			c.Request.Context(), uint(id), reviewerID, perms, dto.OnBehalfOf, dto.Comments,
+		)
 	} else {
-		err = h.expenseService.RejectExpenseRequest(c.Request.Context(), uint(id), reviewerID, dto.Comments)
+		err = h.expenseService.RejectExpenseRequest(
+			c.Request.Context(), uint(id), reviewerID, perms, dto.OnBehalfOf, dto.Comments,
+		)
 	}
 
 	if err != nil {
 		c.JSON(
-			http.StatusInternalServerError, ErrorResponse{
+			expenseErrorStatus(err), ErrorResponse{
 				Error: err.Error(),
 			},
 		)
@@ -206,16 +383,167 @@ func (h *ExpenseHandler) UpdateExpenseRequestStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, SuccessResponse{Message: "status updated successfully"})
 }
 
+// ReturnExpenseRequest godoc
+// @Summary Return expense request for changes
+// @Description Send a pending expense request back to its owner with comments (expenses.approve).
+// @Description The approval chain starts over on resubmit.
+// @Tags expenses
+// @Accept json
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Param request body models.ReturnExpenseDTO true "What has to be changed"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/expenses/{id}/return [post]
+// @Security BearerAuth
+func (h *ExpenseHandler) ReturnExpenseRequest(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.ReturnExpenseDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	err := h.expenseService.ReturnExpenseRequest(
+		c.Request.Context(), id, c.GetUint("userID"), permissionsOf(c), dto.Comments,
+	)
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "request returned for changes"})
+}
+
+// SubmitExpenseRequest godoc
+// @Summary Submit expense request
+// @Description Send your own draft or returned expense request to review.
+// @Description Every field required on creation has to be filled in by now.
+// @Description Category limits fail with 400 and a missing required receipt with 409.
+// @Description Refusals by the policy rules of the submit stage fail with 422.
+// @Description Requests covered by an auto-approval rule are approved at once.
+// @Tags expenses
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /api/expenses/{id}/submit [post]
+// @Security BearerAuth
+func (h *ExpenseHandler) SubmitExpenseRequest(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.expenseService.SubmitExpenseRequest(
+		c.Request.Context(), id, c.GetUint("userID"), permissionsOf(c),
+	)
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "request submitted successfully"})
+}
+
+// SchedulePayment godoc
+// @Summary Schedule payment
+// @Description Put an approved expense request on the payment schedule (expenses.pay)
+// @Tags expenses
+// @Accept json
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Param request body models.SchedulePaymentDTO true "Payment date"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/expenses/{id}/schedule-payment [post]
+// @Security BearerAuth
+func (h *ExpenseHandler) SchedulePayment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.SchedulePaymentDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	err := h.expenseService.SchedulePayment(
+		c.Request.Context(), id, c.GetUint("userID"), permissionsOf(c), dto.PaymentDate,
+	)
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "payment scheduled successfully"})
+}
+
+// MarkExpensePaid godoc
+// @Summary Mark expense request paid
+// @Description Record that a scheduled expense request has been paid out (expenses.pay)
+// @Tags expenses
+// @Produce json
+// @Param id path int true "Expense request ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/expenses/{id}/pay [post]
+// @Security BearerAuth
+func (h *ExpenseHandler) MarkExpensePaid(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.expenseService.MarkExpensePaid(
+		c.Request.Context(), id, c.GetUint("userID"), permissionsOf(c),
+	)
+	if err != nil {
+		c.JSON(expenseErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "request marked as paid"})
+}
+
 // GetStatistics godoc
 // @Summary Get expense statistics
-// @Description Get expense statistics (management only)
+// @Description Get expense statistics (reports.view).
+// @Description With breakdown departments lists every department with its sub-departments included.
+// @Description anomalies summarize the anomaly scores of pending requests and list the most unusual ones.
 // @Tags expenses
 // @Produce json
+// @Param breakdown query bool false "Break the statistics down by department"
 // @Success 200 {object} models.StatsResponse
+// @Failure 400 {object} ErrorResponse
 // @Router /api/expenses/statistics [get]
 // @Security BearerAuth
 func (h *ExpenseHandler) GetStatistics(c *gin.Context) {
-	stats, err := h.expenseService.GetStatistics(c.Request.Context())
+	breakdown, err := strconv.ParseBool(c.DefaultQuery("breakdown", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid breakdown"})
+		return
+	}
+
+	stats, err := h.expenseService.GetStatistics(c.Request.Context(), breakdown)
 	if err != nil {
 		c.JSON(
 			http.StatusInternalServerError, ErrorResponse{
@@ -228,6 +556,36 @@ func (h *ExpenseHandler) GetStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetSuspiciousRequests godoc
+// @Summary List suspicious expense requests
+// @Description Get the requests flagged as possible duplicates or parts of split purchases with their flags,
+// @Description the most recently flagged first (expenses.view_all)
+// @Tags expenses
+// @Produce json
+// @Param kind query string false "Only requests with flags of this kind" Enums(duplicate, split_purchase)
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param offset query int false "Number of requests to skip"
+// @Success 200 {object} models.ExpensePage
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/expenses/suspicious [get]
+// @Security BearerAuth
+func (h *ExpenseHandler) GetSuspiciousRequests(c *gin.Context) {
+	var dto models.ListSuspiciousDTO
+	if err := c.ShouldBindQuery(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := h.expenseService.ListSuspiciousRequests(c.Request.Context(), &dto)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 func (h *ExpenseHandler) GetTopExpenses(c *gin.Context) {
 	expenses, err := h.expenseService.GetTopExpenses(c.Request.Context())
 	if err != nil {
@@ -259,39 +617,37 @@ func (h *ExpenseHandler) GetTopExpenses(c *gin.Context) {
 	)
 }
 
-// BudgetHandler handles budget operations
-type BudgetHandler struct {
-	budgetService *service.BudgetService
-}
-
-func NewBudgetHandler(budgetService *service.BudgetService) *BudgetHandler {
-	return &BudgetHandler{
-		budgetService: budgetService,
+func expenseErrorStatus(err error) int {
+	var transitionErr *service.TransitionError
+	var dutyViolation *service.DutyViolation
+	var policyViolation *service.PolicyViolation
+	var budgetExceeded *service.BudgetExceeded
+	switch {
+	case errors.Is(err, service.ErrRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRequestIncomplete),
+		errors.Is(err, service.ErrUnknownCategory),
+		errors.Is(err, service.ErrCategoryLimit),
+		errors.Is(err, service.ErrNoExchangeRate):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrReceiptRequired):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrRequestNotEditable),
+		errors.Is(err, service.ErrRequestAccessDenied),
+		errors.Is(err, service.ErrNotDelegated),
+		errors.Is(err, service.ErrStepRoleRequired):
+		return http.StatusForbidden
+	case errors.As(err, &dutyViolation):
+		return http.StatusForbidden
+	case errors.As(err, &policyViolation):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &transitionErr), errors.As(err, &budgetExceeded):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
-// GetCurrentBudget godoc
-// @Summary Get current budget
-// @Description Get budget for current month
-// @Tags budget
-// @Produce json
-// @Success 200 {object} models.Budget
-// @Router /api/budget/current [get]
-// @Security BearerAuth
-func (h *BudgetHandler) GetCurrentBudget(c *gin.Context) {
-	budget, err := h.budgetService.GetCurrentBudget(c.Request.Context())
-	if err != nil {
-		c.JSON(
-			http.StatusInternalServerError, ErrorResponse{
-				Error: err.Error(),
-			},
-		)
-		return
-	}
-
-	c.JSON(http.StatusOK, budget)
-}
-
 // ErrorResponse Response types
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"curswork-trpo/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInvitation godoc
+// @Summary Create invitation
+// @Description Invite an email to register with a role (users.manage).
+// @Description The token is returned once; it works for a single registration until it expires.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body models.CreateInvitationDTO true "Invitation data"
+// @Success 201 {object} models.InvitationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/invitations [post]
+// @Security BearerAuth
+func (h *AuthHandler) CreateInvitation(c *gin.Context) {
+	var dto models.CreateInvitationDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	invitedBy := c.GetUint("userID")
+	invitation, err := h.userService.CreateInvitation(c.Request.Context(), &dto, &invitedBy)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// ListInvitations godoc
+// @Summary List invitations
+// @Description Get all invitations, newest first (users.manage)
+// @Tags invitations
+// @Produce json
+// @Success 200 {array} models.Invitation
+// @Failure 403 {object} ErrorResponse
+// @Router /api/invitations [get]
+// @Security BearerAuth
+func (h *AuthHandler) ListInvitations(c *gin.Context) {
+	invitations, err := h.userService.ListInvitations(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
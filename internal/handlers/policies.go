@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyHandler handles the policy rules evaluated against expense requests
+type PolicyHandler struct {
+	policyService *service.PolicyService
+}
+
+func NewPolicyHandler(policyService *service.PolicyService) *PolicyHandler {
+	return &PolicyHandler{policyService: policyService}
+}
+
+// ListPolicyRules godoc
+// @Summary List policy rules
+// @Description Get the policy rules in the order they are evaluated (policies.manage)
+// @Tags policies
+// @Produce json
+// @Param all query bool false "Include inactive rules"
+// @Success 200 {array} models.PolicyRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/policies [get]
+// @Security BearerAuth
+func (h *PolicyHandler) ListPolicyRules(c *gin.Context) {
+	all, err := strconv.ParseBool(c.DefaultQuery("all", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid all"})
+		return
+	}
+
+	rules, err := h.policyService.ListPolicyRules(c.Request.Context(), all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetPolicyRule godoc
+// @Summary Get policy rule
+// @Description Get the current version of a policy rule (policies.manage)
+// @Tags policies
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {object} models.PolicyRule
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/policies/{id} [get]
+// @Security BearerAuth
+func (h *PolicyHandler) GetPolicyRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	rule, err := h.policyService.GetPolicyRule(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(policyErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetPolicyRuleVersions godoc
+// @Summary Get policy rule versions
+// @Description Get every version of a policy rule (policies.manage)
+// @Tags policies
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Success 200 {array} models.PolicyRuleVersion
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/policies/{id}/versions [get]
+// @Security BearerAuth
+func (h *PolicyHandler) GetPolicyRuleVersions(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	versions, err := h.policyService.GetPolicyRuleVersions(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(policyErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// CreatePolicyRule godoc
+// @Summary Create policy rule
+// @Description Add a policy rule evaluated on submit, on approval or on both (policies.manage).
+// @Description A rule fires when its "when" condition matches a request: reject refuses the request,
+// @Description require refuses it unless the "require" condition holds too, warn only notes it for reviewers.
+// @Tags policies
+// @Accept json
+// @Produce json
+// @Param request body models.PolicyRuleDTO true "Rule data"
+// @Success 201 {object} models.PolicyRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/policies [post]
+// @Security BearerAuth
+func (h *PolicyHandler) CreatePolicyRule(c *gin.Context) {
+	var dto models.PolicyRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rule, err := h.policyService.CreatePolicyRule(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(policyErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdatePolicyRule godoc
+// @Summary Update policy rule
+// @Description Change a policy rule into its next version, or deactivate it with isActive (policies.manage)
+// @Tags policies
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param request body models.PolicyRuleDTO true "Rule data"
+// @Success 200 {object} models.PolicyRule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/policies/{id} [put]
+// @Security BearerAuth
+func (h *PolicyHandler) UpdatePolicyRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.PolicyRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rule, err := h.policyService.UpdatePolicyRule(c.Request.Context(), id, &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(policyErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func policyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrPolicyRuleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidPolicyRule):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
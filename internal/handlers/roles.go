@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler handles roles and permissions
+type RoleHandler struct {
+	roleService *service.RoleService
+}
+
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Description Get every permission a role may grant (users.manage)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.PermissionInfo
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/permissions [get]
+// @Security BearerAuth
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description Get all roles with their permissions, built-in ones first (users.manage)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Role
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/roles [get]
+// @Security BearerAuth
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole godoc
+// @Summary Get role
+// @Description Get a role with its permissions (users.manage)
+// @Tags admin
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} models.Role
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/roles/{name} [get]
+// @Security BearerAuth
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	role, err := h.roleService.GetRole(c.Request.Context(), models.UserRole(c.Param("name")))
+	if err != nil {
+		c.JSON(roleErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Define a new role as a set of permissions, e.g. accountant or auditor (users.manage)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.CreateRoleDTO true "Role data"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/admin/roles [post]
+// @Security BearerAuth
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var dto models.CreateRoleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), &dto, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(roleErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Change the description and replace the permissions of a role, holders get them on their next request (users.manage)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param request body models.UpdateRoleDTO true "Role data"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/roles/{name} [put]
+// @Security BearerAuth
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var dto models.UpdateRoleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(
+		c.Request.Context(), models.UserRole(c.Param("name")), &dto, c.GetUint("userID"),
+	)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Delete a custom role that no user or open invitation uses (users.manage)
+// @Tags admin
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/admin/roles/{name} [delete]
+// @Security BearerAuth
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	if err := h.roleService.DeleteRole(
+		c.Request.Context(), models.UserRole(c.Param("name")), c.GetUint("userID"),
+	); err != nil {
+		c.JSON(roleErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "role deleted successfully"})
+}
+
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRoleExists),
+		errors.Is(err, service.ErrRoleBuiltIn),
+		errors.Is(err, service.ErrRoleInUse):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUnknownPermission), errors.Is(err, service.ErrAdminLockout):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
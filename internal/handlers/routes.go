@@ -3,6 +3,7 @@ package handlers
 import (
 	"curswork-trpo/internal/middleware"
 	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,11 +15,24 @@ func SetupRouter(
 	expenseHandler *ExpenseHandler,
 	authHandler *AuthHandler,
 	budgetHandler *BudgetHandler,
+	attachmentHandler *AttachmentHandler,
+	auditHandler *AuditHandler,
+	userHandler *UserHandler,
+	roleHandler *RoleHandler,
+	delegationHandler *DelegationHandler,
+	departmentHandler *DepartmentHandler,
+	categoryHandler *CategoryHandler,
+	policyHandler *PolicyHandler,
+	autoApprovalHandler *AutoApprovalHandler,
+	exchangeRateHandler *ExchangeRateHandler,
+	sessionService *service.SessionService,
+	roleService *service.RoleService,
 ) *gin.Engine {
 	router := gin.Default()
 
 	// Middleware
 	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
 	router.Use(gin.Recovery())
 
 	// Health check
@@ -27,6 +41,8 @@ func SetupRouter(
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	authRequired := middleware.AuthMiddleware(sessionService, roleService)
+
 	// API routes
 	api := router.Group("/api")
 	{
@@ -35,41 +51,224 @@ func SetupRouter(
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
-			auth.GET("/me", middleware.AuthMiddleware(), authHandler.GetCurrentUser)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authRequired, authHandler.Logout)
+			auth.GET("/me", authRequired, authHandler.GetCurrentUser)
 		}
 
 		// Expense routes (protected)
 		expenses := api.Group("/expenses")
-		expenses.Use(middleware.AuthMiddleware())
+		expenses.Use(authRequired)
 		{
 			expenses.POST("", expenseHandler.CreateExpenseRequest)
+			expenses.POST("/drafts", expenseHandler.CreateDraftExpenseRequest)
 			expenses.GET("", expenseHandler.GetExpenseRequests)
 			expenses.GET("/:id", expenseHandler.GetExpenseRequest)
+			expenses.PATCH("/:id", expenseHandler.UpdateExpenseRequest)
+			expenses.POST("/:id/withdraw", expenseHandler.WithdrawExpenseRequest)
+			expenses.GET("/:id/revisions", expenseHandler.GetExpenseRevisions)
+			expenses.POST("/:id/submit", expenseHandler.SubmitExpenseRequest)
+
+			// Receipts and invoices
+			expenses.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+			expenses.GET("/:id/attachments", attachmentHandler.ListAttachments)
+			expenses.GET("/:id/attachments/:attachmentId", attachmentHandler.DownloadAttachment)
+			expenses.DELETE("/:id/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
 
-			// Management only routes
-			expenses.PUT(
-				"/:id/status",
-				middleware.RoleMiddleware(models.RoleManagement),
-				expenseHandler.UpdateExpenseRequestStatus,
+			// Review and payment routes.
+			// Decisions are checked in the service as delegates may decide without expenses.approve.
+			expenses.PUT("/:id/status", expenseHandler.UpdateExpenseRequestStatus)
+			expenses.POST(
+				"/:id/return",
+				middleware.RequirePermission(models.PermExpensesApprove),
+				expenseHandler.ReturnExpenseRequest,
+			)
+			expenses.POST(
+				"/:id/schedule-payment",
+				middleware.RequirePermission(models.PermExpensesPay),
+				expenseHandler.SchedulePayment,
+			)
+			expenses.POST(
+				"/:id/pay",
+				middleware.RequirePermission(models.PermExpensesPay),
+				expenseHandler.MarkExpensePaid,
 			)
 			expenses.GET(
 				"/statistics",
-				middleware.RoleMiddleware(models.RoleManagement),
+				middleware.RequirePermission(models.PermReportsView),
 				expenseHandler.GetStatistics,
 			)
+			expenses.GET(
+				"/suspicious",
+				middleware.RequirePermission(models.PermExpensesViewAll),
+				expenseHandler.GetSuspiciousRequests,
+			)
+		}
+
+		// Approval delegations
+		delegations := api.Group("/delegations")
+		delegations.Use(authRequired)
+		{
+			delegations.POST(
+				"",
+				middleware.RequirePermission(models.PermExpensesApprove),
+				delegationHandler.CreateDelegation,
+			)
+			delegations.GET("", delegationHandler.ListDelegations)
+			delegations.DELETE("/:id", delegationHandler.RevokeDelegation)
 		}
 
 		reports := api.Group("/reports/expenses")
-		reports.Use(middleware.AuthMiddleware())
+		reports.Use(authRequired, middleware.RequirePermission(models.PermReportsView))
 		{
 			reports.GET("", expenseHandler.GetTopExpenses)
 		}
 
 		// Budget routes (protected)
 		budget := api.Group("/budget")
-		budget.Use(middleware.AuthMiddleware())
+		budget.Use(authRequired)
 		{
 			budget.GET("/current", budgetHandler.GetCurrentBudget)
+			budget.GET("/:year/:month", budgetHandler.GetBudget)
+
+			budget.GET(
+				"",
+				middleware.RequirePermission(models.PermBudgetView),
+				budgetHandler.ListBudgets,
+			)
+			budget.POST(
+				"",
+				middleware.RequirePermission(models.PermBudgetEdit),
+				budgetHandler.CreateBudget,
+			)
+			budget.PUT(
+				"/:year/:month",
+				middleware.RequirePermission(models.PermBudgetEdit),
+				budgetHandler.UpdateBudget,
+			)
+			budget.GET(
+				"/:year/:month/history",
+				middleware.RequirePermission(models.PermBudgetView),
+				budgetHandler.GetBudgetHistory,
+			)
+		}
+
+		// Expense category registry, anyone may read it
+		categories := api.Group("/categories")
+		categories.Use(authRequired)
+		{
+			categories.GET("", categoryHandler.ListCategories)
+			categories.GET("/:id", categoryHandler.GetCategory)
+
+			categories.POST(
+				"",
+				middleware.RequirePermission(models.PermCategoryManage),
+				categoryHandler.CreateCategory,
+			)
+			categories.PUT(
+				"/:id",
+				middleware.RequirePermission(models.PermCategoryManage),
+				categoryHandler.UpdateCategory,
+			)
+			categories.DELETE(
+				"/:id",
+				middleware.RequirePermission(models.PermCategoryManage),
+				categoryHandler.DeleteCategory,
+			)
+		}
+
+		// Departments and cost centers, anyone may read the tree
+		departments := api.Group("/departments")
+		departments.Use(authRequired)
+		{
+			departments.GET("", departmentHandler.ListDepartments)
+			departments.GET("/:id", departmentHandler.GetDepartment)
+
+			departments.POST(
+				"",
+				middleware.RequirePermission(models.PermUsersManage),
+				departmentHandler.CreateDepartment,
+			)
+			departments.PUT(
+				"/:id",
+				middleware.RequirePermission(models.PermUsersManage),
+				departmentHandler.UpdateDepartment,
+			)
+			departments.DELETE(
+				"/:id",
+				middleware.RequirePermission(models.PermUsersManage),
+				departmentHandler.DeleteDepartment,
+			)
+		}
+
+		// Audit log
+		audit := api.Group("/audit")
+		audit.Use(authRequired, middleware.RequirePermission(models.PermAuditView))
+		{
+			audit.GET("", auditHandler.ListAuditEntries)
+			audit.GET("/verify", auditHandler.VerifyAuditChain)
+		}
+
+		// Invitations
+		invitations := api.Group("/invitations")
+		invitations.Use(authRequired, middleware.RequirePermission(models.PermUsersManage))
+		{
+			invitations.POST("", authHandler.CreateInvitation)
+			invitations.GET("", authHandler.ListInvitations)
+		}
+
+		// User and role administration
+		admin := api.Group("/admin")
+		admin.Use(authRequired, middleware.RequirePermission(models.PermUsersManage))
+		{
+			admin.GET("/permissions", roleHandler.ListPermissions)
+			admin.GET("/roles", roleHandler.ListRoles)
+			admin.POST("/roles", roleHandler.CreateRole)
+			admin.GET("/roles/:name", roleHandler.GetRole)
+			admin.PUT("/roles/:name", roleHandler.UpdateRole)
+			admin.DELETE("/roles/:name", roleHandler.DeleteRole)
+		}
+
+		// Policy rules, managed apart from users and roles
+		policies := api.Group("/admin/policies")
+		policies.Use(authRequired, middleware.RequirePermission(models.PermPolicyManage))
+		{
+			policies.GET("", policyHandler.ListPolicyRules)
+			policies.POST("", policyHandler.CreatePolicyRule)
+			policies.GET("/:id", policyHandler.GetPolicyRule)
+			policies.PUT("/:id", policyHandler.UpdatePolicyRule)
+			policies.GET("/:id/versions", policyHandler.GetPolicyRuleVersions)
+		}
+
+		autoApproval := api.Group("/admin/auto-approval-rules")
+		autoApproval.Use(authRequired, middleware.RequirePermission(models.PermPolicyManage))
+		{
+			autoApproval.GET("", autoApprovalHandler.ListAutoApprovalRules)
+			autoApproval.POST("", autoApprovalHandler.CreateAutoApprovalRule)
+			autoApproval.GET("/:id", autoApprovalHandler.GetAutoApprovalRule)
+			autoApproval.PUT("/:id", autoApprovalHandler.UpdateAutoApprovalRule)
+			autoApproval.DELETE("/:id", autoApprovalHandler.DeleteAutoApprovalRule)
+		}
+
+		exchangeRates := api.Group("/admin/exchange-rates")
+		exchangeRates.Use(authRequired, middleware.RequirePermission(models.PermRatesManage))
+		{
+			exchangeRates.GET("", exchangeRateHandler.ListExchangeRates)
+			exchangeRates.PUT("", exchangeRateHandler.SetExchangeRate)
+			exchangeRates.POST("/import", exchangeRateHandler.ImportExchangeRates)
+		}
+
+		users := admin.Group("/users")
+		{
+			users.GET("", userHandler.ListUsers)
+			users.GET("/:id", userHandler.GetUser)
+			users.PUT("/:id/role", userHandler.ChangeRole)
+			users.PUT("/:id/roles", userHandler.SetUserRoles)
+			users.PUT("/:id/department", userHandler.SetUserDepartment)
+			users.PUT("/:id/trust-level", userHandler.SetUserTrustLevel)
+			users.POST("/:id/deactivate", userHandler.DeactivateUser)
+			users.POST("/:id/activate", userHandler.ActivateUser)
+			users.POST("/:id/password", userHandler.ResetPassword)
 		}
 	}
 
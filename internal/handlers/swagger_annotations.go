@@ -9,6 +9,7 @@ type SwaggerUser struct {
 	FirstName string `json:"firstName" example:"Иван"`
 	LastName  string `json:"lastName" example:"Иванов"`
 	Role      string `json:"role" example:"employee"`
+	IsActive  bool   `json:"isActive" example:"true"`
 } // @name User
 
 // @Description Expense Request model
@@ -28,16 +29,55 @@ type SwaggerExpenseRequest struct {
 	CreatedAt   string       `json:"createdAt" example:"2025-01-15T10:30:00Z"`
 	UpdatedAt   string       `json:"updatedAt" example:"2025-01-15T10:30:00Z"`
 	ReviewedAt  *string      `json:"reviewedAt,omitempty" example:"2025-01-16T14:20:00Z"`
+	ExpenseDate string       `json:"expenseDate" example:"2025-01-14T00:00:00Z"`
+	Revision    int          `json:"revision" example:"2"`
+	PaymentDate *string      `json:"paymentDate,omitempty" example:"2025-01-20T00:00:00Z"`
+	PaidAt      *string      `json:"paidAt,omitempty" example:"2025-01-20T09:00:00Z"`
+
+	LastViewedRevision *int `json:"lastViewedRevision,omitempty" example:"1"`
+
+	ApprovalSteps []SwaggerApprovalStep `json:"approvalSteps,omitempty"`
+	Attachments   []SwaggerAttachment   `json:"attachments,omitempty"`
 } // @name ExpenseRequest
 
+// @Description Expense request attachment
+type SwaggerAttachment struct {
+	ID          uint   `json:"id" example:"1"`
+	RequestID   uint   `json:"requestId" example:"1"`
+	FileName    string `json:"fileName" example:"receipt.pdf"`
+	ContentType string `json:"contentType" example:"application/pdf"`
+	Size        int64  `json:"size" example:"48213"`
+	Checksum    string `json:"checksum" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	UploadedBy  uint   `json:"uploadedBy" example:"1"`
+	CreatedAt   string `json:"createdAt" example:"2025-01-15T10:35:00Z"`
+} // @name Attachment
+
+// @Description Approval chain step
+type SwaggerApprovalStep struct {
+	ID         uint         `json:"id" example:"1"`
+	RequestID  uint         `json:"requestId" example:"1"`
+	StepOrder  int          `json:"stepOrder" example:"2"`
+	Name       string       `json:"name" example:"finance"`
+	MinAmount  float64      `json:"minAmount" example:"50000"`
+	Status     string       `json:"status" example:"approved"`
+	ReviewerID *uint        `json:"reviewerId,omitempty" example:"2"`
+	Reviewer   *SwaggerUser `json:"reviewer,omitempty"`
+	Comments   string       `json:"comments,omitempty" example:"Согласовано финансовым отделом"`
+	DecidedAt  *string      `json:"decidedAt,omitempty" example:"2025-01-16T14:20:00Z"`
+	CreatedAt  string       `json:"createdAt" example:"2025-01-15T10:30:00Z"`
+} // @name ApprovalStep
+
 // @Description Budget model
 type SwaggerBudget struct {
-	ID        uint    `json:"id" example:"1"`
-	Year      int     `json:"year" example:"2025"`
-	Month     int     `json:"month" example:"1"`
-	Total     float64 `json:"total" example:"100000"`
-	Spent     float64 `json:"spent" example:"28500"`
-	Remaining float64 `json:"remaining" example:"71500"`
+	ID          uint    `json:"id" example:"1"`
+	PeriodType  string  `json:"periodType" example:"monthly"`
+	Year        int     `json:"year" example:"2025"`
+	Month       int     `json:"month" example:"1"`
+	PeriodStart string  `json:"periodStart" example:"2025-01-01T00:00:00Z"`
+	PeriodEnd   string  `json:"periodEnd" example:"2025-01-31T00:00:00Z"`
+	Total       float64 `json:"total" example:"100000"`
+	Spent       float64 `json:"spent" example:"28500"`
+	Remaining   float64 `json:"remaining" example:"71500"`
 } // @name Budget
 
 // @Description Statistics response
@@ -57,6 +97,7 @@ type SwaggerCreateExpenseRequestDTO struct {
 	Amount      float64 `json:"amount" example:"45000" binding:"required,gt=0"`
 	Vendor      string  `json:"vendor" example:"IKEA" binding:"required,min=2"`
 	Description string  `json:"description" example:"Необходимо приобрести 3 рабочих стола" binding:"required,min=10"`
+	ExpenseDate string  `json:"expenseDate" example:"2025-01-14" binding:"omitempty,datetime=2006-01-02"`
 } // @name CreateExpenseRequestDTO
 
 // @Description Update expense status DTO
@@ -71,7 +112,8 @@ type SwaggerRegisterUserDTO struct {
 	Password  string `json:"password" example:"password123" binding:"required,min=6"`
 	FirstName string `json:"firstName" example:"Иван" binding:"required"`
 	LastName  string `json:"lastName" example:"Иванов" binding:"required"`
-	Role      string `json:"role" example:"employee" binding:"required,oneof=employee management"`
+
+	InvitationToken string `json:"invitationToken,omitempty" example:"kJ3nV8pQ2xR7tY1wZ5cB9mL4hF6dS0aG3eU8iO2rT7y"`
 } // @name RegisterUserDTO
 
 // @Description Login DTO
@@ -82,6 +124,15 @@ type SwaggerLoginDTO struct {
 
 // @Description Login response
 type SwaggerLoginResponse struct {
-	Token string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  SwaggerUser `json:"user"`
+	Token        string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string      `json:"refreshToken" example:"q3Xv0n9k2tYb8WcR1mP6sJ4dLz7hE5aF0uGiKoNpQyM"`
+	ExpiresAt    string      `json:"expiresAt" example:"2025-01-15T10:45:00Z"`
+	User         SwaggerUser `json:"user"`
 } // @name LoginResponse
+
+// @Description Refresh response
+type SwaggerTokenResponse struct {
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refreshToken" example:"Zr8mT1cV5xN0bQ7wK2jH9fD4sA6gL3pYeUiOkMnBvCx"`
+	ExpiresAt    string `json:"expiresAt" example:"2025-01-15T10:45:00Z"`
+} // @name TokenResponse
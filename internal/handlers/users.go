@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler handles user administration
+type UserHandler struct {
+	userService *service.UserService
+}
+
+func NewUserHandler(userService *service.UserService) *UserHandler {
+	return &UserHandler{userService: userService}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List and search users ordered by name (users.manage)
+// @Tags admin
+// @Produce json
+// @Param q query string false "Part of the email or full name"
+// @Param role query string false "Primary or additional role"
+// @Param active query bool false "Only active or only deactivated users"
+// @Param department query int false "Department ID, sub-departments are not included"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {object} models.UserPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/users [get]
+// @Security BearerAuth
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var dto models.ListUsersDTO
+	if err := c.ShouldBindQuery(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := h.userService.ListUsers(c.Request.Context(), &dto)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUser godoc
+// @Summary Get user
+// @Description Get a user by ID (users.manage)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id} [get]
+// @Security BearerAuth
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangeRole godoc
+// @Summary Change user role
+// @Description Give a user another primary role (users.manage)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.ChangeRoleDTO true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/role [put]
+// @Security BearerAuth
+func (h *UserHandler) ChangeRole(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.ChangeRoleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.userService.ChangeRole(c.Request.Context(), id, c.GetUint("userID"), dto.Role)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserRoles godoc
+// @Summary Set additional user roles
+// @Description Replace the additional roles of a user (users.manage)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.SetUserRolesDTO true "Additional roles"
+// @Success 200 {object} models.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/roles [put]
+// @Security BearerAuth
+func (h *UserHandler) SetUserRoles(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.SetUserRolesDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.userService.SetRoles(c.Request.Context(), id, c.GetUint("userID"), dto.Roles)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserDepartment godoc
+// @Summary Set user department
+// @Description Move a user to a department.
+// @Description A null department takes the user out of any (users.manage).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.SetUserDepartmentDTO true "Department"
+// @Success 200 {object} models.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/department [put]
+// @Security BearerAuth
+func (h *UserHandler) SetUserDepartment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.SetUserDepartmentDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.userService.SetDepartment(c.Request.Context(), id, c.GetUint("userID"), dto.DepartmentID)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserTrustLevel godoc
+// @Summary Set user trust level
+// @Description Change how far a user is trusted, from 0 to 5; auto-approval rules may ask for a minimum (users.manage)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.SetTrustLevelDTO true "Trust level"
+// @Success 200 {object} models.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/trust-level [put]
+// @Security BearerAuth
+func (h *UserHandler) SetUserTrustLevel(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.SetTrustLevelDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := h.userService.SetTrustLevel(c.Request.Context(), id, c.GetUint("userID"), *dto.TrustLevel)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeactivateUser godoc
+// @Summary Deactivate user
+// @Description Block a user from logging in and revoke their sessions (users.manage)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/deactivate [post]
+// @Security BearerAuth
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// ActivateUser godoc
+// @Summary Reactivate user
+// @Description Let a deactivated user log in again (users.manage)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/activate [post]
+// @Security BearerAuth
+func (h *UserHandler) ActivateUser(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+func (h *UserHandler) setActive(c *gin.Context, active bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.SetActive(c.Request.Context(), id, c.GetUint("userID"), active)
+	if err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResetPassword godoc
+// @Summary Reset user password
+// @Description Set a new password for a user (users.manage)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.ResetPasswordDTO true "New password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/admin/users/{id}/password [post]
+// @Security BearerAuth
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto models.ResetPasswordDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), id, c.GetUint("userID"), dto.Password); err != nil {
+		c.JSON(userErrorStatus(err), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Message: "password reset successfully"})
+}
+
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrDepartmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSelfAdministration), errors.Is(err, service.ErrSystemUser):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrUnknownRole):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
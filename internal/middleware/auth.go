@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,6 +11,8 @@ import (
 	"time"
 
 	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/reqctx"
+	"curswork-trpo/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -16,25 +20,41 @@ import (
 
 var jwtSecret = []byte(getEnv("JWT_SECRET", "your-secret-key"))
 
+// accessTokenTTL is short so a revoked session stops working soon
+var accessTokenTTL = parseDuration(getEnv("ACCESS_TOKEN_TTL", "15m"), 15*time.Minute)
+
+// Claims carry no roles as permissions are read on every request
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(userID uint, role string) (string, error) {
+// GenerateToken generates a short-lived JWT access token of a session and returns its expiry
+func GenerateToken(userID uint, sessionID string) (string, time.Time, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL)
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        hex.EncodeToString(tokenID),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	signed, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
 // ValidateToken validates a JWT token
@@ -59,8 +79,9 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// AuthMiddleware validates JWT token
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates JWT token, makes sure its session has not been revoked
+// and loads the permissions of the user
+func AuthMiddleware(sessions *service.SessionService, roles *service.RoleService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -85,7 +106,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		claims, err := ValidateToken(tokenString)
-		if err != nil {
+		if err != nil || claims.SessionID == "" {
 			c.JSON(
 				http.StatusUnauthorized, gin.H{
 					"error": "invalid token",
@@ -95,20 +116,52 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		active, err := sessions.IsSessionActive(c.Request.Context(), claims.SessionID)
+		if err != nil {
+			c.JSON(
+				http.StatusInternalServerError, gin.H{
+					"error": "failed to check session",
+				},
+			)
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(
+				http.StatusUnauthorized, gin.H{
+					"error": "token revoked",
+				},
+			)
+			c.Abort()
+			return
+		}
+
+		permissions, err := roles.PermissionsOf(c.Request.Context(), claims.UserID)
+		if err != nil {
+			c.JSON(
+				http.StatusInternalServerError, gin.H{
+					"error": "failed to load permissions",
+				},
+			)
+			c.Abort()
+			return
+		}
+
 		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("permissions", permissions)
 		c.Next()
 	}
 }
 
-// RoleMiddleware checks if user has required role
-func RoleMiddleware(requiredRole models.UserRole) gin.HandlerFunc {
+// RequirePermission checks that one of the roles of the user grants the permission
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := c.GetString("userRole")
-		if userRole != string(requiredRole) {
+		permissions, _ := c.Get("permissions")
+		if set, ok := permissions.(models.PermissionSet); !ok || !set.Has(permission) {
 			c.JSON(
 				http.StatusForbidden, gin.H{
-					"error": "insufficient permissions",
+					"error": "insufficient permissions: " + string(permission) + " required",
 				},
 			)
 			c.Abort()
@@ -125,7 +178,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set(
 			"Access-Control-Allow-Headers",
-			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With",
+			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID",
 		)
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
@@ -138,6 +191,27 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware tags every request with an ID, taken from the client or generated,
+// so audit records of one API call can be tied together
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			random := make([]byte, 16)
+			if _, err := rand.Read(random); err == nil {
+				requestID = hex.EncodeToString(random)
+			}
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(reqctx.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
 // LoggerMiddleware logs requests
 func LoggerMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(
@@ -153,3 +227,11 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func parseDuration(value string, defaultValue time.Duration) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
@@ -0,0 +1,10 @@
+-- CASCADE also drops audit_log_hash, which takes an audit_log row
+DROP TABLE IF EXISTS audit_log CASCADE;
+DROP FUNCTION IF EXISTS audit_log_chain();
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP TABLE IF EXISTS expense_attachments;
+DROP TABLE IF EXISTS budget_history;
+DROP TABLE IF EXISTS expense_approval_steps;
+DROP TABLE IF EXISTS budgets;
+DROP TABLE IF EXISTS expense_requests;
+DROP TABLE IF EXISTS users;
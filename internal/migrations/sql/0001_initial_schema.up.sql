@@ -0,0 +1,184 @@
+-- Schema as created by initSchema before versioned migrations existed.
+-- Every statement is idempotent so databases set up by initSchema are adopted as is.
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	first_name VARCHAR(100) NOT NULL,
+	last_name VARCHAR(100) NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS expense_requests (
+	id SERIAL PRIMARY KEY,
+	title VARCHAR(255) NOT NULL,
+	category VARCHAR(100) NOT NULL,
+	amount DECIMAL(10, 2) NOT NULL,
+	vendor VARCHAR(255) NOT NULL,
+	description TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	employee_id INTEGER NOT NULL REFERENCES users(id),
+	reviewer_id INTEGER REFERENCES users(id),
+	comments TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	reviewed_at TIMESTAMP,
+	expense_date DATE NOT NULL DEFAULT CURRENT_DATE
+);
+
+ALTER TABLE expense_requests ADD COLUMN IF NOT EXISTS expense_date DATE;
+UPDATE expense_requests SET expense_date = created_at::date WHERE expense_date IS NULL;
+ALTER TABLE expense_requests ALTER COLUMN expense_date SET NOT NULL;
+ALTER TABLE expense_requests ALTER COLUMN expense_date SET DEFAULT CURRENT_DATE;
+
+CREATE TABLE IF NOT EXISTS budgets (
+	id SERIAL PRIMARY KEY,
+	period_type VARCHAR(20) NOT NULL DEFAULT 'monthly',
+	year INTEGER NOT NULL,
+	month INTEGER NOT NULL,
+	period_start DATE NOT NULL,
+	period_end DATE NOT NULL,
+	total DECIMAL(12, 2) NOT NULL,
+	spent DECIMAL(12, 2) NOT NULL DEFAULT 0,
+	remaining DECIMAL(12, 2) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS period_type VARCHAR(20) NOT NULL DEFAULT 'monthly';
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS period_start DATE;
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS period_end DATE;
+UPDATE budgets
+SET period_start = make_date(year, month, 1),
+    period_end = (make_date(year, month, 1) + INTERVAL '1 month' - INTERVAL '1 day')::date
+WHERE period_start IS NULL;
+ALTER TABLE budgets ALTER COLUMN period_start SET NOT NULL;
+ALTER TABLE budgets ALTER COLUMN period_end SET NOT NULL;
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_year_month_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_period ON budgets(period_type, year, month);
+
+CREATE TABLE IF NOT EXISTS expense_approval_steps (
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL REFERENCES expense_requests(id) ON DELETE CASCADE,
+	step_order INTEGER NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	min_amount DECIMAL(12, 2) NOT NULL DEFAULT 0,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	reviewer_id INTEGER REFERENCES users(id),
+	comments TEXT,
+	decided_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE(request_id, step_order)
+);
+
+CREATE TABLE IF NOT EXISTS budget_history (
+	id SERIAL PRIMARY KEY,
+	budget_id INTEGER NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+	action VARCHAR(20) NOT NULL,
+	amount DECIMAL(12, 2) NOT NULL,
+	total DECIMAL(12, 2) NOT NULL,
+	spent DECIMAL(12, 2) NOT NULL,
+	remaining DECIMAL(12, 2) NOT NULL,
+	user_id INTEGER REFERENCES users(id),
+	expense_request_id INTEGER REFERENCES expense_requests(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS expense_attachments (
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL REFERENCES expense_requests(id) ON DELETE CASCADE,
+	file_name VARCHAR(255) NOT NULL,
+	content_type VARCHAR(100) NOT NULL,
+	size BIGINT NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	storage_key VARCHAR(512) NOT NULL UNIQUE,
+	uploaded_by INTEGER NOT NULL REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	actor_id INTEGER REFERENCES users(id),
+	action VARCHAR(50) NOT NULL,
+	entity_type VARCHAR(50) NOT NULL,
+	entity_id INTEGER NOT NULL,
+	before JSONB,
+	after JSONB,
+	request_id VARCHAR(64),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS prev_hash CHAR(64);
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS hash CHAR(64);
+
+-- Hash of an audit record: its content chained to the hash of the previous record
+CREATE OR REPLACE FUNCTION audit_log_hash(entry audit_log) RETURNS CHAR(64) AS $$
+	SELECT encode(sha256(convert_to(
+		coalesce(entry.prev_hash, '') || '|' || entry.id || '|' || coalesce(entry.actor_id::text, '') || '|' ||
+		entry.action || '|' || entry.entity_type || '|' || entry.entity_id || '|' ||
+		coalesce(entry.before::text, '') || '|' || coalesce(entry.after::text, '') || '|' ||
+		coalesce(entry.request_id, '') || '|' || to_char(entry.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'),
+		'UTF8'
+	)), 'hex');
+$$ LANGUAGE sql STABLE;
+
+-- Inserts are serialized so every record links to the one committed before it.
+-- The ID is taken under the lock to keep the chain in ID order.
+CREATE OR REPLACE FUNCTION audit_log_chain() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('audit_log'));
+	NEW.id := nextval(pg_get_serial_sequence('audit_log', 'id'));
+	SELECT hash INTO NEW.prev_hash FROM audit_log ORDER BY id DESC LIMIT 1;
+	NEW.hash := audit_log_hash(NEW);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The audit log is append-only
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+
+-- Chain records written before hashing existed
+DO $$
+DECLARE
+	entry audit_log;
+	prev CHAR(64);
+BEGIN
+	IF EXISTS (SELECT 1 FROM audit_log WHERE hash IS NULL) THEN
+		FOR entry IN SELECT * FROM audit_log ORDER BY id LOOP
+			entry.prev_hash := prev;
+			entry.hash := audit_log_hash(entry);
+			UPDATE audit_log SET prev_hash = entry.prev_hash, hash = entry.hash WHERE id = entry.id;
+			prev := entry.hash;
+		END LOOP;
+	END IF;
+END;
+$$;
+
+ALTER TABLE audit_log ALTER COLUMN hash SET NOT NULL;
+
+DROP TRIGGER IF EXISTS audit_log_chain ON audit_log;
+CREATE TRIGGER audit_log_chain
+	BEFORE INSERT ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_chain();
+
+CREATE TRIGGER audit_log_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+CREATE INDEX IF NOT EXISTS idx_expense_requests_employee_id ON expense_requests(employee_id);
+CREATE INDEX IF NOT EXISTS idx_expense_requests_status ON expense_requests(status);
+CREATE INDEX IF NOT EXISTS idx_budgets_year_month ON budgets(year, month);
+CREATE INDEX IF NOT EXISTS idx_budget_history_budget_id ON budget_history(budget_id);
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_request_id ON expense_attachments(request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
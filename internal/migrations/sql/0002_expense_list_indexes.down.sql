@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_expense_requests_created_at_id;
+DROP INDEX IF EXISTS idx_expense_requests_amount_id;
+DROP INDEX IF EXISTS idx_expense_requests_reviewed_at_id;
+DROP INDEX IF EXISTS idx_expense_requests_category;
+DROP INDEX IF EXISTS idx_expense_requests_reviewer_id;
+DROP INDEX IF EXISTS idx_expense_requests_expense_date;
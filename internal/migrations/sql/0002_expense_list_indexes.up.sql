@@ -0,0 +1,9 @@
+-- Keyset pagination of expense requests sorts by these columns with the ID as tie-breaker
+CREATE INDEX IF NOT EXISTS idx_expense_requests_created_at_id ON expense_requests(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_expense_requests_amount_id ON expense_requests(amount, id);
+CREATE INDEX IF NOT EXISTS idx_expense_requests_reviewed_at_id
+	ON expense_requests((COALESCE(reviewed_at, '0001-01-01'::timestamp)), id);
+
+CREATE INDEX IF NOT EXISTS idx_expense_requests_category ON expense_requests(category);
+CREATE INDEX IF NOT EXISTS idx_expense_requests_reviewer_id ON expense_requests(reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_expense_requests_expense_date ON expense_requests(expense_date);
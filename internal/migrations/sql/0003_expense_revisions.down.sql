@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS expense_request_views;
+DROP TABLE IF EXISTS expense_request_revisions;
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS revision;
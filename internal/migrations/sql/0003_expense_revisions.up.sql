@@ -0,0 +1,20 @@
+ALTER TABLE expense_requests ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE expense_request_revisions (
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL REFERENCES expense_requests(id) ON DELETE CASCADE,
+	revision INTEGER NOT NULL,
+	changed_by INTEGER NOT NULL REFERENCES users(id),
+	changes JSONB NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE(request_id, revision)
+);
+
+-- The revision of a request each user saw last, so reviewers can tell what changed since
+CREATE TABLE expense_request_views (
+	request_id INTEGER NOT NULL REFERENCES expense_requests(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	revision INTEGER NOT NULL,
+	viewed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (request_id, user_id)
+);
@@ -0,0 +1,7 @@
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS paid_at;
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS payment_date;
+
+-- Requests past approval fall back to approved, returned ones to pending
+UPDATE expense_requests SET status = 'approved' WHERE status IN ('scheduled_for_payment', 'paid');
+UPDATE expense_requests SET status = 'pending' WHERE status = 'returned_for_changes';
+ALTER TABLE expense_requests ALTER COLUMN status TYPE VARCHAR(20);
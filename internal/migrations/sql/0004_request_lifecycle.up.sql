@@ -0,0 +1,5 @@
+-- Room for the longer statuses of the request lifecycle, e.g. scheduled_for_payment
+ALTER TABLE expense_requests ALTER COLUMN status TYPE VARCHAR(30);
+
+ALTER TABLE expense_requests ADD COLUMN payment_date DATE;
+ALTER TABLE expense_requests ADD COLUMN paid_at TIMESTAMP;
@@ -0,0 +1,20 @@
+-- A session is one login, all refresh tokens rotated from it form its token family
+CREATE TABLE auth_sessions (
+	id CHAR(32) PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_auth_sessions_user ON auth_sessions (user_id) WHERE revoked_at IS NULL;
+
+CREATE TABLE refresh_tokens (
+	hash CHAR(64) PRIMARY KEY,
+	session_id CHAR(32) NOT NULL REFERENCES auth_sessions(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens (session_id);
@@ -0,0 +1,11 @@
+CREATE TABLE invitations (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	invited_by INTEGER REFERENCES users(id),
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	used_by INTEGER REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
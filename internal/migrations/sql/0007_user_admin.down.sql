@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS is_active;
+
+-- Administrators fall back to management, the closest role that existed before
+UPDATE users SET role = 'management' WHERE role = 'admin';
+UPDATE invitations SET role = 'management' WHERE role = 'admin';
@@ -0,0 +1,2 @@
+-- Deactivated users keep their history but can no longer log in
+ALTER TABLE users ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
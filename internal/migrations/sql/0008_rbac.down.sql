@@ -0,0 +1,11 @@
+ALTER TABLE invitations DROP CONSTRAINT IF EXISTS fk_invitations_role;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+
+-- Custom roles did not exist before, their holders fall back to employee
+UPDATE users SET role = 'employee' WHERE role NOT IN ('employee', 'management', 'admin');
+UPDATE invitations SET role = 'employee' WHERE role NOT IN ('employee', 'management', 'admin');
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS permissions;
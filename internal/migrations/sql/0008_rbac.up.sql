@@ -0,0 +1,66 @@
+-- Permissions are fixed by the code, roles are sets of them and can be edited at runtime
+CREATE TABLE permissions (
+	name VARCHAR(50) PRIMARY KEY,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+-- Roles are referenced by name, the numeric ID ties them to the audit log
+CREATE TABLE roles (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(20) NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	built_in BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE role_permissions (
+	role VARCHAR(20) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+	permission VARCHAR(50) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+	PRIMARY KEY (role, permission)
+);
+
+-- Roles a user holds in addition to the primary one in users.role
+CREATE TABLE user_roles (
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role VARCHAR(20) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE,
+	PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO permissions (name, description) VALUES
+	('expenses.view_all', 'See expense requests of all employees'),
+	('expenses.approve', 'Approve, reject and return expense requests'),
+	('expenses.pay', 'Schedule payments and mark requests paid'),
+	('reports.view', 'See statistics and reports'),
+	('budget.view', 'See budgets and their history'),
+	('budget.edit', 'Create and change budgets'),
+	('audit.view', 'Read the audit log'),
+	('users.manage', 'Manage users, invitations and roles');
+
+INSERT INTO roles (name, description, built_in) VALUES
+	('employee', 'Creates own expense requests', TRUE),
+	('management', 'Reviews requests and manages budgets', TRUE),
+	('admin', 'Manages users and roles', TRUE),
+	('accountant', 'Pays approved requests', FALSE),
+	('auditor', 'Reads everything, changes nothing', FALSE);
+
+INSERT INTO role_permissions (role, permission) VALUES
+	('management', 'expenses.view_all'),
+	('management', 'expenses.approve'),
+	('management', 'expenses.pay'),
+	('management', 'reports.view'),
+	('management', 'budget.view'),
+	('management', 'budget.edit'),
+	('management', 'audit.view'),
+	('admin', 'users.manage'),
+	('admin', 'audit.view'),
+	('accountant', 'expenses.view_all'),
+	('accountant', 'expenses.pay'),
+	('accountant', 'reports.view'),
+	('accountant', 'budget.view'),
+	('auditor', 'expenses.view_all'),
+	('auditor', 'reports.view'),
+	('auditor', 'budget.view'),
+	('auditor', 'audit.view');
+
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+ALTER TABLE invitations ADD CONSTRAINT fk_invitations_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,4 @@
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS on_behalf_of;
+ALTER TABLE expense_approval_steps DROP COLUMN IF EXISTS on_behalf_of;
+
+DROP TABLE IF EXISTS approval_delegations;
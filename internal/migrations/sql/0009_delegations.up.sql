@@ -0,0 +1,21 @@
+-- A delegation lets a user decide on requests on behalf of a reviewer for a range of days,
+-- optionally only for one category and up to an amount
+CREATE TABLE approval_delegations (
+	id SERIAL PRIMARY KEY,
+	delegator_id INTEGER NOT NULL REFERENCES users(id),
+	delegate_id INTEGER NOT NULL REFERENCES users(id),
+	starts_on DATE NOT NULL,
+	ends_on DATE NOT NULL,
+	category VARCHAR(100),
+	max_amount DECIMAL(12, 2),
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (delegator_id <> delegate_id),
+	CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX idx_approval_delegations_delegate ON approval_delegations (delegate_id, delegator_id) WHERE revoked_at IS NULL;
+
+-- The user a decision was made for when the reviewer acted under a delegation
+ALTER TABLE expense_approval_steps ADD COLUMN on_behalf_of INTEGER REFERENCES users(id);
+ALTER TABLE expense_requests ADD COLUMN on_behalf_of INTEGER REFERENCES users(id);
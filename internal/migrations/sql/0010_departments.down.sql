@@ -0,0 +1,9 @@
+DELETE FROM budgets WHERE department_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_budgets_department_period;
+DROP INDEX IF EXISTS idx_budgets_period;
+ALTER TABLE budgets DROP COLUMN IF EXISTS department_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_period ON budgets (period_type, year, month);
+
+ALTER TABLE users DROP COLUMN IF EXISTS department_id;
+
+DROP TABLE IF EXISTS departments;
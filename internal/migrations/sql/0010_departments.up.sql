@@ -0,0 +1,22 @@
+-- Departments form a tree, each one is also a cost center identified by its code
+CREATE TABLE departments (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	code VARCHAR(20) NOT NULL UNIQUE,
+	parent_id INTEGER REFERENCES departments(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (parent_id <> id)
+);
+
+CREATE INDEX idx_departments_parent_id ON departments (parent_id);
+
+ALTER TABLE users ADD COLUMN department_id INTEGER REFERENCES departments(id);
+CREATE INDEX idx_users_department_id ON users (department_id);
+
+-- A budget without a department is the company budget, department budgets roll up into it
+ALTER TABLE budgets ADD COLUMN department_id INTEGER REFERENCES departments(id);
+DROP INDEX IF EXISTS idx_budgets_period;
+CREATE UNIQUE INDEX idx_budgets_period ON budgets (period_type, year, month) WHERE department_id IS NULL;
+CREATE UNIQUE INDEX idx_budgets_department_period ON budgets (department_id, period_type, year, month)
+	WHERE department_id IS NOT NULL;
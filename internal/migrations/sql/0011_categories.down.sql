@@ -0,0 +1,6 @@
+-- Category codes stay normalized in expense requests
+DELETE FROM permissions WHERE name = 'categories.manage';
+
+DROP INDEX IF EXISTS idx_expense_requests_employee_category;
+
+DROP TABLE IF EXISTS expense_categories;
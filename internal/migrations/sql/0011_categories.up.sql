@@ -0,0 +1,35 @@
+-- Managed expense categories, requests keep referring to a category by its code
+CREATE TABLE expense_categories (
+	id SERIAL PRIMARY KEY,
+	code VARCHAR(100) NOT NULL UNIQUE,
+	name VARCHAR(100) NOT NULL,
+	names JSONB NOT NULL DEFAULT '{}',
+	parent_id INTEGER REFERENCES expense_categories(id),
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	max_amount DECIMAL(12, 2),
+	monthly_cap DECIMAL(12, 2),
+	receipt_required BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK (parent_id <> id),
+	CHECK (max_amount > 0),
+	CHECK (monthly_cap > 0)
+);
+
+-- Free-text categories become codes, spellings differing only in case or surrounding spaces merge.
+-- Other duplicates have to be cleaned up by hand, e.g. by deactivating one of them.
+UPDATE expense_requests SET category = lower(trim(category)) WHERE category <> lower(trim(category));
+UPDATE approval_delegations SET category = lower(trim(category)) WHERE category <> lower(trim(category));
+
+INSERT INTO expense_categories (code, name)
+SELECT category, category FROM expense_requests WHERE category <> '' GROUP BY category;
+
+-- Monthly caps sum up the requests of an employee in a category
+CREATE INDEX idx_expense_requests_employee_category ON expense_requests (employee_id, category, expense_date);
+
+INSERT INTO permissions (name, description) VALUES
+	('categories.manage', 'Manage expense categories and their limits');
+
+INSERT INTO role_permissions (role, permission) VALUES
+	('management', 'categories.manage'),
+	('admin', 'categories.manage');
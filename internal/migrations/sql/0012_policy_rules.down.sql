@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE name = 'policies.manage';
+
+DROP TABLE IF EXISTS policy_evaluations;
+DROP TABLE IF EXISTS policy_rule_versions;
+DROP TABLE IF EXISTS policy_rules;
@@ -0,0 +1,48 @@
+-- Declarative policy rules evaluated against expense requests on submit and on approval.
+-- Conditions are JSON trees, see models.PolicyCondition. Every change bumps the version.
+CREATE TABLE policy_rules (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	stage VARCHAR(20) NOT NULL CHECK (stage IN ('submit', 'approve', 'all')),
+	when_condition JSONB,
+	effect VARCHAR(20) NOT NULL CHECK (effect IN ('reject', 'require', 'warn')),
+	require_condition JSONB,
+	message VARCHAR(500) NOT NULL,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	version INTEGER NOT NULL DEFAULT 1,
+	updated_by INTEGER REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	CHECK ((effect = 'require') = (require_condition IS NOT NULL))
+);
+
+-- Every version a rule went through, evaluations refer to the version that fired
+CREATE TABLE policy_rule_versions (
+	rule_id INTEGER NOT NULL REFERENCES policy_rules(id),
+	version INTEGER NOT NULL,
+	definition JSONB NOT NULL,
+	changed_by INTEGER REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (rule_id, version)
+);
+
+-- Outcome of evaluating the active rules against a request, results hold the rules that fired
+CREATE TABLE policy_evaluations (
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL REFERENCES expense_requests(id) ON DELETE CASCADE,
+	stage VARCHAR(20) NOT NULL,
+	revision INTEGER NOT NULL,
+	outcome VARCHAR(20) NOT NULL,
+	results JSONB NOT NULL DEFAULT '[]',
+	actor_id INTEGER REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_policy_evaluations_request ON policy_evaluations (request_id, created_at);
+
+INSERT INTO permissions (name, description) VALUES
+	('policies.manage', 'Manage expense policy rules');
+
+INSERT INTO role_permissions (role, permission) VALUES
+	('admin', 'policies.manage');
@@ -0,0 +1,4 @@
+-- The system user stays, requests it approved refer to it as their reviewer
+DROP TABLE IF EXISTS auto_approval_rules;
+
+ALTER TABLE users DROP COLUMN IF EXISTS trust_level;
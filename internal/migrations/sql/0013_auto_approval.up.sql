@@ -0,0 +1,20 @@
+-- How far the company trusts an employee, from 0 (new) to 5, auto-approval rules may ask for a minimum
+ALTER TABLE users ADD COLUMN trust_level INTEGER NOT NULL DEFAULT 0 CHECK (trust_level BETWEEN 0 AND 5);
+
+-- Automatic decisions are recorded for this user, it cannot log in
+INSERT INTO users (email, password, first_name, last_name, role, is_active)
+VALUES ('system@expenses.local', '!', 'System', 'Auto-approval', 'employee', FALSE)
+ON CONFLICT (email) DO NOTHING;
+
+-- Low-risk requests matching a rule are approved right after they are submitted
+CREATE TABLE auto_approval_rules (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	category VARCHAR(100),
+	max_amount DECIMAL(12, 2) NOT NULL CHECK (max_amount > 0),
+	min_trust_level INTEGER CHECK (min_trust_level BETWEEN 0 AND 5),
+	min_budget_remaining DECIMAL(5, 2) CHECK (min_budget_remaining BETWEEN 0 AND 100),
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expense_requests_vendor_date;
+
+DROP TABLE IF EXISTS expense_flags;
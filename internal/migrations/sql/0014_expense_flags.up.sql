@@ -0,0 +1,16 @@
+-- Requests that look like a duplicate of another one or like part of a split purchase,
+-- related_ids are the requests they were compared with
+CREATE TABLE expense_flags (
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL REFERENCES expense_requests(id) ON DELETE CASCADE,
+	kind VARCHAR(30) NOT NULL CHECK (kind IN ('duplicate', 'split_purchase')),
+	reason TEXT NOT NULL,
+	related_ids INTEGER[] NOT NULL DEFAULT '{}',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_expense_flags_request ON expense_flags (request_id);
+CREATE INDEX idx_expense_flags_created ON expense_flags (created_at DESC);
+
+-- Detection compares a request with recent ones of the same vendor
+CREATE INDEX idx_expense_requests_vendor_date ON expense_requests (lower(trim(vendor)), expense_date);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_expense_requests_employee_created;
+DROP INDEX IF EXISTS idx_expense_requests_anomaly_score;
+
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS anomaly_factors;
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS anomaly_score;
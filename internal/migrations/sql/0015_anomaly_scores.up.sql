@@ -0,0 +1,9 @@
+-- How unusual a request is against the history, from 0 to 100, and the factors the score is made of.
+-- Requests are scored when they are sent to review, drafts have no score.
+ALTER TABLE expense_requests ADD COLUMN anomaly_score DECIMAL(5, 2) CHECK (anomaly_score BETWEEN 0 AND 100);
+ALTER TABLE expense_requests ADD COLUMN anomaly_factors JSONB;
+
+CREATE INDEX idx_expense_requests_anomaly_score ON expense_requests (anomaly_score DESC NULLS LAST);
+
+-- Scoring compares a request with the history of its employee
+CREATE INDEX idx_expense_requests_employee_created ON expense_requests (employee_id, created_at);
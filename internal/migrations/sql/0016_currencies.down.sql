@@ -0,0 +1,8 @@
+DELETE FROM permissions WHERE name = 'rates.manage';
+
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS rate_date;
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS exchange_rate;
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS original_amount;
+ALTER TABLE expense_requests DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,27 @@
+-- Exchange rates to the base currency (RUB): one unit of currency costs rate roubles from rate_date on
+CREATE TABLE exchange_rates (
+	id SERIAL PRIMARY KEY,
+	currency VARCHAR(3) NOT NULL CHECK (currency <> 'RUB'),
+	rate_date DATE NOT NULL,
+	rate DECIMAL(18, 8) NOT NULL CHECK (rate > 0),
+	updated_by INTEGER REFERENCES users(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE (currency, rate_date)
+);
+
+-- amount stays in the base currency, original_amount is what the employee entered in currency.
+-- exchange_rate and rate_date record the conversion, drafts in a currency without a rate have none.
+ALTER TABLE expense_requests ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE expense_requests ADD COLUMN original_amount DECIMAL(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE expense_requests ADD COLUMN exchange_rate DECIMAL(18, 8);
+ALTER TABLE expense_requests ADD COLUMN rate_date DATE;
+
+UPDATE expense_requests SET original_amount = amount, exchange_rate = 1, rate_date = created_at::date;
+
+INSERT INTO permissions (name, description) VALUES
+	('rates.manage', 'Maintain exchange rates');
+
+INSERT INTO role_permissions (role, permission) VALUES
+	('management', 'rates.manage'),
+	('admin', 'rates.manage');
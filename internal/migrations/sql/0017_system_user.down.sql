@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_system;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_system;
@@ -0,0 +1,6 @@
+-- The system user records automatic decisions, administrators cannot change it
+ALTER TABLE users ADD COLUMN is_system BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users SET is_system = TRUE WHERE email = 'system@expenses.local';
+
+CREATE UNIQUE INDEX idx_users_system ON users (is_system) WHERE is_system;
@@ -0,0 +1,29 @@
+-- Records signed by the application stay in the log, the database hashes the ones after them again
+
+-- Hash of an audit record: its content chained to the hash of the previous record
+CREATE OR REPLACE FUNCTION audit_log_hash(entry audit_log) RETURNS CHAR(64) AS $$
+	SELECT encode(sha256(convert_to(
+		coalesce(entry.prev_hash, '') || '|' || entry.id || '|' || coalesce(entry.actor_id::text, '') || '|' ||
+		entry.action || '|' || entry.entity_type || '|' || entry.entity_id || '|' ||
+		coalesce(entry.before::text, '') || '|' || coalesce(entry.after::text, '') || '|' ||
+		coalesce(entry.request_id, '') || '|' || to_char(entry.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US'),
+		'UTF8'
+	)), 'hex');
+$$ LANGUAGE sql STABLE;
+
+-- Inserts are serialized so every record links to the one committed before it.
+-- The ID is taken under the lock to keep the chain in ID order.
+CREATE OR REPLACE FUNCTION audit_log_chain() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('audit_log'));
+	NEW.id := nextval(pg_get_serial_sequence('audit_log', 'id'));
+	SELECT hash INTO NEW.prev_hash FROM audit_log ORDER BY id DESC LIMIT 1;
+	NEW.hash := audit_log_hash(NEW);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_chain ON audit_log;
+CREATE TRIGGER audit_log_chain
+	BEFORE INSERT ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_chain();
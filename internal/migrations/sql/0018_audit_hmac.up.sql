@@ -0,0 +1,5 @@
+-- The application chains and signs audit records with an HMAC key the database does not hold,
+-- records hashed here so far keep their SHA-256 hashes
+DROP TRIGGER IF EXISTS audit_log_chain ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_chain();
+DROP FUNCTION IF EXISTS audit_log_hash(audit_log);
@@ -0,0 +1 @@
+ALTER TABLE expense_approval_steps DROP COLUMN IF EXISTS role;
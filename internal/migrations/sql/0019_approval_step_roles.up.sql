@@ -0,0 +1,2 @@
+-- A step bound to a role is decided only by its holders
+ALTER TABLE expense_approval_steps ADD COLUMN role VARCHAR(20);
@@ -0,0 +1,50 @@
+package models
+
+// HighAnomalyScore is the score from which a request counts as highly unusual in the statistics
+const HighAnomalyScore = 50
+
+// AnomalyFactor is one way a request differs from the history, Score goes from 0 (usual) to 1
+type AnomalyFactor struct {
+	Kind   AnomalyKind `json:"kind"`
+	Score  float64     `json:"score"`
+	Weight float64     `json:"weight"` // share of the factor in the anomaly score of the request
+	Reason string      `json:"reason"`
+}
+
+type AnomalyKind string
+
+const (
+	AnomalyAmount   AnomalyKind = "amount"   // amount far above the category average
+	AnomalyVendor   AnomalyKind = "vendor"   // vendor new to the employee
+	AnomalyTiming   AnomalyKind = "timing"   // weekday or hour the employee rarely files expenses at
+	AnomalyVelocity AnomalyKind = "velocity" // recent spending well above the baseline of the employee
+)
+
+// AnomalyStats summarize the anomaly scores of pending requests
+type AnomalyStats struct {
+	ScoredCount  int     `json:"scoredCount"`
+	AverageScore float64 `json:"averageScore"`
+	HighCount    int     `json:"highCount"` // requests scored HighAnomalyScore or above
+	// Top are the most unusual pending requests
+	Top []AnomalousRequest `json:"top"`
+}
+
+// AnomalousRequest is a pending request with its anomaly score
+type AnomalousRequest struct {
+	ID           uint    `json:"id"`
+	Title        string  `json:"title"`
+	Category     string  `json:"category"`
+	Amount       float64 `json:"amount"`
+	EmployeeID   uint    `json:"employeeId"`
+	AnomalyScore float64 `json:"anomalyScore"`
+}
+
+// AnomalyHistory is what a request is scored against
+type AnomalyHistory struct {
+	// CategoryCount, CategoryMean and CategoryStddev describe the amounts of the other requests of the category
+	CategoryCount  int
+	CategoryMean   float64
+	CategoryStddev float64
+	// Employee holds the earlier requests of the employee, oldest first
+	Employee []ExpenseRequest
+}
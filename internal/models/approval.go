@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ApprovalStep represents a single step of an expense request approval chain
+type ApprovalStep struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	RequestID  uint           `gorm:"not null" json:"requestId"`
+	StepOrder  int            `gorm:"not null" json:"stepOrder"`
+	Name       string         `gorm:"not null" json:"name"`
+	MinAmount  float64        `gorm:"not null;default:0" json:"minAmount"`
+	Role       UserRole       `json:"role,omitempty"`
+	Status     ApprovalStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	ReviewerID *uint          `json:"reviewerId,omitempty"`
+	Reviewer   *User          `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+	Comments   string         `gorm:"type:text" json:"comments,omitempty"`
+	DecidedAt  *time.Time     `json:"decidedAt,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+
+	// OnBehalfOfID is the reviewer the decision was made for under a delegation
+	OnBehalfOfID *uint `json:"onBehalfOfId,omitempty"`
+}
+
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalSkipped  ApprovalStatus = "skipped"
+)
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Attachment represents a receipt or invoice file attached to an expense request
+type Attachment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	RequestID   uint      `gorm:"not null" json:"requestId"`
+	FileName    string    `gorm:"not null" json:"fileName"`
+	ContentType string    `gorm:"not null" json:"contentType"`
+	Size        int64     `gorm:"not null" json:"size"`
+	Checksum    string    `gorm:"not null" json:"checksum"`
+	StorageKey  string    `gorm:"not null" json:"-"`
+	UploadedBy  uint      `gorm:"not null" json:"uploadedBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
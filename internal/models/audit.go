@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry is an append-only record of a change made in the system
+type AuditEntry struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    *uint           `json:"actorId,omitempty"`
+	Action     AuditAction     `gorm:"type:varchar(50);not null" json:"action"`
+	EntityType AuditEntity     `gorm:"type:varchar(50);not null" json:"entityType"`
+	EntityID   uint            `gorm:"not null" json:"entityId"`
+	Before     json.RawMessage `gorm:"type:jsonb" json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `gorm:"type:jsonb" json:"after,omitempty" swaggertype:"object"`
+	RequestID  string          `json:"requestId,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	PrevHash   string          `json:"prevHash,omitempty"`
+	Hash       string          `json:"hash"`
+}
+
+type AuditAction string
+
+const (
+	AuditExpenseCreated       AuditAction = "expense.created"
+	AuditExpenseStepApproved  AuditAction = "expense.step_approved"
+	AuditExpenseApproved      AuditAction = "expense.approved"
+	AuditExpenseRejected      AuditAction = "expense.rejected"
+	AuditExpenseUpdated       AuditAction = "expense.updated"
+	AuditExpenseWithdrawn     AuditAction = "expense.withdrawn"
+	AuditExpenseReturned      AuditAction = "expense.returned"
+	AuditExpenseSubmitted     AuditAction = "expense.submitted"
+	AuditExpenseScheduled     AuditAction = "expense.payment_scheduled"
+	AuditExpensePaid          AuditAction = "expense.paid"
+	AuditExpenseDutyViolation AuditAction = "expense.duty_violation"
+	AuditExpenseAutoApproved  AuditAction = "expense.auto_approved"
+	AuditAttachmentAdded      AuditAction = "attachment.uploaded"
+	AuditAttachmentDeleted    AuditAction = "attachment.deleted"
+	AuditBudgetCreated        AuditAction = "budget.created"
+	AuditBudgetUpdated        AuditAction = "budget.updated"
+	AuditBudgetSpent          AuditAction = "budget.spent"
+	AuditUserRegistered       AuditAction = "user.registered"
+	AuditUserLogin            AuditAction = "user.login"
+	AuditUserLoginFailed      AuditAction = "user.login_failed"
+	AuditUserLogout           AuditAction = "user.logout"
+	AuditTokenReused          AuditAction = "user.refresh_token_reused"
+	AuditInvitationCreated    AuditAction = "invitation.created"
+	AuditUserRoleChanged      AuditAction = "user.role_changed"
+	AuditUserDeactivated      AuditAction = "user.deactivated"
+	AuditUserActivated        AuditAction = "user.activated"
+	AuditUserPasswordReset    AuditAction = "user.password_reset"
+	AuditUserRolesChanged     AuditAction = "user.roles_changed"
+	AuditRoleCreated          AuditAction = "role.created"
+	AuditRoleUpdated          AuditAction = "role.updated"
+	AuditRoleDeleted          AuditAction = "role.deleted"
+	AuditDelegationCreated    AuditAction = "delegation.created"
+	AuditDelegationRevoked    AuditAction = "delegation.revoked"
+	AuditDepartmentCreated    AuditAction = "department.created"
+	AuditDepartmentUpdated    AuditAction = "department.updated"
+	AuditDepartmentDeleted    AuditAction = "department.deleted"
+	AuditUserDepartmentSet    AuditAction = "user.department_changed"
+	AuditCategoryCreated      AuditAction = "category.created"
+	AuditCategoryUpdated      AuditAction = "category.updated"
+	AuditCategoryDeleted      AuditAction = "category.deleted"
+	AuditPolicyRuleCreated    AuditAction = "policy_rule.created"
+	AuditPolicyRuleUpdated    AuditAction = "policy_rule.updated"
+	AuditAutoRuleCreated      AuditAction = "auto_approval_rule.created"
+	AuditAutoRuleUpdated      AuditAction = "auto_approval_rule.updated"
+	AuditAutoRuleDeleted      AuditAction = "auto_approval_rule.deleted"
+	AuditUserTrustLevelSet    AuditAction = "user.trust_level_changed"
+	AuditExchangeRateSet      AuditAction = "exchange_rate.set"
+	AuditExchangeRateImported AuditAction = "exchange_rate.imported"
+	AuditExpenseConverted     AuditAction = "expense.converted"
+)
+
+type AuditEntity string
+
+const (
+	AuditEntityExpense    AuditEntity = "expense_request"
+	AuditEntityAttachment AuditEntity = "attachment"
+	AuditEntityBudget     AuditEntity = "budget"
+	AuditEntityUser       AuditEntity = "user"
+	AuditEntityInvitation AuditEntity = "invitation"
+	AuditEntityRole       AuditEntity = "role"
+	AuditEntityDelegation AuditEntity = "delegation"
+	AuditEntityDepartment AuditEntity = "department"
+	AuditEntityCategory   AuditEntity = "category"
+	AuditEntityPolicyRule AuditEntity = "policy_rule"
+	AuditEntityAutoRule   AuditEntity = "auto_approval_rule"
+	AuditEntityRate       AuditEntity = "exchange_rate"
+)
+
+// AuditFilter narrows down the audit log listing
+type AuditFilter struct {
+	EntityType AuditEntity
+	EntityID   *uint
+	ActorID    *uint
+	Action     AuditAction
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditChainReport is the result of walking the audit log hash chain
+type AuditChainReport struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	LastID   uint   `json:"lastId,omitempty"`
+	LastHash string `json:"lastHash,omitempty"`
+	BrokenAt *uint  `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Legacy counts the records at the start of the log hashed by the database before the log was signed
+	Legacy int `json:"legacy,omitempty"`
+}
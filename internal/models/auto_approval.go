@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// MaxTrustLevel is the highest trust level of an employee, new employees start at 0
+const MaxTrustLevel = 5
+
+// AutoApprovalRule approves low-risk requests without a reviewer.
+// MinBudgetRemaining is the percent of every charged budget that has to be left.
+type AutoApprovalRule struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	Name               string    `gorm:"not null" json:"name"`
+	Category           *string   `json:"category,omitempty"`
+	MaxAmount          float64   `gorm:"not null" json:"maxAmount"`
+	MinTrustLevel      *int      `json:"minTrustLevel,omitempty"`
+	MinBudgetRemaining *float64  `json:"minBudgetRemaining,omitempty"` // percent of the budget total
+	IsActive           bool      `gorm:"not null;default:true" json:"isActive"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+// Covers tells whether the rule lets a request of an employee be approved automatically,
+// leaving aside the budgets it is charged to
+func (r *AutoApprovalRule) Covers(request *ExpenseRequest, employee *User) bool {
+	switch {
+	case !r.IsActive:
+		return false
+	case r.Category != nil && *r.Category != request.Category:
+		return false
+	case request.Amount > r.MaxAmount:
+		return false
+	case r.MinTrustLevel != nil && employee.TrustLevel < *r.MinTrustLevel:
+		return false
+	}
+	return true
+}
+
+// AutoApprovalRuleDTO for creating or changing an auto-approval rule
+type AutoApprovalRuleDTO struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	Category           *string  `json:"category" binding:"omitempty,min=1,max=100"`
+	MaxAmount          float64  `json:"maxAmount" binding:"required,gt=0"`
+	MinTrustLevel      *int     `json:"minTrustLevel" binding:"omitempty,min=0,max=5"`
+	MinBudgetRemaining *float64 `json:"minBudgetRemaining" binding:"omitempty,min=0,max=100"`
+	IsActive           *bool    `json:"isActive"` // true when omitted
+}
+
+// SetTrustLevelDTO for changing how far an employee is trusted
+type SetTrustLevelDTO struct {
+	TrustLevel *int `json:"trustLevel" binding:"required,min=0,max=5"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Category is an entry of the expense category registry.
+// MaxAmount caps a request and MonthlyCap the requests of an employee in a month.
+type Category struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	Code            string            `gorm:"uniqueIndex;not null" json:"code"`
+	Name            string            `gorm:"not null" json:"name"`
+	Names           map[string]string `gorm:"type:jsonb" json:"names"` // display names by language, e.g. "ru"
+	ParentID        *uint             `json:"parentId,omitempty"`
+	IsActive        bool              `gorm:"not null;default:true" json:"isActive"`
+	MaxAmount       *float64          `json:"maxAmount,omitempty"`
+	MonthlyCap      *float64          `json:"monthlyCap,omitempty"`
+	ReceiptRequired bool              `gorm:"not null;default:false" json:"receiptRequired"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+}
+
+// CreateCategoryDTO for adding a category to the registry
+type CreateCategoryDTO struct {
+	Code string `json:"code" binding:"required,max=100,lowercase"`
+	UpdateCategoryDTO
+}
+
+// UpdateCategoryDTO for changing a category
+type UpdateCategoryDTO struct {
+	Name            string            `json:"name" binding:"required,max=100"`
+	Names           map[string]string `json:"names" binding:"omitempty,dive,keys,min=2,max=10,endkeys,required,max=100"`
+	ParentID        *uint             `json:"parentId"`
+	IsActive        *bool             `json:"isActive"` // true when omitted
+	MaxAmount       *float64          `json:"maxAmount" binding:"omitempty,gt=0"`
+	MonthlyCap      *float64          `json:"monthlyCap" binding:"omitempty,gt=0"`
+	ReceiptRequired bool              `json:"receiptRequired"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// BaseCurrency is the currency amounts of requests, budgets, limits and statistics are kept in
+const BaseCurrency = "RUB"
+
+// ExchangeRate is the price of one unit of a currency in the base currency from RateDate on,
+// until the next rate of the currency
+type ExchangeRate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Currency  string    `gorm:"type:varchar(3);not null" json:"currency"`
+	RateDate  time.Time `gorm:"type:date;not null" json:"rateDate"`
+	Rate      float64   `gorm:"not null" json:"rate"`
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetExchangeRateDTO for adding or correcting the rate of a currency on a date
+type SetExchangeRateDTO struct {
+	Currency string  `json:"currency" binding:"required,iso4217"`
+	Date     string  `json:"date" binding:"required,datetime=2006-01-02"`
+	Rate     float64 `json:"rate" binding:"required,gt=0"`
+}
+
+// ListExchangeRatesDTO for listing exchange rates
+type ListExchangeRatesDTO struct {
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+	From     string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To       string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ExchangeRateImport reports a CSV upload of exchange rates
+type ExchangeRateImport struct {
+	Imported int `json:"imported"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Delegation lets a user decide on expense requests on behalf of an absent reviewer.
+// It may be limited to one category and to amounts up to MaxAmount.
+type Delegation struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	DelegatorID uint       `gorm:"not null" json:"delegatorId"`
+	DelegateID  uint       `gorm:"not null" json:"delegateId"`
+	StartsOn    time.Time  `gorm:"type:date;not null" json:"startsOn"`
+	EndsOn      time.Time  `gorm:"type:date;not null" json:"endsOn"`
+	Category    *string    `json:"category,omitempty"`
+	MaxAmount   *float64   `json:"maxAmount,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// Covers tells whether the delegation lets its delegate decide on a request on a day
+func (d *Delegation) Covers(request *ExpenseRequest, day time.Time) bool {
+	switch {
+	case d.RevokedAt != nil, day.Before(d.StartsOn), day.After(d.EndsOn):
+		return false
+	case d.Category != nil && *d.Category != request.Category:
+		return false
+	case d.MaxAmount != nil && request.Amount > *d.MaxAmount:
+		return false
+	}
+	return true
+}
+
+// CreateDelegationDTO for delegating approvals to another user
+type CreateDelegationDTO struct {
+	DelegateID uint     `json:"delegateId" binding:"required"`
+	StartsOn   string   `json:"startsOn" binding:"required,datetime=2006-01-02"`
+	EndsOn     string   `json:"endsOn" binding:"required,datetime=2006-01-02"`
+	Category   *string  `json:"category" binding:"omitempty,min=1,max=100"`
+	MaxAmount  *float64 `json:"maxAmount" binding:"omitempty,gt=0"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Department is a unit of the company and the cost center its expenses are charged to.
+// Departments form a tree under the company.
+type Department struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Code      string    `gorm:"uniqueIndex;type:varchar(20);not null" json:"code"`
+	ParentID  *uint     `json:"parentId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DepartmentDTO for creating or changing a department
+type DepartmentDTO struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Code     string `json:"code" binding:"required,max=20"`
+	ParentID *uint  `json:"parentId"`
+}
+
+// SetUserDepartmentDTO for moving a user to another department, null takes them out of any
+type SetUserDepartmentDTO struct {
+	DepartmentID *uint `json:"departmentId"`
+}
+
+// DepartmentStats are the expense statistics of a department including its sub-departments
+type DepartmentStats struct {
+	DepartmentID      uint    `json:"departmentId"`
+	Name              string  `json:"name"`
+	Code              string  `json:"code"`
+	TotalPending      float64 `json:"totalPending"`
+	TotalApproved     float64 `json:"totalApproved"`
+	PendingCount      int     `json:"pendingCount"`
+	ApprovedThisMonth int     `json:"approvedThisMonth"`
+	BudgetUsed        float64 `json:"budgetUsed"`
+	BudgetRemaining   float64 `json:"budgetRemaining"`
+}
@@ -10,7 +10,7 @@ type ExpenseRequest struct {
 	Amount      float64       `gorm:"not null" json:"amount"`
 	Vendor      string        `gorm:"not null" json:"vendor"`
 	Description string        `gorm:"type:text;not null" json:"description"`
-	Status      RequestStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Status      RequestStatus `gorm:"type:varchar(30);not null;default:'pending'" json:"status"`
 	EmployeeID  uint          `gorm:"not null" json:"employeeId"`
 	Employee    User          `gorm:"foreignKey:EmployeeID" json:"employee"`
 	ReviewerID  *uint         `json:"reviewerId,omitempty"`
@@ -19,35 +19,237 @@ type ExpenseRequest struct {
 	CreatedAt   time.Time     `json:"createdAt"`
 	UpdatedAt   time.Time     `json:"updatedAt"`
 	ReviewedAt  *time.Time    `json:"reviewedAt,omitempty"`
+	ExpenseDate time.Time     `gorm:"type:date" json:"expenseDate"`
+	Revision    int           `gorm:"not null;default:1" json:"revision"`
+	PaymentDate *time.Time    `gorm:"type:date" json:"paymentDate,omitempty"`
+	PaidAt      *time.Time    `json:"paidAt,omitempty"`
+
+	// OnBehalfOfID is the reviewer the last decision was made for under a delegation
+	OnBehalfOfID *uint `json:"onBehalfOfId,omitempty"`
+
+	// LastViewedRevision is the revision the current user saw on their previous visit
+	LastViewedRevision *int `gorm:"-" json:"lastViewedRevision,omitempty"`
+
+	ApprovalSteps []ApprovalStep `gorm:"foreignKey:RequestID" json:"approvalSteps,omitempty"`
+	Attachments   []Attachment   `gorm:"foreignKey:RequestID" json:"attachments,omitempty"`
+
+	// PolicyEvaluations tell reviewers which policy rules fired on submit and on approvals
+	PolicyEvaluations []PolicyEvaluation `gorm:"foreignKey:RequestID" json:"policyEvaluations,omitempty"`
+	// Flags mark the request as a possible duplicate or part of a split purchase
+	Flags []ExpenseFlag `gorm:"foreignKey:RequestID" json:"flags,omitempty"`
+
+	// AnomalyScore tells how unusual the request is against the history, from 0 to 100,
+	// AnomalyFactors explain it. Requests are scored when they are sent to review.
+	AnomalyScore   *float64        `json:"anomalyScore,omitempty"`
+	AnomalyFactors []AnomalyFactor `gorm:"type:jsonb" json:"anomalyFactors,omitempty"`
+
+	// Amount is in BaseCurrency and OriginalAmount in Currency.
+	// ExchangeRate is the rate of RateDate used to convert it.
+	Currency       string     `gorm:"type:varchar(3);not null;default:'RUB'" json:"currency"`
+	OriginalAmount float64    `gorm:"not null" json:"originalAmount"`
+	ExchangeRate   *float64   `json:"exchangeRate,omitempty"`
+	RateDate       *time.Time `gorm:"type:date" json:"rateDate,omitempty"`
 }
 
 type RequestStatus string
 
+// Request statuses, see the lifecycle in service.requestTransitions.
+// StatusPending is the submitted state: the request awaits review.
 const (
-	StatusPending  RequestStatus = "pending"
-	StatusApproved RequestStatus = "approved"
-	StatusRejected RequestStatus = "rejected"
+	StatusDraft            RequestStatus = "draft"
+	StatusPending          RequestStatus = "pending"
+	StatusApproved         RequestStatus = "approved"
+	StatusScheduledPayment RequestStatus = "scheduled_for_payment"
+	StatusPaid             RequestStatus = "paid"
+	StatusRejected         RequestStatus = "rejected"
+	StatusWithdrawn        RequestStatus = "withdrawn"
+	StatusReturned         RequestStatus = "returned_for_changes"
 )
 
-// Budget represents monthly budget information
+// ApprovedStatuses are the statuses of requests charged to a budget
+var ApprovedStatuses = []RequestStatus{StatusApproved, StatusScheduledPayment, StatusPaid}
+
+// Budget represents budget information for a monthly, quarterly or fiscal-year period.
+// Year and Month identify the first month of the period.
 type Budget struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Year      int       `gorm:"not null" json:"year"`
-	Month     int       `gorm:"not null" json:"month"`
-	Total     float64   `gorm:"not null" json:"total"`
-	Spent     float64   `gorm:"not null;default:0" json:"spent"`
-	Remaining float64   `gorm:"not null" json:"remaining"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	PeriodType  BudgetPeriodType `gorm:"type:varchar(20);not null;default:'monthly'" json:"periodType"`
+	Year        int              `gorm:"not null" json:"year"`
+	Month       int              `gorm:"not null" json:"month"`
+	PeriodStart time.Time        `gorm:"type:date;not null" json:"periodStart"`
+	PeriodEnd   time.Time        `gorm:"type:date;not null" json:"periodEnd"`
+	Total       float64          `gorm:"not null" json:"total"`
+	Spent       float64          `gorm:"not null;default:0" json:"spent"`
+	Remaining   float64          `gorm:"not null" json:"remaining"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+
+	// DepartmentID is nil on the company budget.
+	// Spending of a department is also charged to the budgets of the levels above it.
+	DepartmentID *uint `json:"departmentId,omitempty"`
+	// Departments break the budget down into the department budgets below it, when asked for
+	Departments []Budget `gorm:"-" json:"departments,omitempty"`
+}
+
+type BudgetPeriodType string
+
+const (
+	PeriodMonthly    BudgetPeriodType = "monthly"
+	PeriodQuarterly  BudgetPeriodType = "quarterly"
+	PeriodFiscalYear BudgetPeriodType = "fiscal_year"
+)
+
+// Months returns the length of the period in months
+func (t BudgetPeriodType) Months() int {
+	switch t {
+	case PeriodQuarterly:
+		return 3
+	case PeriodFiscalYear:
+		return 12
+	default:
+		return 1
+	}
 }
 
-// CreateExpenseRequestDTO for creating new expense requests
+// BudgetPeriod is a resolved budget period, End is its last day
+type BudgetPeriod struct {
+	Type  BudgetPeriodType
+	Year  int
+	Month int
+	Start time.Time
+	End   time.Time
+}
+
+// BudgetHistoryEntry represents a single change of a budget
+type BudgetHistoryEntry struct {
+	ID               uint         `gorm:"primaryKey" json:"id"`
+	BudgetID         uint         `gorm:"not null" json:"budgetId"`
+	Action           BudgetAction `gorm:"type:varchar(20);not null" json:"action"`
+	Amount           float64      `gorm:"not null" json:"amount"`
+	Total            float64      `gorm:"not null" json:"total"`
+	Spent            float64      `gorm:"not null" json:"spent"`
+	Remaining        float64      `gorm:"not null" json:"remaining"`
+	UserID           *uint        `json:"userId,omitempty"`
+	ExpenseRequestID *uint        `json:"expenseRequestId,omitempty"`
+	CreatedAt        time.Time    `json:"createdAt"`
+}
+
+type BudgetAction string
+
+const (
+	BudgetActionCreated      BudgetAction = "created"
+	BudgetActionTotalChanged BudgetAction = "total_changed"
+	BudgetActionSpent        BudgetAction = "spent"
+)
+
+// CreateExpenseRequestDTO for creating new expense requests, Amount is in Currency (BaseCurrency when omitted)
 type CreateExpenseRequestDTO struct {
 	Title       string  `json:"title" binding:"required,min=3"`
 	Category    string  `json:"category" binding:"required"`
 	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Currency    string  `json:"currency" binding:"omitempty,iso4217"`
 	Vendor      string  `json:"vendor" binding:"required,min=2"`
 	Description string  `json:"description" binding:"required,min=10"`
+	ExpenseDate string  `json:"expenseDate" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// CreateDraftExpenseDTO for saving an expense request as a draft
+// The remaining fields are checked against CreateExpenseRequestDTO when the draft is submitted.
+type CreateDraftExpenseDTO struct {
+	Title       string  `json:"title" binding:"required,min=3"`
+	Category    string  `json:"category"`
+	Amount      float64 `json:"amount" binding:"omitempty,gt=0"`
+	Currency    string  `json:"currency" binding:"omitempty,iso4217"`
+	Vendor      string  `json:"vendor" binding:"omitempty,min=2"`
+	Description string  `json:"description"`
+	ExpenseDate string  `json:"expenseDate" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// UpdateExpenseRequestDTO for editing a draft, pending or returned expense request, omitted fields are kept.
+// Amount is in the currency of the request after the edit.
+type UpdateExpenseRequestDTO struct {
+	Title       *string  `json:"title" binding:"omitempty,min=3"`
+	Category    *string  `json:"category" binding:"omitempty,min=1"`
+	Amount      *float64 `json:"amount" binding:"omitempty,gt=0"`
+	Currency    *string  `json:"currency" binding:"omitempty,iso4217"`
+	Vendor      *string  `json:"vendor" binding:"omitempty,min=2"`
+	Description *string  `json:"description" binding:"omitempty,min=10"`
+	ExpenseDate *string  `json:"expenseDate" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ExpenseRevision records the fields changed by one edit of an expense request
+type ExpenseRevision struct {
+	ID        uint                   `gorm:"primaryKey" json:"id"`
+	RequestID uint                   `gorm:"not null" json:"requestId"`
+	Revision  int                    `gorm:"not null" json:"revision"`
+	ChangedBy uint                   `gorm:"not null" json:"changedBy"`
+	Changes   map[string]FieldChange `gorm:"type:jsonb" json:"changes"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// FieldChange is the old and new value of an edited field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// ListExpensesDTO for filtering, sorting and paging expense requests
+type ListExpensesDTO struct {
+	Status     string   `form:"status"`
+	Category   string   `form:"category"`
+	Vendor     string   `form:"vendor"`
+	EmployeeID *uint    `form:"employeeId"`
+	ReviewerID *uint    `form:"reviewerId"`
+	MinAmount  *float64 `form:"minAmount" binding:"omitempty,gte=0"`
+	MaxAmount  *float64 `form:"maxAmount" binding:"omitempty,gte=0"`
+	From       string   `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To         string   `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Sort       string   `form:"sort" binding:"omitempty,oneof=created amount reviewed anomaly"`
+	Order      string   `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit      int      `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor     string   `form:"cursor"`
+}
+
+// ExpenseSort is the column expense requests are ordered by
+type ExpenseSort string
+
+const (
+	SortByCreated  ExpenseSort = "created"
+	SortByAmount   ExpenseSort = "amount"
+	SortByReviewed ExpenseSort = "reviewed"
+	SortByAnomaly  ExpenseSort = "anomaly"
+)
+
+// ExpenseCursor points at the last request of a page: its sort value and ID.
+// Value is a float64 for amount and anomaly sorting and a time.Time otherwise.
+type ExpenseCursor struct {
+	Value interface{}
+	ID    uint
+}
+
+// ExpenseFilter selects a page of expense requests
+type ExpenseFilter struct {
+	Status     string
+	Category   string
+	Vendor     string
+	EmployeeID *uint
+	ReviewerID *uint
+	MinAmount  *float64
+	MaxAmount  *float64
+	From       *time.Time
+	To         *time.Time
+	Sort       ExpenseSort
+	Desc       bool
+	Limit      int
+	After      *ExpenseCursor
+	ViewerID   uint // drafts of other users are left out
+}
+
+// ExpensePage is a page of expense requests with the total number of matches
+type ExpensePage struct {
+	Items      []ExpenseRequest `json:"items"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"nextCursor,omitempty"`
 }
 
 type TopExpenseRequest struct {
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ExpenseFlag marks a request that looks like a duplicate of another one or like part of a split purchase.
+// Flags are raised on submit and only inform reviewers.
+type ExpenseFlag struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RequestID  uint      `gorm:"not null" json:"requestId"`
+	Kind       FlagKind  `gorm:"type:varchar(30);not null" json:"kind"`
+	Reason     string    `gorm:"type:text;not null" json:"reason"`
+	RelatedIDs []uint    `gorm:"type:integer[]" json:"relatedIds"` // requests this one was compared with
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type FlagKind string
+
+const (
+	FlagDuplicate     FlagKind = "duplicate"
+	FlagSplitPurchase FlagKind = "split_purchase"
+)
+
+// ListSuspiciousDTO for listing flagged requests
+type ListSuspiciousDTO struct {
+	Kind   string `form:"kind" binding:"omitempty,oneof=duplicate split_purchase"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
@@ -1,18 +1,97 @@
 package models
 
+import "time"
+
 // UpdateExpenseStatusDTO for updating expense request status
 type UpdateExpenseStatusDTO struct {
 	Status   RequestStatus `json:"status" binding:"required,oneof=approved rejected"`
 	Comments string        `json:"comments" binding:"required,min=10"`
+	// OnBehalfOf is the reviewer who delegated the decision to the current user
+	OnBehalfOf *uint `json:"onBehalfOf"`
+}
+
+// ReturnExpenseDTO for sending an expense request back to its owner for changes
+type ReturnExpenseDTO struct {
+	Comments string `json:"comments" binding:"required,min=10"`
+}
+
+// SchedulePaymentDTO for scheduling the payment of an approved expense request
+type SchedulePaymentDTO struct {
+	PaymentDate string `json:"paymentDate" binding:"required,datetime=2006-01-02"`
+}
+
+// CreateBudgetDTO for creating the budget of the period containing a month
+type CreateBudgetDTO struct {
+	Year  int     `json:"year" binding:"required,min=2000,max=2100"`
+	Month int     `json:"month" binding:"required,min=1,max=12"`
+	Total float64 `json:"total" binding:"required,gt=0"`
+	// DepartmentID creates a department budget
+	DepartmentID *uint `json:"departmentId"`
+}
+
+// UpdateBudgetDTO for changing a budget total
+type UpdateBudgetDTO struct {
+	Total float64 `json:"total" binding:"required,gt=0"`
 }
 
-// RegisterUserDTO for user registration
+// RegisterUserDTO for user registration by invitation
 type RegisterUserDTO struct {
-	Email     string   `json:"email" binding:"required,email"`
-	Password  string   `json:"password" binding:"required,min=6"`
-	FirstName string   `json:"firstName" binding:"required"`
-	LastName  string   `json:"lastName" binding:"required"`
-	Role      UserRole `json:"role" binding:"required,oneof=employee management"`
+	Email           string `json:"email" binding:"required,email"`
+	Password        string `json:"password" binding:"required,min=6"`
+	FirstName       string `json:"firstName" binding:"required"`
+	LastName        string `json:"lastName" binding:"required"`
+	InvitationToken string `json:"invitationToken"`
+}
+
+// CreateInvitationDTO for inviting someone to register with a role
+type CreateInvitationDTO struct {
+	Email string   `json:"email" binding:"required,email"`
+	Role  UserRole `json:"role" binding:"required,max=20"`
+}
+
+// ListUsersDTO for searching users
+type ListUsersDTO struct {
+	Search string `form:"q"`
+	Role   string `form:"role" binding:"omitempty,max=20"`
+	Active *bool  `form:"active"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+
+	Department *uint `form:"department"`
+}
+
+// ChangeRoleDTO for changing the primary role of a user
+type ChangeRoleDTO struct {
+	Role UserRole `json:"role" binding:"required,max=20"`
+}
+
+// SetUserRolesDTO for replacing the additional roles of a user
+type SetUserRolesDTO struct {
+	Roles []UserRole `json:"roles" binding:"omitempty,dive,required,max=20"`
+}
+
+// CreateRoleDTO for defining a new role
+type CreateRoleDTO struct {
+	Name        UserRole     `json:"name" binding:"required,max=20,lowercase,alphanum"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" binding:"omitempty,dive,required"`
+}
+
+// UpdateRoleDTO for changing the description and permissions of a role
+type UpdateRoleDTO struct {
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" binding:"omitempty,dive,required"`
+}
+
+// ResetPasswordDTO for setting a new password of a user
+type ResetPasswordDTO struct {
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// InvitationResponse for a new invitation
+type InvitationResponse struct {
+	Invitation Invitation `json:"invitation"`
+	Token      string     `json:"token"`
 }
 
 // LoginDTO for user login
@@ -23,8 +102,22 @@ type LoginDTO struct {
 
 // LoginResponse for login response
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	User         User      `json:"user"`
+}
+
+// RefreshTokenDTO for exchanging a refresh token for a new token pair
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// TokenResponse for refresh response
+type TokenResponse struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
 }
 
 // StatsResponse for statistics
@@ -35,4 +128,11 @@ type StatsResponse struct {
 	ApprovedThisMonth int     `json:"approvedThisMonth"`
 	BudgetUsed        float64 `json:"budgetUsed"`
 	BudgetRemaining   float64 `json:"budgetRemaining"`
+
+	// Departments break the statistics down by department, when asked for
+	Departments []DepartmentStats `json:"departments,omitempty"`
+	// Anomalies summarize how unusual the pending requests are
+	Anomalies AnomalyStats `json:"anomalies"`
+	// Currency is the currency of the figures, BaseCurrency
+	Currency string `json:"currency"`
 }
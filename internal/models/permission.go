@@ -0,0 +1,47 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Permission is a named action guarded by RequirePermission, roles are sets of permissions
+type Permission string
+
+const (
+	PermExpensesViewAll Permission = "expenses.view_all"
+	PermExpensesApprove Permission = "expenses.approve"
+	PermExpensesPay     Permission = "expenses.pay"
+	PermReportsView     Permission = "reports.view"
+	PermBudgetView      Permission = "budget.view"
+	PermBudgetEdit      Permission = "budget.edit"
+	PermAuditView       Permission = "audit.view"
+	PermUsersManage     Permission = "users.manage"
+	PermCategoryManage  Permission = "categories.manage"
+	PermPolicyManage    Permission = "policies.manage"
+	PermRatesManage     Permission = "rates.manage"
+)
+
+// PermissionInfo describes a permission for role editors
+type PermissionInfo struct {
+	Name        Permission `gorm:"primaryKey;type:varchar(50)" json:"name"`
+	Description string     `json:"description"`
+}
+
+// Role is a named set of permissions. Built-in roles cannot be deleted.
+type Role struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        UserRole     `gorm:"uniqueIndex;type:varchar(20);not null" json:"name"`
+	Description string       `json:"description"`
+	BuiltIn     bool         `gorm:"not null;default:false" json:"builtIn"`
+	Permissions []Permission `gorm:"-" json:"permissions"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// PermissionSet is the union of the permissions of all roles of a user
+type PermissionSet []Permission
+
+// Has tells whether the set grants a permission
+func (s PermissionSet) Has(permission Permission) bool {
+	return slices.Contains(s, permission)
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// PolicyRule is a declarative business rule evaluated against expense requests.
+// It fires when its When condition matches. Every change bumps its Version.
+type PolicyRule struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	Name        string           `gorm:"not null" json:"name"`
+	Description string           `gorm:"type:text" json:"description"`
+	Stage       PolicyStage      `gorm:"type:varchar(20);not null" json:"stage"`
+	When        *PolicyCondition `gorm:"type:jsonb" json:"when,omitempty"`
+	Effect      PolicyEffect     `gorm:"type:varchar(20);not null" json:"effect"`
+	Require     *PolicyCondition `gorm:"type:jsonb" json:"require,omitempty"`
+	Message     string           `gorm:"not null" json:"message"`
+	IsActive    bool             `gorm:"not null;default:true" json:"isActive"`
+	Version     int              `gorm:"not null;default:1" json:"version"`
+	UpdatedBy   *uint            `json:"updatedBy,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
+
+// PolicyStage is the transition a rule is evaluated on
+type PolicyStage string
+
+const (
+	PolicyStageSubmit  PolicyStage = "submit"
+	PolicyStageApprove PolicyStage = "approve"
+	PolicyStageAll     PolicyStage = "all"
+)
+
+type PolicyEffect string
+
+const (
+	PolicyReject  PolicyEffect = "reject"
+	PolicyRequire PolicyEffect = "require"
+	PolicyWarn    PolicyEffect = "warn"
+)
+
+// PolicyCondition compares a request field with a value or combines conditions in All, Any or Not.
+// Example: {"field": "amount", "op": "gt", "value": 30000}
+type PolicyCondition struct {
+	All   []PolicyCondition `json:"all,omitempty"`
+	Any   []PolicyCondition `json:"any,omitempty"`
+	Not   *PolicyCondition  `json:"not,omitempty"`
+	Field string            `json:"field,omitempty"`
+	Op    string            `json:"op,omitempty"`
+	Value interface{}       `json:"value,omitempty" swaggertype:"object"`
+}
+
+// PolicyRuleDTO for creating a policy rule or changing it into its next version
+type PolicyRuleDTO struct {
+	Name        string           `json:"name" binding:"required,max=100"`
+	Description string           `json:"description" binding:"max=1000"`
+	Stage       PolicyStage      `json:"stage" binding:"required,oneof=submit approve all"`
+	When        *PolicyCondition `json:"when"`
+	Effect      PolicyEffect     `json:"effect" binding:"required,oneof=reject require warn"`
+	Require     *PolicyCondition `json:"require"`
+	Message     string           `json:"message" binding:"required,max=500"`
+	IsActive    *bool            `json:"isActive"` // true when omitted
+}
+
+// PolicyRuleVersion is a rule as it was in one of its versions
+type PolicyRuleVersion struct {
+	RuleID    uint       `gorm:"primaryKey" json:"ruleId"`
+	Version   int        `gorm:"primaryKey" json:"version"`
+	Rule      PolicyRule `gorm:"type:jsonb;column:definition" json:"rule"`
+	ChangedBy *uint      `json:"changedBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// PolicyEvaluation is the outcome of evaluating the active rules against a request on submit or approval
+type PolicyEvaluation struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	RequestID uint           `gorm:"not null" json:"requestId"`
+	Stage     PolicyStage    `gorm:"type:varchar(20);not null" json:"stage"`
+	Revision  int            `gorm:"not null" json:"revision"`
+	Outcome   PolicyOutcome  `gorm:"type:varchar(20);not null" json:"outcome"`
+	Results   []PolicyResult `gorm:"type:jsonb" json:"results"`
+	ActorID   *uint          `json:"actorId,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+type PolicyOutcome string
+
+const (
+	PolicyPassed  PolicyOutcome = "passed"
+	PolicyWarned  PolicyOutcome = "warned"
+	PolicyBlocked PolicyOutcome = "blocked"
+)
+
+// PolicyResult is a rule that fired in an evaluation
+type PolicyResult struct {
+	RuleID  uint         `json:"ruleId"`
+	Version int          `json:"version"`
+	Name    string       `json:"name"`
+	Effect  PolicyEffect `json:"effect"`
+	Message string       `json:"message"`
+}
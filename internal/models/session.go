@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session is one login of a user and owns the refresh tokens issued since
+type Session struct {
+	ID        string     `gorm:"primaryKey;type:char(32)" json:"id"`
+	UserID    uint       `gorm:"not null" json:"userId"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// RefreshToken is a single-use token of a session stored as a SHA-256 hash
+type RefreshToken struct {
+	Hash      string     `gorm:"primaryKey;type:char(64)" json:"-"`
+	SessionID string     `gorm:"not null" json:"sessionId"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type User struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -9,13 +12,73 @@ type User struct {
 	FirstName string    `gorm:"not null" json:"firstName"`
 	LastName  string    `gorm:"not null" json:"lastName"`
 	Role      UserRole  `gorm:"type:varchar(20);not null" json:"role"`
+	IsActive  bool      `gorm:"not null;default:true" json:"isActive"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// DepartmentID is the department whose budget the expenses of the user are charged to
+	DepartmentID *uint `json:"departmentId,omitempty"`
+	// TrustLevel from 0 to MaxTrustLevel, auto-approval rules may ask for a minimum
+	TrustLevel int `gorm:"not null;default:0" json:"trustLevel"`
+	// IsSystem marks the user recorded for automatic decisions
+	IsSystem bool `gorm:"not null;default:false" json:"isSystem"`
+	// AdditionalRoles are held on top of Role
+	AdditionalRoles []UserRole `gorm:"-" json:"additionalRoles"`
+	// Permissions are filled in only for the current user
+	Permissions PermissionSet `gorm:"-" json:"permissions,omitempty"`
+}
+
+// HasRole tells whether the user holds a role as the main or an additional one
+func (u *User) HasRole(role UserRole) bool {
+	return u.Role == role || slices.Contains(u.AdditionalRoles, role)
 }
 
+// UserRole names a role from the roles table
 type UserRole string
 
 const (
 	RoleEmployee   UserRole = "employee"
 	RoleManagement UserRole = "management"
+	RoleAdmin      UserRole = "admin"
+)
+
+// UserFilter selects users for administration
+type UserFilter struct {
+	Search string // part of the email or full name
+	Role   UserRole
+	Active *bool
+	Limit  int
+	Offset int
+
+	// Department selects the members of a department, not of its sub-departments
+	Department *uint
+}
+
+// UserPage is a page of users with the total number of matches
+type UserPage struct {
+	Items []User `json:"items"`
+	Total int    `json:"total"`
+}
+
+// Invitation lets one person register with the role chosen by whoever invited them.
+// The token is single-use and expires. Only its SHA-256 hash is stored.
+type Invitation struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Email     string     `gorm:"not null" json:"email"`
+	Role      UserRole   `gorm:"type:varchar(20);not null" json:"role"`
+	TokenHash string     `gorm:"uniqueIndex;type:char(64);not null" json:"-"`
+	InvitedBy *uint      `json:"invitedBy,omitempty"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	UsedBy    *uint      `json:"usedBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// RegistrationMode tells who may register
+type RegistrationMode string
+
+const (
+	// RegistrationOpen lets anyone register as an employee, other roles need an invitation
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationInvite lets only invited people register
+	RegistrationInvite RegistrationMode = "invite"
 )
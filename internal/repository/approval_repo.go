@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+)
+
+// ApprovalRepository handles approval chain operations
+type ApprovalRepository struct {
+	client *postgres.Client
+}
+
+func NewApprovalRepository(client *postgres.Client) *ApprovalRepository {
+	return &ApprovalRepository{client: client}
+}
+
+// CreateApprovalSteps stores the approval chain of a request
+func (r *ApprovalRepository) CreateApprovalSteps(ctx context.Context, steps []models.ApprovalStep) error {
+	query := `
+		INSERT INTO expense_approval_steps (request_id, step_order, name, min_amount, role, status, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		RETURNING id, created_at
+	`
+	now := time.Now().UTC()
+	for i := range steps {
+		err := r.client.QueryRow(
+			ctx, query,
+			steps[i].RequestID, steps[i].StepOrder, steps[i].Name, steps[i].MinAmount, steps[i].Role, models.ApprovalPending, now,
+		).Scan(&steps[i].ID, &steps[i].CreatedAt)
+		if err != nil {
+			return fmt.Errorf("CreateApprovalSteps: %w", err)
+		}
+		steps[i].Status = models.ApprovalPending
+	}
+	return nil
+}
+
+// GetApprovalSteps gets the approval chain of a request ordered by step
+func (r *ApprovalRepository) GetApprovalSteps(ctx context.Context, requestID uint) ([]models.ApprovalStep, error) {
+	query := `
+		SELECT s.id, s.request_id, s.step_order, s.name, s.min_amount, COALESCE(s.role, ''), s.status,
+		       s.reviewer_id, s.on_behalf_of, s.comments, s.decided_at, s.created_at,
+		       u.email, u.first_name, u.last_name, u.role
+		FROM expense_approval_steps s
+		LEFT JOIN users u ON s.reviewer_id = u.id
+		WHERE s.request_id = $1
+		ORDER BY s.step_order
+	`
+
+	rows, err := r.client.Query(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("GetApprovalSteps: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []models.ApprovalStep
+	for rows.Next() {
+		var step models.ApprovalStep
+		var comments *string
+		var reviewerEmail, reviewerFirstName, reviewerLastName, reviewerRole *string
+
+		if err = rows.Scan(
+			&step.ID, &step.RequestID, &step.StepOrder, &step.Name, &step.MinAmount, &step.Role, &step.Status,
+			&step.ReviewerID, &step.OnBehalfOfID, &comments, &step.DecidedAt, &step.CreatedAt,
+			&reviewerEmail, &reviewerFirstName, &reviewerLastName, &reviewerRole,
+		); err != nil {
+			return nil, fmt.Errorf("GetApprovalSteps scan: %w", err)
+		}
+
+		if comments != nil {
+			step.Comments = *comments
+		}
+
+		if step.ReviewerID != nil && reviewerEmail != nil {
+			step.Reviewer = &models.User{
+				ID:        *step.ReviewerID,
+				Email:     *reviewerEmail,
+				FirstName: *reviewerFirstName,
+				LastName:  *reviewerLastName,
+				Role:      models.UserRole(*reviewerRole),
+			}
+		}
+
+		steps = append(steps, step)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetApprovalSteps rows: %w", err)
+	}
+	return steps, nil
+}
+
+// DecideApprovalStep records a reviewer decision on a step, onBehalfOf is set when the reviewer acted under a delegation
+func (r *ApprovalRepository) DecideApprovalStep(ctx context.Context, stepID uint, reviewerID uint, onBehalfOf *uint, status models.ApprovalStatus, comments string) error {
+	query := `
+		UPDATE expense_approval_steps
+		SET status = $1, reviewer_id = $2, on_behalf_of = $3, comments = $4, decided_at = $5
+		WHERE id = $6 AND status = $7
+	`
+	tag, err := r.client.Exec(
+		ctx, query, status, reviewerID, onBehalfOf, comments, time.Now().UTC(), stepID, models.ApprovalPending,
+	)
+	if err != nil {
+		return fmt.Errorf("DecideApprovalStep: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("DecideApprovalStep: step %d is already decided", stepID)
+	}
+	return nil
+}
+
+// SkipPendingApprovalSteps marks every undecided step of a request as skipped
+func (r *ApprovalRepository) SkipPendingApprovalSteps(ctx context.Context, requestID uint) error {
+	query := `
+		UPDATE expense_approval_steps
+		SET status = $1, decided_at = $2
+		WHERE request_id = $3 AND status = $4
+	`
+	_, err := r.client.Exec(ctx, query, models.ApprovalSkipped, time.Now().UTC(), requestID, models.ApprovalPending)
+	if err != nil {
+		return fmt.Errorf("SkipPendingApprovalSteps: %w", err)
+	}
+	return nil
+}
+
+// DeleteApprovalSteps removes the approval chain of a request so it can be built anew
+func (r *ApprovalRepository) DeleteApprovalSteps(ctx context.Context, requestID uint) error {
+	query := `DELETE FROM expense_approval_steps WHERE request_id = $1`
+	if _, err := r.client.Exec(ctx, query, requestID); err != nil {
+		return fmt.Errorf("DeleteApprovalSteps: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+)
+
+// AttachmentRepository handles expense request attachment metadata
+type AttachmentRepository struct {
+	client *postgres.Client
+}
+
+func NewAttachmentRepository(client *postgres.Client) *AttachmentRepository {
+	return &AttachmentRepository{client: client}
+}
+
+const attachmentColumns = `id, request_id, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at`
+
+// CreateAttachment stores attachment metadata
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	query := `
+		INSERT INTO expense_attachments (request_id, file_name, content_type, size, checksum, storage_key, uploaded_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		attachment.RequestID, attachment.FileName, attachment.ContentType, attachment.Size,
+		attachment.Checksum, attachment.StorageKey, attachment.UploadedBy, time.Now().UTC(),
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateAttachment: %w", err)
+	}
+	return nil
+}
+
+// GetAttachment gets an attachment of a request by ID
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, requestID, id uint) (*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM expense_attachments WHERE request_id = $1 AND id = $2`
+
+	var attachment models.Attachment
+	err := r.client.QueryRow(ctx, query, requestID, id).Scan(
+		&attachment.ID, &attachment.RequestID, &attachment.FileName, &attachment.ContentType, &attachment.Size,
+		&attachment.Checksum, &attachment.StorageKey, &attachment.UploadedBy, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetAttachment: %w", err)
+	}
+	return &attachment, nil
+}
+
+// GetAttachmentsByRequest gets all attachments of a request, oldest first
+func (r *AttachmentRepository) GetAttachmentsByRequest(ctx context.Context, requestID uint) ([]models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM expense_attachments WHERE request_id = $1 ORDER BY created_at, id`
+
+	rows, err := r.client.Query(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("GetAttachmentsByRequest: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var attachment models.Attachment
+		if err = rows.Scan(
+			&attachment.ID, &attachment.RequestID, &attachment.FileName, &attachment.ContentType, &attachment.Size,
+			&attachment.Checksum, &attachment.StorageKey, &attachment.UploadedBy, &attachment.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetAttachmentsByRequest scan: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAttachmentsByRequest rows: %w", err)
+	}
+	return attachments, nil
+}
+
+// DeleteAttachment removes attachment metadata
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, id uint) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM expense_attachments WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("DeleteAttachment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+)
+
+// AuditRepository handles the append-only audit log
+type AuditRepository struct {
+	client *postgres.Client
+}
+
+func NewAuditRepository(client *postgres.Client) *AuditRepository {
+	return &AuditRepository{client: client}
+}
+
+// LockAuditChain waits for the other transactions appending to the audit log to commit,
+// so every record links to the one committed before it. The lock is held until the transaction ends.
+func (r *AuditRepository) LockAuditChain(ctx context.Context) error {
+	if _, err := r.client.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit_log'))`); err != nil {
+		return fmt.Errorf("LockAuditChain: %w", err)
+	}
+	return nil
+}
+
+// PrepareAuditEntry takes the ID of a new entry and links it to the last record of the log.
+// Before and After are replaced with the text the database keeps for them, as the hash covers that text.
+func (r *AuditRepository) PrepareAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		SELECT nextval(pg_get_serial_sequence('audit_log', 'id')),
+		       COALESCE((SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1), ''),
+		       $1::jsonb::text, $2::jsonb::text
+	`
+	var before, after *string
+	err := r.client.QueryRow(ctx, query, nullableJSON(entry.Before), nullableJSON(entry.After)).
+		Scan(&entry.ID, &entry.PrevHash, &before, &after)
+	if err != nil {
+		return fmt.Errorf("PrepareAuditEntry: %w", err)
+	}
+	entry.Before, entry.After = rawJSON(before), rawJSON(after)
+	return nil
+}
+
+// CreateAuditEntry appends an entry prepared with PrepareAuditEntry and hashed to the audit log
+func (r *AuditRepository) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log
+			(id, actor_id, action, entity_type, entity_id, before, after, request_id, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11)
+	`
+	_, err := r.client.Exec(
+		ctx, query,
+		entry.ID, entry.ActorID, entry.Action, entry.EntityType, entry.EntityID,
+		nullableJSON(entry.Before), nullableJSON(entry.After), entry.RequestID, entry.CreatedAt,
+		entry.PrevHash, entry.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("CreateAuditEntry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries gets audit entries matching the filter, newest first
+func (r *AuditRepository) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `
+		SELECT id, actor_id, action, entity_type, entity_id, before, after, request_id, created_at,
+		       COALESCE(prev_hash, ''), hash
+		FROM audit_log
+	`
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.EntityType != "" {
+		addCondition("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		addCondition("entity_id = $%d", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		addCondition("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.client.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ListAuditEntries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var requestID *string
+		if err = rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&entry.Before, &entry.After, &requestID, &entry.CreatedAt,
+			&entry.PrevHash, &entry.Hash,
+		); err != nil {
+			return nil, fmt.Errorf("ListAuditEntries scan: %w", err)
+		}
+		if requestID != nil {
+			entry.RequestID = *requestID
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListAuditEntries rows: %w", err)
+	}
+	return entries, nil
+}
+
+// GetAuditChain gets up to limit entries after afterID in chain order
+func (r *AuditRepository) GetAuditChain(ctx context.Context, afterID uint, limit int) ([]models.AuditEntry, error) {
+	query := `
+		SELECT id, actor_id, action, entity_type, entity_id, before::text, after::text, request_id, created_at,
+		       COALESCE(prev_hash, ''), COALESCE(hash, '')
+		FROM audit_log
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.client.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditChain: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after, requestID *string
+		if err = rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &requestID, &entry.CreatedAt,
+			&entry.PrevHash, &entry.Hash,
+		); err != nil {
+			return nil, fmt.Errorf("GetAuditChain scan: %w", err)
+		}
+		entry.Before, entry.After = rawJSON(before), rawJSON(after)
+		if requestID != nil {
+			entry.RequestID = *requestID
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAuditChain rows: %w", err)
+	}
+	return entries, nil
+}
+
+// nullableJSON stores empty documents as NULL
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// rawJSON keeps the text of a document as it is, NULL stays empty
+func rawJSON(text *string) json.RawMessage {
+	if text == nil {
+		return nil
+	}
+	return json.RawMessage(*text)
+}
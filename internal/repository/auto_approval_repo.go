@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AutoApprovalRepository handles the rules low-risk requests are approved automatically by
+type AutoApprovalRepository struct {
+	client *postgres.Client
+}
+
+func NewAutoApprovalRepository(client *postgres.Client) *AutoApprovalRepository {
+	return &AutoApprovalRepository{client: client}
+}
+
+const autoApprovalRuleColumns = `id, name, category, max_amount, min_trust_level, min_budget_remaining, is_active,
+	created_at, updated_at`
+
+func scanAutoApprovalRule(row pgx.Row) (*models.AutoApprovalRule, error) {
+	var rule models.AutoApprovalRule
+	err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Category, &rule.MaxAmount, &rule.MinTrustLevel, &rule.MinBudgetRemaining,
+		&rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListAutoApprovalRules gets the rules in the order they are tried, inactive ones only when asked for
+func (r *AutoApprovalRepository) ListAutoApprovalRules(ctx context.Context, includeInactive bool) ([]models.AutoApprovalRule, error) {
+	query := `SELECT ` + autoApprovalRuleColumns + ` FROM auto_approval_rules WHERE is_active OR $1 ORDER BY id`
+
+	rows, err := r.client.Query(ctx, query, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("ListAutoApprovalRules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.AutoApprovalRule{}
+	for rows.Next() {
+		rule, err := scanAutoApprovalRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListAutoApprovalRules scan: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListAutoApprovalRules rows: %w", err)
+	}
+	return rules, nil
+}
+
+// GetAutoApprovalRule gets a rule by ID
+func (r *AutoApprovalRepository) GetAutoApprovalRule(ctx context.Context, id uint) (*models.AutoApprovalRule, error) {
+	query := `SELECT ` + autoApprovalRuleColumns + ` FROM auto_approval_rules WHERE id = $1`
+
+	rule, err := scanAutoApprovalRule(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetAutoApprovalRule: %w", err)
+	}
+	return rule, nil
+}
+
+// CreateAutoApprovalRule stores a new rule
+func (r *AutoApprovalRepository) CreateAutoApprovalRule(ctx context.Context, rule *models.AutoApprovalRule) error {
+	query := `
+		INSERT INTO auto_approval_rules
+			(name, category, max_amount, min_trust_level, min_budget_remaining, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		rule.Name, rule.Category, rule.MaxAmount, rule.MinTrustLevel, rule.MinBudgetRemaining, rule.IsActive,
+		time.Now().UTC(),
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateAutoApprovalRule: %w", err)
+	}
+	return nil
+}
+
+// UpdateAutoApprovalRule changes a rule
+func (r *AutoApprovalRepository) UpdateAutoApprovalRule(ctx context.Context, rule *models.AutoApprovalRule) error {
+	query := `
+		UPDATE auto_approval_rules
+		SET name = $1, category = $2, max_amount = $3, min_trust_level = $4, min_budget_remaining = $5,
+		    is_active = $6, updated_at = $7
+		WHERE id = $8
+		RETURNING updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		rule.Name, rule.Category, rule.MaxAmount, rule.MinTrustLevel, rule.MinBudgetRemaining, rule.IsActive,
+		time.Now().UTC(), rule.ID,
+	).Scan(&rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("UpdateAutoApprovalRule: %w", err)
+	}
+	return nil
+}
+
+// DeleteAutoApprovalRule deletes a rule
+func (r *AutoApprovalRepository) DeleteAutoApprovalRule(ctx context.Context, id uint) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM auto_approval_rules WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("DeleteAutoApprovalRule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CategoryRepository handles the expense category registry
+type CategoryRepository struct {
+	client *postgres.Client
+}
+
+func NewCategoryRepository(client *postgres.Client) *CategoryRepository {
+	return &CategoryRepository{client: client}
+}
+
+const categoryColumns = `id, code, name, names, parent_id, is_active, max_amount, monthly_cap, receipt_required,
+	created_at, updated_at`
+
+func scanCategory(row pgx.Row) (*models.Category, error) {
+	var category models.Category
+	err := row.Scan(
+		&category.ID, &category.Code, &category.Name, &category.Names, &category.ParentID, &category.IsActive,
+		&category.MaxAmount, &category.MonthlyCap, &category.ReceiptRequired, &category.CreatedAt, &category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// ListCategories gets the categories ordered by code, inactive ones only when asked for
+func (r *CategoryRepository) ListCategories(ctx context.Context, includeInactive bool) ([]models.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM expense_categories WHERE is_active OR $1 ORDER BY code`
+
+	rows, err := r.client.Query(ctx, query, includeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("ListCategories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListCategories scan: %w", err)
+		}
+		categories = append(categories, *category)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListCategories rows: %w", err)
+	}
+	return categories, nil
+}
+
+// GetCategory gets a category by ID
+func (r *CategoryRepository) GetCategory(ctx context.Context, id uint) (*models.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM expense_categories WHERE id = $1`
+
+	category, err := scanCategory(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetCategory: %w", err)
+	}
+	return category, nil
+}
+
+// GetCategoryByCode gets a category by the code requests refer to it with
+func (r *CategoryRepository) GetCategoryByCode(ctx context.Context, code string) (*models.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM expense_categories WHERE code = $1`
+
+	category, err := scanCategory(r.client.QueryRow(ctx, query, code))
+	if err != nil {
+		return nil, fmt.Errorf("GetCategoryByCode: %w", err)
+	}
+	return category, nil
+}
+
+// GetCategoryPath gets the IDs of a category and all categories above it
+func (r *CategoryRepository) GetCategoryPath(ctx context.Context, id uint) ([]uint, error) {
+	query := `
+		WITH RECURSIVE path AS (
+			SELECT id, parent_id FROM expense_categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.parent_id FROM expense_categories c JOIN path p ON c.id = p.parent_id
+		)
+		SELECT id FROM path
+	`
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetCategoryPath: %w", err)
+	}
+	defer rows.Close()
+
+	var path []uint
+	for rows.Next() {
+		var categoryID uint
+		if err = rows.Scan(&categoryID); err != nil {
+			return nil, fmt.Errorf("GetCategoryPath scan: %w", err)
+		}
+		path = append(path, categoryID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetCategoryPath rows: %w", err)
+	}
+	return path, nil
+}
+
+// CreateCategory stores a new category
+func (r *CategoryRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	query := `
+		INSERT INTO expense_categories
+			(code, name, names, parent_id, is_active, max_amount, monthly_cap, receipt_required, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		category.Code, category.Name, category.Names, category.ParentID, category.IsActive,
+		category.MaxAmount, category.MonthlyCap, category.ReceiptRequired, time.Now().UTC(),
+	).Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateCategory: %w", err)
+	}
+	return nil
+}
+
+// UpdateCategory changes everything but the code of a category
+func (r *CategoryRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	query := `
+		UPDATE expense_categories
+		SET name = $1, names = $2, parent_id = $3, is_active = $4, max_amount = $5, monthly_cap = $6,
+		    receipt_required = $7, updated_at = $8
+		WHERE id = $9
+		RETURNING updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		category.Name, category.Names, category.ParentID, category.IsActive, category.MaxAmount,
+		category.MonthlyCap, category.ReceiptRequired, time.Now().UTC(), category.ID,
+	).Scan(&category.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("UpdateCategory: %w", err)
+	}
+	return nil
+}
+
+// DeleteCategory deletes a category
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, id uint) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM expense_categories WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("DeleteCategory: %w", err)
+	}
+	return nil
+}
+
+// CategoryInUse tells whether a category has sub-categories or is referred to by requests or delegations
+func (r *CategoryRepository) CategoryInUse(ctx context.Context, category *models.Category) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM expense_categories WHERE parent_id = $1)
+			OR EXISTS (SELECT 1 FROM expense_requests WHERE category = $2)
+			OR EXISTS (SELECT 1 FROM approval_delegations WHERE category = $2)
+	`
+	var inUse bool
+	if err := r.client.QueryRow(ctx, query, category.ID, category.Code).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("CategoryInUse: %w", err)
+	}
+	return inUse, nil
+}
+
+// GetMonthlyTotal sums the amounts an employee claims in a category with expense dates in [from, to).
+// Drafts, rejected and withdrawn requests are left out, as is the request excludeID.
+func (r *CategoryRepository) GetMonthlyTotal(
+	ctx context.Context, employeeID uint, code string, from, to time.Time, excludeID uint,
+) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM expense_requests
+		WHERE employee_id = $1 AND category = $2 AND expense_date >= $3 AND expense_date < $4
+		  AND id <> $5 AND status <> ALL($6)
+	`
+	excluded := []string{string(models.StatusDraft), string(models.StatusRejected), string(models.StatusWithdrawn)}
+
+	var total float64
+	err := r.client.QueryRow(ctx, query, employeeID, code, from, to, excludeID, excluded).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("GetMonthlyTotal: %w", err)
+	}
+	return total, nil
+}
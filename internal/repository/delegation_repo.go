@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DelegationRepository handles approval delegations
+type DelegationRepository struct {
+	client *postgres.Client
+}
+
+func NewDelegationRepository(client *postgres.Client) *DelegationRepository {
+	return &DelegationRepository{client: client}
+}
+
+const delegationColumns = `id, delegator_id, delegate_id, starts_on, ends_on, category, max_amount, revoked_at, created_at`
+
+func scanDelegation(row pgx.Row) (*models.Delegation, error) {
+	var delegation models.Delegation
+	err := row.Scan(
+		&delegation.ID, &delegation.DelegatorID, &delegation.DelegateID, &delegation.StartsOn, &delegation.EndsOn,
+		&delegation.Category, &delegation.MaxAmount, &delegation.RevokedAt, &delegation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+func scanDelegations(rows pgx.Rows) ([]models.Delegation, error) {
+	defer rows.Close()
+
+	delegations := []models.Delegation{}
+	for rows.Next() {
+		delegation, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, *delegation)
+	}
+	return delegations, rows.Err()
+}
+
+// CreateDelegation stores a new delegation
+func (r *DelegationRepository) CreateDelegation(ctx context.Context, delegation *models.Delegation) error {
+	query := `
+		INSERT INTO approval_delegations (delegator_id, delegate_id, starts_on, ends_on, category, max_amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		delegation.DelegatorID, delegation.DelegateID, delegation.StartsOn, delegation.EndsOn,
+		delegation.Category, delegation.MaxAmount, time.Now().UTC(),
+	).Scan(&delegation.ID, &delegation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateDelegation: %w", err)
+	}
+	return nil
+}
+
+// GetDelegation gets a delegation by ID
+func (r *DelegationRepository) GetDelegation(ctx context.Context, id uint) (*models.Delegation, error) {
+	query := `SELECT ` + delegationColumns + ` FROM approval_delegations WHERE id = $1`
+
+	delegation, err := scanDelegation(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetDelegation: %w", err)
+	}
+	return delegation, nil
+}
+
+// ListUserDelegations gets the delegations a user gave or received, latest start first
+func (r *DelegationRepository) ListUserDelegations(ctx context.Context, userID uint) ([]models.Delegation, error) {
+	query := `
+		SELECT ` + delegationColumns + `
+		FROM approval_delegations
+		WHERE delegator_id = $1 OR delegate_id = $1
+		ORDER BY starts_on DESC, id DESC
+	`
+	rows, err := r.client.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListUserDelegations: %w", err)
+	}
+
+	delegations, err := scanDelegations(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListUserDelegations scan: %w", err)
+	}
+	return delegations, nil
+}
+
+// ListActiveDelegations gets the unrevoked delegations from a delegator to a delegate that include a day
+func (r *DelegationRepository) ListActiveDelegations(ctx context.Context, delegatorID, delegateID uint, day time.Time) ([]models.Delegation, error) {
+	query := `
+		SELECT ` + delegationColumns + `
+		FROM approval_delegations
+		WHERE delegator_id = $1 AND delegate_id = $2 AND revoked_at IS NULL AND $3 BETWEEN starts_on AND ends_on
+		ORDER BY id
+	`
+	rows, err := r.client.Query(ctx, query, delegatorID, delegateID, day)
+	if err != nil {
+		return nil, fmt.Errorf("ListActiveDelegations: %w", err)
+	}
+
+	delegations, err := scanDelegations(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListActiveDelegations scan: %w", err)
+	}
+	return delegations, nil
+}
+
+// RevokeDelegation ends a delegation, revoking it again keeps the original time
+func (r *DelegationRepository) RevokeDelegation(ctx context.Context, id uint) error {
+	query := `UPDATE approval_delegations SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+	if _, err := r.client.Exec(ctx, query, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("RevokeDelegation: %w", err)
+	}
+	return nil
+}
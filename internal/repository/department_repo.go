@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DepartmentRepository handles the department tree
+type DepartmentRepository struct {
+	client *postgres.Client
+}
+
+func NewDepartmentRepository(client *postgres.Client) *DepartmentRepository {
+	return &DepartmentRepository{client: client}
+}
+
+const departmentColumns = `id, name, code, parent_id, created_at, updated_at`
+
+func scanDepartment(row pgx.Row) (*models.Department, error) {
+	var department models.Department
+	err := row.Scan(
+		&department.ID, &department.Name, &department.Code, &department.ParentID,
+		&department.CreatedAt, &department.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &department, nil
+}
+
+// ListDepartments gets all departments ordered by code
+func (r *DepartmentRepository) ListDepartments(ctx context.Context) ([]models.Department, error) {
+	rows, err := r.client.Query(ctx, `SELECT `+departmentColumns+` FROM departments ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("ListDepartments: %w", err)
+	}
+	defer rows.Close()
+
+	departments := []models.Department{}
+	for rows.Next() {
+		department, err := scanDepartment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListDepartments scan: %w", err)
+		}
+		departments = append(departments, *department)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListDepartments rows: %w", err)
+	}
+	return departments, nil
+}
+
+// GetDepartment gets a department by ID
+func (r *DepartmentRepository) GetDepartment(ctx context.Context, id uint) (*models.Department, error) {
+	query := `SELECT ` + departmentColumns + ` FROM departments WHERE id = $1`
+
+	department, err := scanDepartment(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetDepartment: %w", err)
+	}
+	return department, nil
+}
+
+// GetDepartmentByCode gets a department by its cost center code
+func (r *DepartmentRepository) GetDepartmentByCode(ctx context.Context, code string) (*models.Department, error) {
+	query := `SELECT ` + departmentColumns + ` FROM departments WHERE code = $1`
+
+	department, err := scanDepartment(r.client.QueryRow(ctx, query, code))
+	if err != nil {
+		return nil, fmt.Errorf("GetDepartmentByCode: %w", err)
+	}
+	return department, nil
+}
+
+// GetDepartmentPath gets the IDs of a department and those above it, topmost first
+func (r *DepartmentRepository) GetDepartmentPath(ctx context.Context, id uint) ([]uint, error) {
+	query := `
+		WITH RECURSIVE path AS (
+			SELECT id, parent_id, 0 AS depth FROM departments WHERE id = $1
+			UNION ALL
+			SELECT d.id, d.parent_id, p.depth + 1 FROM departments d JOIN path p ON d.id = p.parent_id
+		)
+		SELECT id FROM path ORDER BY depth DESC
+	`
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetDepartmentPath: %w", err)
+	}
+	defer rows.Close()
+
+	var path []uint
+	for rows.Next() {
+		var departmentID uint
+		if err = rows.Scan(&departmentID); err != nil {
+			return nil, fmt.Errorf("GetDepartmentPath scan: %w", err)
+		}
+		path = append(path, departmentID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetDepartmentPath rows: %w", err)
+	}
+	return path, nil
+}
+
+// CreateDepartment stores a new department
+func (r *DepartmentRepository) CreateDepartment(ctx context.Context, department *models.Department) error {
+	query := `
+		INSERT INTO departments (name, code, parent_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query, department.Name, department.Code, department.ParentID, time.Now().UTC(),
+	).Scan(&department.ID, &department.CreatedAt, &department.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateDepartment: %w", err)
+	}
+	return nil
+}
+
+// UpdateDepartment changes the name, code and parent of a department
+func (r *DepartmentRepository) UpdateDepartment(ctx context.Context, department *models.Department) error {
+	query := `
+		UPDATE departments
+		SET name = $1, code = $2, parent_id = $3, updated_at = $4
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query, department.Name, department.Code, department.ParentID, time.Now().UTC(), department.ID,
+	).Scan(&department.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("UpdateDepartment: %w", err)
+	}
+	return nil
+}
+
+// DeleteDepartment deletes a department
+func (r *DepartmentRepository) DeleteDepartment(ctx context.Context, id uint) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM departments WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("DeleteDepartment: %w", err)
+	}
+	return nil
+}
+
+// DepartmentInUse tells whether a department still has sub-departments, members or budgets
+func (r *DepartmentRepository) DepartmentInUse(ctx context.Context, id uint) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM departments WHERE parent_id = $1)
+			OR EXISTS (SELECT 1 FROM users WHERE department_id = $1)
+			OR EXISTS (SELECT 1 FROM budgets WHERE department_id = $1)
+	`
+	var inUse bool
+	if err := r.client.QueryRow(ctx, query, id).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("DepartmentInUse: %w", err)
+	}
+	return inUse, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ExchangeRateRepository handles the exchange rates of foreign currencies to the base currency
+type ExchangeRateRepository struct {
+	client *postgres.Client
+}
+
+func NewExchangeRateRepository(client *postgres.Client) *ExchangeRateRepository {
+	return &ExchangeRateRepository{client: client}
+}
+
+const exchangeRateColumns = `id, currency, rate_date, rate, updated_by, created_at, updated_at`
+
+func scanExchangeRate(row pgx.Row) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := row.Scan(
+		&rate.ID, &rate.Currency, &rate.RateDate, &rate.Rate, &rate.UpdatedBy, &rate.CreatedAt, &rate.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// ListExchangeRates gets the rates of one or all currencies in [from, to], latest first
+func (r *ExchangeRateRepository) ListExchangeRates(
+	ctx context.Context, currency string, from, to *time.Time,
+) ([]models.ExchangeRate, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if currency != "" {
+		addCondition("currency = $%d", currency)
+	}
+	if from != nil {
+		addCondition("rate_date >= $%d", *from)
+	}
+	if to != nil {
+		addCondition("rate_date <= $%d", *to)
+	}
+
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY rate_date DESC, currency"
+
+	rows, err := r.client.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ListExchangeRates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		rate, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListExchangeRates scan: %w", err)
+		}
+		rates = append(rates, *rate)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListExchangeRates rows: %w", err)
+	}
+	return rates, nil
+}
+
+// GetExchangeRate gets the rate of a currency set for exactly a date
+func (r *ExchangeRateRepository) GetExchangeRate(ctx context.Context, currency string, date time.Time) (*models.ExchangeRate, error) {
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates WHERE currency = $1 AND rate_date = $2`
+
+	rate, err := scanExchangeRate(r.client.QueryRow(ctx, query, currency, date))
+	if err != nil {
+		return nil, fmt.Errorf("GetExchangeRate: %w", err)
+	}
+	return rate, nil
+}
+
+// GetRateOn gets the rate of a currency in effect on a date: the latest one set on or before it
+func (r *ExchangeRateRepository) GetRateOn(ctx context.Context, currency string, date time.Time) (*models.ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateColumns + `
+		FROM exchange_rates
+		WHERE currency = $1 AND rate_date <= $2
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`
+	rate, err := scanExchangeRate(r.client.QueryRow(ctx, query, currency, date))
+	if err != nil {
+		return nil, fmt.Errorf("GetRateOn: %w", err)
+	}
+	return rate, nil
+}
+
+// UpsertExchangeRate sets the rate of a currency on a date, replacing the one set before
+func (r *ExchangeRateRepository) UpsertExchangeRate(ctx context.Context, rate *models.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (currency, rate_date, rate, updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (currency, rate_date) DO UPDATE
+		SET rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query, rate.Currency, rate.RateDate, rate.Rate, rate.UpdatedBy, time.Now().UTC(),
+	).Scan(&rate.ID, &rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("UpsertExchangeRate: %w", err)
+	}
+	return nil
+}
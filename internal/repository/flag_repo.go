@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+)
+
+// FlagRepository handles the flags of requests that look like duplicates or split purchases
+type FlagRepository struct {
+	client *postgres.Client
+}
+
+func NewFlagRepository(client *postgres.Client) *FlagRepository {
+	return &FlagRepository{client: client}
+}
+
+// ListComparableRequests gets the requests of the same employee or the same vendor as a request
+// with expense dates in [from, to]. Drafts, rejected and withdrawn requests are left out.
+func (r *FlagRepository) ListComparableRequests(
+	ctx context.Context, request *models.ExpenseRequest, from, to time.Time,
+) ([]models.ExpenseRequest, error) {
+	query := expenseRequestSelect + `
+		WHERE er.id <> $1 AND er.status <> ALL($2)
+		  AND (er.employee_id = $3 OR lower(trim(er.vendor)) = lower(trim($4)))
+		  AND er.expense_date BETWEEN $5 AND $6
+		ORDER BY er.expense_date, er.id
+	`
+	excluded := []string{string(models.StatusDraft), string(models.StatusRejected), string(models.StatusWithdrawn)}
+
+	rows, err := r.client.Query(ctx, query, request.ID, excluded, request.EmployeeID, request.Vendor, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ListComparableRequests: %w", err)
+	}
+	requests, err := scanExpenseRequests(rows)
+	if err != nil {
+		return nil, fmt.Errorf("ListComparableRequests scan: %w", err)
+	}
+	return requests, nil
+}
+
+// ReplaceFlags replaces the flags of a request
+func (r *FlagRepository) ReplaceFlags(ctx context.Context, requestID uint, flags []models.ExpenseFlag) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM expense_flags WHERE request_id = $1`, requestID); err != nil {
+		return fmt.Errorf("ReplaceFlags delete: %w", err)
+	}
+
+	query := `
+		INSERT INTO expense_flags (request_id, kind, reason, related_ids, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	for i := range flags {
+		flag := &flags[i]
+		relatedIDs := make([]int64, len(flag.RelatedIDs))
+		for j, id := range flag.RelatedIDs {
+			relatedIDs[j] = int64(id)
+		}
+
+		err := r.client.QueryRow(
+			ctx, query, requestID, flag.Kind, flag.Reason, relatedIDs, time.Now().UTC(),
+		).Scan(&flag.ID, &flag.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("ReplaceFlags insert: %w", err)
+		}
+		flag.RequestID = requestID
+	}
+	return nil
+}
+
+// GetFlagsByRequests gets the flags of requests by request ID
+func (r *FlagRepository) GetFlagsByRequests(ctx context.Context, requestIDs []uint) (map[uint][]models.ExpenseFlag, error) {
+	ids := make([]int64, len(requestIDs))
+	for i, id := range requestIDs {
+		ids[i] = int64(id)
+	}
+
+	query := `
+		SELECT id, request_id, kind, reason, related_ids, created_at
+		FROM expense_flags
+		WHERE request_id = ANY($1)
+		ORDER BY created_at, id
+	`
+	rows, err := r.client.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("GetFlagsByRequests: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make(map[uint][]models.ExpenseFlag)
+	for rows.Next() {
+		var flag models.ExpenseFlag
+		var relatedIDs []int64
+		if err = rows.Scan(
+			&flag.ID, &flag.RequestID, &flag.Kind, &flag.Reason, &relatedIDs, &flag.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetFlagsByRequests scan: %w", err)
+		}
+		flag.RelatedIDs = make([]uint, len(relatedIDs))
+		for i, id := range relatedIDs {
+			flag.RelatedIDs[i] = uint(id)
+		}
+		flags[flag.RequestID] = append(flags[flag.RequestID], flag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetFlagsByRequests rows: %w", err)
+	}
+	return flags, nil
+}
+
+// ListFlaggedRequests gets a page of flagged requests and the total number of matches
+func (r *FlagRepository) ListFlaggedRequests(
+	ctx context.Context, kind models.FlagKind, limit, offset int,
+) ([]models.ExpenseRequest, int, error) {
+	flagged := `
+		SELECT request_id, MAX(created_at) AS flagged_at
+		FROM expense_flags
+		WHERE $1::text = '' OR kind = $1
+		GROUP BY request_id
+	`
+
+	var total int
+	if err := r.client.QueryRow(ctx, `SELECT COUNT(*) FROM (`+flagged+`) f`, kind).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ListFlaggedRequests count: %w", err)
+	}
+
+	query := `WITH flagged AS (` + flagged + `)` + expenseRequestSelect + `
+		JOIN flagged f ON f.request_id = er.id
+		ORDER BY f.flagged_at DESC, er.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.client.Query(ctx, query, kind, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListFlaggedRequests: %w", err)
+	}
+	requests, err := scanExpenseRequests(rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListFlaggedRequests scan: %w", err)
+	}
+	return requests, total, nil
+}
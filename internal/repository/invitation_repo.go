@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InvitationRepository handles registration invitations
+type InvitationRepository struct {
+	client *postgres.Client
+}
+
+func NewInvitationRepository(client *postgres.Client) *InvitationRepository {
+	return &InvitationRepository{client: client}
+}
+
+const invitationColumns = `id, email, role, token_hash, invited_by, expires_at, used_at, used_by, created_at`
+
+func scanInvitation(row pgx.Row) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := row.Scan(
+		&invitation.ID, &invitation.Email, &invitation.Role, &invitation.TokenHash, &invitation.InvitedBy,
+		&invitation.ExpiresAt, &invitation.UsedAt, &invitation.UsedBy, &invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// CreateInvitation stores a new invitation
+func (r *InvitationRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	query := `
+		INSERT INTO invitations (email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		invitation.Email, invitation.Role, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt, time.Now().UTC(),
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateInvitation: %w", err)
+	}
+	return nil
+}
+
+// LockInvitationByToken gets an invitation by token hash and locks it until the end of the current transaction
+func (r *InvitationRepository) LockInvitationByToken(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations WHERE token_hash = $1 FOR UPDATE`
+
+	invitation, err := scanInvitation(r.client.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		return nil, fmt.Errorf("LockInvitationByToken: %w", err)
+	}
+	return invitation, nil
+}
+
+// MarkInvitationUsed records who registered with an invitation
+func (r *InvitationRepository) MarkInvitationUsed(ctx context.Context, id, userID uint) error {
+	query := `UPDATE invitations SET used_at = $1, used_by = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, time.Now().UTC(), userID, id); err != nil {
+		return fmt.Errorf("MarkInvitationUsed: %w", err)
+	}
+	return nil
+}
+
+// ListInvitations gets all invitations, newest first
+func (r *InvitationRepository) ListInvitations(ctx context.Context) ([]models.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM invitations ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ListInvitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListInvitations scan: %w", err)
+		}
+		invitations = append(invitations, *invitation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListInvitations rows: %w", err)
+	}
+	return invitations, nil
+}
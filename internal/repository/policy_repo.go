@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PolicyRepository handles policy rules, their versions and their evaluations against requests
+type PolicyRepository struct {
+	client *postgres.Client
+}
+
+func NewPolicyRepository(client *postgres.Client) *PolicyRepository {
+	return &PolicyRepository{client: client}
+}
+
+const policyRuleColumns = `id, name, description, stage, when_condition, effect, require_condition, message, is_active,
+	version, updated_by, created_at, updated_at`
+
+func scanPolicyRule(row pgx.Row) (*models.PolicyRule, error) {
+	var rule models.PolicyRule
+	err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Description, &rule.Stage, &rule.When, &rule.Effect, &rule.Require, &rule.Message,
+		&rule.IsActive, &rule.Version, &rule.UpdatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListPolicyRules gets the rules in the order they are evaluated, inactive ones only when asked for
+func (r *PolicyRepository) ListPolicyRules(ctx context.Context, includeInactive bool) ([]models.PolicyRule, error) {
+	query := `SELECT ` + policyRuleColumns + ` FROM policy_rules WHERE is_active OR $1 ORDER BY id`
+	return r.listPolicyRules(ctx, "ListPolicyRules", query, includeInactive)
+}
+
+// ListActivePolicyRules gets the active rules evaluated on a stage
+func (r *PolicyRepository) ListActivePolicyRules(ctx context.Context, stage models.PolicyStage) ([]models.PolicyRule, error) {
+	query := `SELECT ` + policyRuleColumns + ` FROM policy_rules WHERE is_active AND stage IN ($1, $2) ORDER BY id`
+	return r.listPolicyRules(ctx, "ListActivePolicyRules", query, stage, models.PolicyStageAll)
+}
+
+func (r *PolicyRepository) listPolicyRules(ctx context.Context, caller, query string, args ...interface{}) ([]models.PolicyRule, error) {
+	rows, err := r.client.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	rules := []models.PolicyRule{}
+	for rows.Next() {
+		rule, err := scanPolicyRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s scan: %w", caller, err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s rows: %w", caller, err)
+	}
+	return rules, nil
+}
+
+// GetPolicyRule gets the current version of a rule
+func (r *PolicyRepository) GetPolicyRule(ctx context.Context, id uint) (*models.PolicyRule, error) {
+	query := `SELECT ` + policyRuleColumns + ` FROM policy_rules WHERE id = $1`
+
+	rule, err := scanPolicyRule(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetPolicyRule: %w", err)
+	}
+	return rule, nil
+}
+
+// CreatePolicyRule stores the first version of a rule
+func (r *PolicyRepository) CreatePolicyRule(ctx context.Context, rule *models.PolicyRule) error {
+	query := `
+		INSERT INTO policy_rules
+			(name, description, stage, when_condition, effect, require_condition, message, is_active, version,
+			 updated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1, $9, $10, $10)
+		RETURNING id, version, created_at, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		rule.Name, rule.Description, rule.Stage, rule.When, rule.Effect, rule.Require, rule.Message, rule.IsActive,
+		rule.UpdatedBy, time.Now().UTC(),
+	).Scan(&rule.ID, &rule.Version, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("CreatePolicyRule: %w", err)
+	}
+	return r.createPolicyRuleVersion(ctx, rule)
+}
+
+// UpdatePolicyRule stores the next version of a rule
+func (r *PolicyRepository) UpdatePolicyRule(ctx context.Context, rule *models.PolicyRule) error {
+	query := `
+		UPDATE policy_rules
+		SET name = $1, description = $2, stage = $3, when_condition = $4, effect = $5, require_condition = $6,
+		    message = $7, is_active = $8, version = version + 1, updated_by = $9, updated_at = $10
+		WHERE id = $11
+		RETURNING version, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		rule.Name, rule.Description, rule.Stage, rule.When, rule.Effect, rule.Require, rule.Message, rule.IsActive,
+		rule.UpdatedBy, time.Now().UTC(), rule.ID,
+	).Scan(&rule.Version, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("UpdatePolicyRule: %w", err)
+	}
+	return r.createPolicyRuleVersion(ctx, rule)
+}
+
+// createPolicyRuleVersion keeps a copy of the rule as it is in its current version
+func (r *PolicyRepository) createPolicyRuleVersion(ctx context.Context, rule *models.PolicyRule) error {
+	query := `
+		INSERT INTO policy_rule_versions (rule_id, version, definition, changed_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.client.Exec(ctx, query, rule.ID, rule.Version, rule, rule.UpdatedBy, rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("createPolicyRuleVersion: %w", err)
+	}
+	return nil
+}
+
+// GetPolicyRuleVersions gets every version of a rule, latest first
+func (r *PolicyRepository) GetPolicyRuleVersions(ctx context.Context, ruleID uint) ([]models.PolicyRuleVersion, error) {
+	query := `
+		SELECT rule_id, version, definition, changed_by, created_at
+		FROM policy_rule_versions
+		WHERE rule_id = $1
+		ORDER BY version DESC
+	`
+	rows, err := r.client.Query(ctx, query, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPolicyRuleVersions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []models.PolicyRuleVersion{}
+	for rows.Next() {
+		var version models.PolicyRuleVersion
+		if err = rows.Scan(
+			&version.RuleID, &version.Version, &version.Rule, &version.ChangedBy, &version.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetPolicyRuleVersions scan: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetPolicyRuleVersions rows: %w", err)
+	}
+	return versions, nil
+}
+
+// CreatePolicyEvaluation stores the outcome of evaluating the rules against a request
+func (r *PolicyRepository) CreatePolicyEvaluation(ctx context.Context, evaluation *models.PolicyEvaluation) error {
+	query := `
+		INSERT INTO policy_evaluations (request_id, stage, revision, outcome, results, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		evaluation.RequestID, evaluation.Stage, evaluation.Revision, evaluation.Outcome, evaluation.Results,
+		evaluation.ActorID, time.Now().UTC(),
+	).Scan(&evaluation.ID, &evaluation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreatePolicyEvaluation: %w", err)
+	}
+	return nil
+}
+
+// GetPolicyEvaluations gets the evaluations of a request in the order they were made
+func (r *PolicyRepository) GetPolicyEvaluations(ctx context.Context, requestID uint) ([]models.PolicyEvaluation, error) {
+	query := `
+		SELECT id, request_id, stage, revision, outcome, results, actor_id, created_at
+		FROM policy_evaluations
+		WHERE request_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.client.Query(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPolicyEvaluations: %w", err)
+	}
+	defer rows.Close()
+
+	evaluations := []models.PolicyEvaluation{}
+	for rows.Next() {
+		var evaluation models.PolicyEvaluation
+		if err = rows.Scan(
+			&evaluation.ID, &evaluation.RequestID, &evaluation.Stage, &evaluation.Revision, &evaluation.Outcome,
+			&evaluation.Results, &evaluation.ActorID, &evaluation.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetPolicyEvaluations scan: %w", err)
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetPolicyEvaluations rows: %w", err)
+	}
+	return evaluations, nil
+}
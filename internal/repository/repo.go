@@ -1,11 +1,15 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"curswork-trpo/internal/models"
 	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
 )
 
 import (
@@ -20,35 +24,23 @@ func NewExpenseRepository(client *postgres.Client) *ExpenseRepository {
 	return &ExpenseRepository{client: client}
 }
 
-// CreateExpenseRequest creates a new expense request
-func (r *ExpenseRepository) CreateExpenseRequest(ctx context.Context, req *models.ExpenseRequest) error {
-	query := `
-		INSERT INTO expense_requests (title, category, amount, vendor, description, status, employee_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id
-	`
-	now := time.Now().UTC()
-	return r.client.QueryRow(
-		ctx, query,
-		req.Title, req.Category, req.Amount, req.Vendor, req.Description,
-		models.StatusPending, req.EmployeeID, now, now,
-	).Scan(&req.ID)
-}
-
-// GetExpenseRequestByID gets an expense request by ID
-func (r *ExpenseRepository) GetExpenseRequestByID(ctx context.Context, id uint) (*models.ExpenseRequest, error) {
-	query := `
-		SELECT er.id, er.title, er.category, er.amount, er.vendor, er.description, 
-		       er.status, er.employee_id, er.reviewer_id, er.comments, 
-		       er.created_at, er.updated_at, er.reviewed_at,
-		       e.id, e.email, e.first_name, e.last_name, e.role,
-		       r.id, r.email, r.first_name, r.last_name, r.role
-		FROM expense_requests er
-		LEFT JOIN users e ON er.employee_id = e.id
-		LEFT JOIN users r ON er.reviewer_id = r.id
-		WHERE er.id = $1
-	`
-
+// expenseRequestSelect selects expense requests joined with their employee and reviewer,
+// rows are read with scanExpenseRequest
+const expenseRequestSelect = `
+	SELECT er.id, er.title, er.category, er.amount, er.vendor, er.description, 
+	       er.status, er.employee_id, er.reviewer_id, er.comments, 
+	       er.created_at, er.updated_at, er.reviewed_at, er.expense_date, er.revision,
+	       er.payment_date, er.paid_at, er.on_behalf_of, er.anomaly_score, er.anomaly_factors,
+	       er.currency, er.original_amount, er.exchange_rate, er.rate_date,
+	       e.id, e.email, e.first_name, e.last_name, e.role,
+	       r.id, r.email, r.first_name, r.last_name, r.role
+	FROM expense_requests er
+	LEFT JOIN users e ON er.employee_id = e.id
+	LEFT JOIN users r ON er.reviewer_id = r.id
+`
+
+// scanExpenseRequest scans a row selected with expenseRequestSelect
+func scanExpenseRequest(row pgx.Row) (*models.ExpenseRequest, error) {
 	var req models.ExpenseRequest
 	var employee models.User
 	var reviewerID *uint
@@ -62,14 +54,15 @@ func (r *ExpenseRepository) GetExpenseRequestByID(ctx context.Context, id uint)
 	var reviewerLastName *string
 	var reviewerRole *string
 
-	err := r.client.QueryRow(ctx, query, id).Scan(
+	err := row.Scan(
 		&req.ID, &req.Title, &req.Category, &req.Amount, &req.Vendor,
 		&req.Description, &req.Status, &req.EmployeeID, &reviewerID, &comments,
-		&req.CreatedAt, &req.UpdatedAt, &reviewedAt,
+		&req.CreatedAt, &req.UpdatedAt, &reviewedAt, &req.ExpenseDate, &req.Revision,
+		&req.PaymentDate, &req.PaidAt, &req.OnBehalfOfID, &req.AnomalyScore, &req.AnomalyFactors,
+		&req.Currency, &req.OriginalAmount, &req.ExchangeRate, &req.RateDate,
 		&employee.ID, &employee.Email, &employee.FirstName, &employee.LastName, &employee.Role,
 		&reviewerIDNullable, &reviewerEmail, &reviewerFirstName, &reviewerLastName, &reviewerRole,
 	)
-
 	if err != nil {
 		return nil, err
 	}
@@ -96,177 +89,220 @@ func (r *ExpenseRepository) GetExpenseRequestByID(ctx context.Context, id uint)
 	return &req, nil
 }
 
-// GetExpenseRequestsByEmployee gets all expense requests for an employee
-func (r *ExpenseRepository) GetExpenseRequestsByEmployee(ctx context.Context, employeeID uint, status string) ([]models.ExpenseRequest, error) {
-	query := `
-		SELECT er.id, er.title, er.category, er.amount, er.vendor, er.description, 
-		       er.status, er.employee_id, er.reviewer_id, er.comments, 
-		       er.created_at, er.updated_at, er.reviewed_at,
-		       e.id, e.email, e.first_name, e.last_name, e.role,
-		       r.id, r.email, r.first_name, r.last_name, r.role
-		FROM expense_requests er
-		LEFT JOIN users e ON er.employee_id = e.id
-		LEFT JOIN users r ON er.reviewer_id = r.id
-		WHERE er.employee_id = $1
-	`
-
-	args := []interface{}{employeeID}
-
-	if status != "" && status != "all" {
-		query += " AND er.status = $2"
-		args = append(args, status)
-	}
-
-	query += " ORDER BY er.created_at DESC"
-
-	rows, err := r.client.Query(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
+// scanExpenseRequests scans all rows selected with expenseRequestSelect
+func scanExpenseRequests(rows pgx.Rows) ([]models.ExpenseRequest, error) {
 	defer rows.Close()
 
 	var requests []models.ExpenseRequest
 	for rows.Next() {
-		var req models.ExpenseRequest
-		var employee models.User
-		var reviewerID *uint
-		var comments *string
-		var reviewedAt *time.Time
-
-		// Nullable fields for reviewer
-		var reviewerIDNullable *uint
-		var reviewerEmail *string
-		var reviewerFirstName *string
-		var reviewerLastName *string
-		var reviewerRole *string
-
-		err = rows.Scan(
-			&req.ID, &req.Title, &req.Category, &req.Amount, &req.Vendor,
-			&req.Description, &req.Status, &req.EmployeeID, &reviewerID, &comments,
-			&req.CreatedAt, &req.UpdatedAt, &reviewedAt,
-			&employee.ID, &employee.Email, &employee.FirstName, &employee.LastName, &employee.Role,
-			&reviewerIDNullable, &reviewerEmail, &reviewerFirstName, &reviewerLastName, &reviewerRole,
-		)
+		req, err := scanExpenseRequest(rows)
 		if err != nil {
 			return nil, err
 		}
+		requests = append(requests, *req)
+	}
 
-		req.Employee = employee
-		req.ReviewerID = reviewerID
-		req.ReviewedAt = reviewedAt
-
-		if comments != nil {
-			req.Comments = *comments
-		}
+	return requests, rows.Err()
+}
 
-		if reviewerIDNullable != nil && reviewerEmail != nil {
-			req.Reviewer = &models.User{
-				ID:        *reviewerIDNullable,
-				Email:     *reviewerEmail,
-				FirstName: *reviewerFirstName,
-				LastName:  *reviewerLastName,
-				Role:      models.UserRole(*reviewerRole),
-			}
-		}
+// CreateExpenseRequest creates a new expense request
+func (r *ExpenseRepository) CreateExpenseRequest(ctx context.Context, req *models.ExpenseRequest) error {
+	query := `
+		INSERT INTO expense_requests (title, category, amount, vendor, description, status, employee_id, expense_date, created_at, updated_at,
+		                              currency, original_amount, exchange_rate, rate_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at, revision
+	`
+	now := time.Now().UTC()
+	return r.client.QueryRow(
+		ctx, query,
+		req.Title, req.Category, req.Amount, req.Vendor, req.Description,
+		req.Status, req.EmployeeID, req.ExpenseDate, now, now,
+		req.Currency, req.OriginalAmount, req.ExchangeRate, req.RateDate,
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt, &req.Revision)
+}
 
-		requests = append(requests, req)
+// UpdateExpenseRequest saves the editable fields of a request and bumps its revision
+func (r *ExpenseRepository) UpdateExpenseRequest(ctx context.Context, req *models.ExpenseRequest) error {
+	query := `
+		UPDATE expense_requests
+		SET title = $1, category = $2, amount = $3, vendor = $4, description = $5, expense_date = $6,
+		    currency = $7, original_amount = $8, exchange_rate = $9, rate_date = $10,
+		    revision = revision + 1, updated_at = $11
+		WHERE id = $12
+		RETURNING revision, updated_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		req.Title, req.Category, req.Amount, req.Vendor, req.Description, req.ExpenseDate,
+		req.Currency, req.OriginalAmount, req.ExchangeRate, req.RateDate,
+		time.Now().UTC(), req.ID,
+	).Scan(&req.Revision, &req.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("UpdateExpenseRequest: %w", err)
 	}
+	return nil
+}
 
-	return requests, nil
+// UpdateConversion saves the base amount of a request and its rate
+func (r *ExpenseRepository) UpdateConversion(ctx context.Context, req *models.ExpenseRequest) error {
+	query := `UPDATE expense_requests SET amount = $1, exchange_rate = $2, rate_date = $3, updated_at = $4 WHERE id = $5`
+	if _, err := r.client.Exec(ctx, query, req.Amount, req.ExchangeRate, req.RateDate, time.Now().UTC(), req.ID); err != nil {
+		return fmt.Errorf("UpdateConversion: %w", err)
+	}
+	return nil
 }
 
-// GetAllExpenseRequests gets all expense requests with optional filter
-func (r *ExpenseRepository) GetAllExpenseRequests(ctx context.Context, status string) ([]models.ExpenseRequest, error) {
-	query := `
-		SELECT er.id, er.title, er.category, er.amount, er.vendor, er.description, 
-		       er.status, er.employee_id, er.reviewer_id, er.comments, 
-		       er.created_at, er.updated_at, er.reviewed_at,
-		       e.id, e.email, e.first_name, e.last_name, e.role,
-		       r.id, r.email, r.first_name, r.last_name, r.role
-		FROM expense_requests er
-		LEFT JOIN users e ON er.employee_id = e.id
-		LEFT JOIN users r ON er.reviewer_id = r.id
+// GetExpenseRequestByID gets an expense request by ID
+func (r *ExpenseRepository) GetExpenseRequestByID(ctx context.Context, id uint) (*models.ExpenseRequest, error) {
+	query := expenseRequestSelect + `
+		WHERE er.id = $1
 	`
 
-	var args []interface{}
+	return scanExpenseRequest(r.client.QueryRow(ctx, query, id))
+}
 
-	if status != "" && status != "all" {
-		query += " WHERE er.status = $1"
-		args = append(args, status)
-	}
+// expenseSortColumns are the sort expressions of expense requests, ties are broken by ID.
+// Unreviewed requests sort as reviewed at the zero time, unscored ones as scored -1.
+var expenseSortColumns = map[models.ExpenseSort]string{
+	models.SortByCreated:  "er.created_at",
+	models.SortByAmount:   "er.amount",
+	models.SortByReviewed: "COALESCE(er.reviewed_at, '0001-01-01'::timestamp)",
+	models.SortByAnomaly:  "COALESCE(er.anomaly_score, -1)",
+}
 
-	query += " ORDER BY er.created_at DESC"
+// ListExpenseRequests gets a page of expense requests matching the filter and the total number of matches.
+// Up to filter.Limit+1 requests are returned so the caller can tell whether another page follows.
+func (r *ExpenseRepository) ListExpenseRequests(ctx context.Context, filter models.ExpenseFilter) ([]models.ExpenseRequest, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
 
-	rows, err := r.client.Query(ctx, query, args...)
-	if err != nil {
-		return nil, err
+	if filter.Status != "" && filter.Status != "all" {
+		addCondition("er.status = $%d", filter.Status)
+	}
+	// Drafts are listed to their owner only
+	addCondition("(er.status <> '"+string(models.StatusDraft)+"' OR er.employee_id = $%d)", filter.ViewerID)
+	if filter.Category != "" {
+		addCondition("er.category = $%d", filter.Category)
+	}
+	if filter.Vendor != "" {
+		addCondition("er.vendor ILIKE '%%' || $%d || '%%'", filter.Vendor)
+	}
+	if filter.EmployeeID != nil {
+		addCondition("er.employee_id = $%d", *filter.EmployeeID)
+	}
+	if filter.ReviewerID != nil {
+		addCondition("er.reviewer_id = $%d", *filter.ReviewerID)
+	}
+	if filter.MinAmount != nil {
+		addCondition("er.amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("er.amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		addCondition("er.expense_date >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("er.expense_date <= $%d", *filter.To)
 	}
-	defer rows.Close()
 
-	var requests []models.ExpenseRequest
-	for rows.Next() {
-		var req models.ExpenseRequest
-		var employee models.User
-		var reviewerID *uint
-		var comments *string
-		var reviewedAt *time.Time
-
-		// Nullable fields for reviewer
-		var reviewerIDNullable *uint
-		var reviewerEmail *string
-		var reviewerFirstName *string
-		var reviewerLastName *string
-		var reviewerRole *string
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
 
-		err = rows.Scan(
-			&req.ID, &req.Title, &req.Category, &req.Amount, &req.Vendor,
-			&req.Description, &req.Status, &req.EmployeeID, &reviewerID, &comments,
-			&req.CreatedAt, &req.UpdatedAt, &reviewedAt,
-			&employee.ID, &employee.Email, &employee.FirstName, &employee.LastName, &employee.Role,
-			&reviewerIDNullable, &reviewerEmail, &reviewerFirstName, &reviewerLastName, &reviewerRole,
-		)
-		if err != nil {
-			return nil, err
-		}
+	var total int
+	countQuery := `SELECT COUNT(*) FROM expense_requests er` + where
+	if err := r.client.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ListExpenseRequests count: %w", err)
+	}
 
-		req.Employee = employee
-		req.ReviewerID = reviewerID
-		req.ReviewedAt = reviewedAt
+	sortColumn, ok := expenseSortColumns[filter.Sort]
+	if !ok {
+		sortColumn = expenseSortColumns[models.SortByCreated]
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Desc {
+		direction, comparison = "DESC", "<"
+	}
 
-		if comments != nil {
-			req.Comments = *comments
-		}
+	if filter.After != nil {
+		args = append(args, filter.After.Value, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, er.id) %s ($%d, $%d)", sortColumn, comparison, len(args)-1, len(args),
+		))
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
 
-		if reviewerIDNullable != nil && reviewerEmail != nil {
-			req.Reviewer = &models.User{
-				ID:        *reviewerIDNullable,
-				Email:     *reviewerEmail,
-				FirstName: *reviewerFirstName,
-				LastName:  *reviewerLastName,
-				Role:      models.UserRole(*reviewerRole),
-			}
-		}
+	args = append(args, filter.Limit+1)
+	query := expenseRequestSelect + where + fmt.Sprintf(
+		" ORDER BY %s %s, er.id %s LIMIT $%d", sortColumn, direction, direction, len(args),
+	)
 
-		requests = append(requests, req)
+	rows, err := r.client.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListExpenseRequests: %w", err)
+	}
+	requests, err := scanExpenseRequests(rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ListExpenseRequests scan: %w", err)
 	}
+	return requests, total, nil
+}
 
-	return requests, nil
+// LockExpenseRequest locks the request row until the end of the current transaction
+func (r *ExpenseRepository) LockExpenseRequest(ctx context.Context, id uint) error {
+	query := `SELECT id FROM expense_requests WHERE id = $1 FOR UPDATE`
+	return r.client.QueryRow(ctx, query, id).Scan(&id)
 }
 
-// UpdateExpenseRequestStatus updates the status of an expense request
-func (r *ExpenseRepository) UpdateExpenseRequestStatus(ctx context.Context, id uint, reviewerID uint, status models.RequestStatus, comments string) error {
+// UpdateExpenseRequestStatus updates the status of an expense request.
+// onBehalfOf is the reviewer who delegated the decision.
+func (r *ExpenseRepository) UpdateExpenseRequestStatus(ctx context.Context, id uint, reviewerID uint, onBehalfOf *uint, status models.RequestStatus, comments string) error {
 	query := `
 		UPDATE expense_requests 
-		SET status = $1, reviewer_id = $2, comments = $3, reviewed_at = $4, updated_at = $5
-		WHERE id = $6
+		SET status = $1, reviewer_id = $2, on_behalf_of = $3, comments = $4, reviewed_at = $5, updated_at = $6
+		WHERE id = $7
 	`
 	now := time.Now().UTC()
-	_, err := r.client.Exec(ctx, query, status, reviewerID, comments, now, now, id)
+	_, err := r.client.Exec(ctx, query, status, reviewerID, onBehalfOf, comments, now, now, id)
 	return err
 }
 
-// GetStatistics gets expense statistics
-func (r *ExpenseRepository) GetStatistics(ctx context.Context) (*models.StatsResponse, error) {
+// SetExpenseRequestStatus changes the status of a request on behalf of its owner
+func (r *ExpenseRepository) SetExpenseRequestStatus(ctx context.Context, id uint, status models.RequestStatus) error {
+	query := `UPDATE expense_requests SET status = $1, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, status, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("SetExpenseRequestStatus: %w", err)
+	}
+	return nil
+}
+
+// SchedulePayment marks an approved request as scheduled to be paid on a date
+func (r *ExpenseRepository) SchedulePayment(ctx context.Context, id uint, paymentDate time.Time) error {
+	query := `UPDATE expense_requests SET status = $1, payment_date = $2, updated_at = $3 WHERE id = $4`
+	if _, err := r.client.Exec(ctx, query, models.StatusScheduledPayment, paymentDate, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("SchedulePayment: %w", err)
+	}
+	return nil
+}
+
+// MarkPaid marks a request as paid now
+func (r *ExpenseRepository) MarkPaid(ctx context.Context, id uint) error {
+	query := `UPDATE expense_requests SET status = $1, paid_at = $2, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, models.StatusPaid, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("MarkPaid: %w", err)
+	}
+	return nil
+}
+
+// GetStatistics gets expense statistics, budget figures are taken from the given period
+func (r *ExpenseRepository) GetStatistics(ctx context.Context, period models.BudgetPeriod) (*models.StatsResponse, error) {
 	var stats models.StatsResponse
 
 	// Total pending
@@ -289,22 +325,28 @@ func (r *ExpenseRepository) GetStatistics(ctx context.Context) (*models.StatsRes
 	now := time.Now().UTC()
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 
-	query = `SELECT COALESCE(SUM(amount), 0) FROM expense_requests WHERE status = $1 AND created_at >= $2`
-	err = r.client.QueryRow(ctx, query, models.StatusApproved, startOfMonth).Scan(&stats.TotalApproved)
+	// Scheduled and paid requests stay approved
+	approved := make([]string, len(models.ApprovedStatuses))
+	for i, status := range models.ApprovedStatuses {
+		approved[i] = string(status)
+	}
+
+	query = `SELECT COALESCE(SUM(amount), 0) FROM expense_requests WHERE status = ANY($1) AND created_at >= $2`
+	err = r.client.QueryRow(ctx, query, approved, startOfMonth).Scan(&stats.TotalApproved)
 	if err != nil {
 		return nil, err
 	}
 
-	query = `SELECT COUNT(*) FROM expense_requests WHERE status = $1 AND created_at >= $2`
-	err = r.client.QueryRow(ctx, query, models.StatusApproved, startOfMonth).Scan(&count)
+	query = `SELECT COUNT(*) FROM expense_requests WHERE status = ANY($1) AND created_at >= $2`
+	err = r.client.QueryRow(ctx, query, approved, startOfMonth).Scan(&count)
 	if err != nil {
 		return nil, err
 	}
 	stats.ApprovedThisMonth = int(count)
 
 	// Budget info
-	query = `SELECT spent, remaining FROM budgets WHERE year = $1 AND month = $2`
-	err = r.client.QueryRow(ctx, query, now.Year(), int(now.Month())).Scan(&stats.BudgetUsed, &stats.BudgetRemaining)
+	query = `SELECT spent, remaining FROM budgets WHERE period_type = $1 AND year = $2 AND month = $3 AND department_id IS NULL`
+	err = r.client.QueryRow(ctx, query, period.Type, period.Year, period.Month).Scan(&stats.BudgetUsed, &stats.BudgetRemaining)
 	if err != nil {
 		stats.BudgetUsed = 0
 		stats.BudgetRemaining = 0
@@ -313,16 +355,161 @@ func (r *ExpenseRepository) GetStatistics(ctx context.Context) (*models.StatsRes
 	return &stats, nil
 }
 
+// GetDepartmentStatistics gets the statistics of every department with its sub-departments
+func (r *ExpenseRepository) GetDepartmentStatistics(ctx context.Context, period models.BudgetPeriod) ([]models.DepartmentStats, error) {
+	now := time.Now().UTC()
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	approved := make([]string, len(models.ApprovedStatuses))
+	for i, status := range models.ApprovedStatuses {
+		approved[i] = string(status)
+	}
+
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id AS root_id, id FROM departments
+			UNION ALL
+			SELECT t.root_id, d.id FROM departments d JOIN tree t ON d.parent_id = t.id
+		)
+		SELECT d.id, d.name, d.code,
+		       COALESCE(SUM(e.amount) FILTER (WHERE e.status = $1), 0),
+		       COALESCE(SUM(e.amount) FILTER (WHERE e.status = ANY($2) AND e.created_at >= $3), 0),
+		       COUNT(e.id) FILTER (WHERE e.status = $1),
+		       COUNT(e.id) FILTER (WHERE e.status = ANY($2) AND e.created_at >= $3),
+		       COALESCE(b.spent, 0), COALESCE(b.remaining, 0)
+		FROM departments d
+		JOIN tree t ON t.root_id = d.id
+		LEFT JOIN users u ON u.department_id = t.id
+		LEFT JOIN expense_requests e ON e.employee_id = u.id
+		LEFT JOIN budgets b ON b.department_id = d.id AND b.period_type = $4 AND b.year = $5 AND b.month = $6
+		GROUP BY d.id, d.name, d.code, b.spent, b.remaining
+		ORDER BY d.code
+	`
+	rows, err := r.client.Query(
+		ctx, query, models.StatusPending, approved, startOfMonth, period.Type, period.Year, period.Month,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetDepartmentStatistics: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.DepartmentStats{}
+	for rows.Next() {
+		var department models.DepartmentStats
+		err = rows.Scan(
+			&department.DepartmentID, &department.Name, &department.Code,
+			&department.TotalPending, &department.TotalApproved, &department.PendingCount, &department.ApprovedThisMonth,
+			&department.BudgetUsed, &department.BudgetRemaining,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("GetDepartmentStatistics scan: %w", err)
+		}
+		stats = append(stats, department)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetDepartmentStatistics rows: %w", err)
+	}
+	return stats, nil
+}
+
+// GetAnomalyHistory gets what a request is scored against: the amounts of the other requests of its category
+// and the other requests of its employee created since a time. Drafts, rejected and withdrawn requests are left out.
+func (r *ExpenseRepository) GetAnomalyHistory(
+	ctx context.Context, request *models.ExpenseRequest, since time.Time,
+) (*models.AnomalyHistory, error) {
+	excluded := []string{string(models.StatusDraft), string(models.StatusRejected), string(models.StatusWithdrawn)}
+
+	var history models.AnomalyHistory
+	query := `
+		SELECT COUNT(*), COALESCE(AVG(amount), 0), COALESCE(STDDEV_SAMP(amount), 0)
+		FROM expense_requests
+		WHERE category = $1 AND id <> $2 AND status <> ALL($3) AND created_at >= $4
+	`
+	err := r.client.QueryRow(ctx, query, request.Category, request.ID, excluded, since).Scan(
+		&history.CategoryCount, &history.CategoryMean, &history.CategoryStddev,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetAnomalyHistory category: %w", err)
+	}
+
+	query = expenseRequestSelect + `
+		WHERE er.employee_id = $1 AND er.id <> $2 AND er.status <> ALL($3) AND er.created_at >= $4
+		ORDER BY er.created_at, er.id
+	`
+	rows, err := r.client.Query(ctx, query, request.EmployeeID, request.ID, excluded, since)
+	if err != nil {
+		return nil, fmt.Errorf("GetAnomalyHistory: %w", err)
+	}
+	if history.Employee, err = scanExpenseRequests(rows); err != nil {
+		return nil, fmt.Errorf("GetAnomalyHistory scan: %w", err)
+	}
+	return &history, nil
+}
+
+// SetAnomalyScore stores the anomaly score of a request and the factors it is made of
+func (r *ExpenseRepository) SetAnomalyScore(ctx context.Context, id uint, score float64, factors []models.AnomalyFactor) error {
+	query := `UPDATE expense_requests SET anomaly_score = $1, anomaly_factors = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, score, factors, id); err != nil {
+		return fmt.Errorf("SetAnomalyScore: %w", err)
+	}
+	return nil
+}
+
+// GetAnomalyStatistics summarizes the anomaly scores of pending requests
+func (r *ExpenseRepository) GetAnomalyStatistics(ctx context.Context, top int) (*models.AnomalyStats, error) {
+	stats := models.AnomalyStats{Top: []models.AnomalousRequest{}}
+
+	query := `
+		SELECT COUNT(anomaly_score), COALESCE(AVG(anomaly_score), 0), COUNT(*) FILTER (WHERE anomaly_score >= $2)
+		FROM expense_requests
+		WHERE status = $1
+	`
+	err := r.client.QueryRow(ctx, query, models.StatusPending, models.HighAnomalyScore).Scan(
+		&stats.ScoredCount, &stats.AverageScore, &stats.HighCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetAnomalyStatistics: %w", err)
+	}
+
+	query = `
+		SELECT id, title, category, amount, employee_id, anomaly_score
+		FROM expense_requests
+		WHERE status = $1 AND anomaly_score IS NOT NULL
+		ORDER BY anomaly_score DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.client.Query(ctx, query, models.StatusPending, top)
+	if err != nil {
+		return nil, fmt.Errorf("GetAnomalyStatistics top: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var request models.AnomalousRequest
+		if err = rows.Scan(
+			&request.ID, &request.Title, &request.Category, &request.Amount, &request.EmployeeID, &request.AnomalyScore,
+		); err != nil {
+			return nil, fmt.Errorf("GetAnomalyStatistics scan: %w", err)
+		}
+		stats.Top = append(stats.Top, request)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAnomalyStatistics rows: %w", err)
+	}
+	return &stats, nil
+}
+
 func (r *ExpenseRepository) GetTopExpenses(ctx context.Context) ([]models.TopExpenseRequest, error) {
 	var expenses []models.TopExpenseRequest
 
 	query := `
 		SELECT title, amount, category, status
 		FROM expense_requests
+		WHERE status <> $1
 		ORDER BY amount DESC 
 		LIMIT 3;`
 
-	rows, err := r.client.Query(ctx, query)
+	rows, err := r.client.Query(ctx, query, models.StatusDraft)
 	if err != nil {
 		return nil, fmt.Errorf("GetTopExpenses: %w", err)
 	}
@@ -343,15 +530,18 @@ func (r *ExpenseRepository) GetTopExpenses(ctx context.Context) ([]models.TopExp
 	return expenses, nil
 }
 
+// GetExpensesByCategory sums the amounts of requests by category without drafts.
+// Amounts are in the base currency, so requests in different currencies add up.
 func (r *ExpenseRepository) GetExpensesByCategory(ctx context.Context) ([]models.CategoryExpenseRequest, error) {
 	var expenses []models.CategoryExpenseRequest
 
 	query := `
 		SELECT category, SUM(amount) AS amount, COUNT(*) AS count
 		FROM expense_requests
+		WHERE status <> $1
 		GROUP BY category;`
 
-	rows, err := r.client.Query(ctx, query)
+	rows, err := r.client.Query(ctx, query, models.StatusDraft)
 	if err != nil {
 		return nil, fmt.Errorf("GetExpensesByCategory: %w", err)
 	}
@@ -393,44 +583,151 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) erro
 	).Scan(&user.ID)
 }
 
-// GetUserByEmail gets a user by email
-func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `
-		SELECT id, email, password, first_name, last_name, role, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
+const userColumns = `id, email, password, first_name, last_name, role, is_active, created_at, updated_at, department_id,
+	trust_level, is_system, ARRAY(SELECT ur.role FROM user_roles ur WHERE ur.user_id = users.id ORDER BY ur.role)`
 
+func scanUser(row pgx.Row) (*models.User, error) {
 	var user models.User
-	err := r.client.QueryRow(ctx, query, email).Scan(
+	var additionalRoles []string
+	err := row.Scan(
 		&user.ID, &user.Email, &user.Password, &user.FirstName,
-		&user.LastName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+		&user.LastName, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.DepartmentID,
+		&user.TrustLevel, &user.IsSystem, &additionalRoles,
 	)
-
 	if err != nil {
 		return nil, err
 	}
+	user.AdditionalRoles = make([]models.UserRole, len(additionalRoles))
+	for i, role := range additionalRoles {
+		user.AdditionalRoles[i] = models.UserRole(role)
+	}
 	return &user, nil
 }
 
+// GetUserByEmail gets a user by email
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	return scanUser(r.client.QueryRow(ctx, query, email))
+}
+
+// GetSystemUser gets the user automatic decisions are recorded for
+func (r *UserRepository) GetSystemUser(ctx context.Context) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE is_system`
+	return scanUser(r.client.QueryRow(ctx, query))
+}
+
 // GetUserByID gets a user by ID
 func (r *UserRepository) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
-	query := `
-		SELECT id, email, password, first_name, last_name, role, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return scanUser(r.client.QueryRow(ctx, query, id))
+}
 
-	var user models.User
-	err := r.client.QueryRow(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.Password, &user.FirstName,
-		&user.LastName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+// ListUsers gets a page of users matching the filter, ordered by name, and the total number of matches
+func (r *UserRepository) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Search != "" {
+		addCondition(
+			"(email ILIKE '%%' || $%[1]d || '%%' OR first_name || ' ' || last_name ILIKE '%%' || $%[1]d || '%%')",
+			filter.Search,
+		)
+	}
+	if filter.Role != "" {
+		addCondition(
+			"(role = $%[1]d OR EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = users.id AND ur.role = $%[1]d))",
+			filter.Role,
+		)
+	}
+	if filter.Active != nil {
+		addCondition("is_active = $%d", *filter.Active)
+	}
+	if filter.Department != nil {
+		addCondition("department_id = $%d", *filter.Department)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.client.QueryRow(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ListUsers count: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT ` + userColumns + ` FROM users` + where + fmt.Sprintf(
+		" ORDER BY last_name, first_name, id LIMIT $%d OFFSET $%d", len(args)-1, len(args),
 	)
 
+	rows, err := r.client.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, fmt.Errorf("ListUsers: %w", err)
 	}
-	return &user, nil
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ListUsers scan: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ListUsers rows: %w", err)
+	}
+	return users, total, nil
+}
+
+// UpdateUserRole changes the role of a user
+func (r *UserRepository) UpdateUserRole(ctx context.Context, id uint, role models.UserRole) error {
+	query := `UPDATE users SET role = $1, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, role, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("UpdateUserRole: %w", err)
+	}
+	return nil
+}
+
+// SetUserActive activates or deactivates a user
+func (r *UserRepository) SetUserActive(ctx context.Context, id uint, active bool) error {
+	query := `UPDATE users SET is_active = $1, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, active, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("SetUserActive: %w", err)
+	}
+	return nil
+}
+
+// SetUserDepartment moves a user to a department
+func (r *UserRepository) SetUserDepartment(ctx context.Context, id uint, departmentID *uint) error {
+	query := `UPDATE users SET department_id = $1, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, departmentID, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("SetUserDepartment: %w", err)
+	}
+	return nil
+}
+
+// SetUserTrustLevel changes how far a user is trusted
+func (r *UserRepository) SetUserTrustLevel(ctx context.Context, id uint, trustLevel int) error {
+	query := `UPDATE users SET trust_level = $1, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, trustLevel, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("SetUserTrustLevel: %w", err)
+	}
+	return nil
+}
+
+// UpdateUserPassword replaces the password hash of a user
+func (r *UserRepository) UpdateUserPassword(ctx context.Context, id uint, passwordHash string) error {
+	query := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3`
+	if _, err := r.client.Exec(ctx, query, passwordHash, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("UpdateUserPassword: %w", err)
+	}
+	return nil
 }
 
 // BudgetRepository handles budget operations
@@ -442,65 +739,309 @@ func NewBudgetRepository(client *postgres.Client) *BudgetRepository {
 	return &BudgetRepository{client: client}
 }
 
-// GetOrCreateCurrentBudget gets or creates budget for current month
-func (r *BudgetRepository) GetOrCreateCurrentBudget(ctx context.Context) (*models.Budget, error) {
-	now := time.Now().UTC()
-	year, month := now.Year(), int(now.Month())
-
-	query := `SELECT id, year, month, total, spent, remaining, created_at, updated_at FROM budgets WHERE year = $1 AND month = $2`
+const budgetColumns = `id, period_type, year, month, period_start, period_end, total, spent, remaining, created_at, updated_at,
+	department_id`
 
+func scanBudget(row pgx.Row) (*models.Budget, error) {
 	var budget models.Budget
-	err := r.client.QueryRow(ctx, query, year, month).Scan(
-		&budget.ID, &budget.Year, &budget.Month, &budget.Total,
-		&budget.Spent, &budget.Remaining, &budget.CreatedAt, &budget.UpdatedAt,
+	err := row.Scan(
+		&budget.ID, &budget.PeriodType, &budget.Year, &budget.Month, &budget.PeriodStart, &budget.PeriodEnd,
+		&budget.Total, &budget.Spent, &budget.Remaining, &budget.CreatedAt, &budget.UpdatedAt, &budget.DepartmentID,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
 
+// GetBudget gets the budget of a period and department
+func (r *BudgetRepository) GetBudget(ctx context.Context, period models.BudgetPeriod, departmentID *uint) (*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` 
+				FROM budgets 
+				WHERE period_type = $1 AND year = $2 AND month = $3 AND department_id IS NOT DISTINCT FROM $4;`
+
+	budget, err := scanBudget(r.client.QueryRow(ctx, query, period.Type, period.Year, period.Month, departmentID))
 	if err != nil {
-		// Create new budget if not found
-		insertQuery := `
-			INSERT INTO budgets (year, month, total, spent, remaining, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-			RETURNING id, year, month, total, spent, remaining, created_at, updated_at
-		`
-		now = time.Now().UTC()
-		err = r.client.QueryRow(ctx, insertQuery, year, month, 100000.0, 0.0, 100000.0, now, now).Scan(
-			&budget.ID, &budget.Year, &budget.Month, &budget.Total,
-			&budget.Spent, &budget.Remaining, &budget.CreatedAt, &budget.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
+		return nil, fmt.Errorf("budget not found for %s %d-%d: %w", period.Type, period.Year, period.Month, err)
 	}
+	return budget, nil
+}
 
-	return &budget, nil
+// GetBudgetByID gets a budget by ID
+func (r *BudgetRepository) GetBudgetByID(ctx context.Context, id uint) (*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1;`
+
+	budget, err := scanBudget(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("GetBudgetByID: %w", err)
+	}
+	return budget, nil
+}
+
+// LockBudget gets the budget of a period and locks its row until the end of the current transaction,
+// departmentID selects the budget as in GetBudget
+func (r *BudgetRepository) LockBudget(ctx context.Context, period models.BudgetPeriod, departmentID *uint) (*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` 
+				FROM budgets 
+				WHERE period_type = $1 AND year = $2 AND month = $3 AND department_id IS NOT DISTINCT FROM $4
+				FOR UPDATE;`
+
+	budget, err := scanBudget(r.client.QueryRow(ctx, query, period.Type, period.Year, period.Month, departmentID))
+	if err != nil {
+		return nil, fmt.Errorf("budget not found for %s %d-%d: %w", period.Type, period.Year, period.Month, err)
+	}
+	return budget, nil
+}
+
+// EnsureBudget gets the company budget of a period, creating it with defaultTotal if missing
+func (r *BudgetRepository) EnsureBudget(ctx context.Context, period models.BudgetPeriod, defaultTotal float64) (*models.Budget, error) {
+	query := `
+		INSERT INTO budgets (period_type, year, month, period_start, period_end, total, spent, remaining, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $6, $7, $7)
+		ON CONFLICT (period_type, year, month) WHERE department_id IS NULL DO NOTHING
+		RETURNING ` + budgetColumns
+
+	budget, err := scanBudget(r.client.QueryRow(
+		ctx, query,
+		period.Type, period.Year, period.Month, period.Start, period.End, defaultTotal, time.Now().UTC(),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Already exists
+		return r.GetBudget(ctx, period, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("EnsureBudget: %w", err)
+	}
+
+	if err = r.AddBudgetHistory(ctx, &models.BudgetHistoryEntry{
+		BudgetID:  budget.ID,
+		Action:    models.BudgetActionCreated,
+		Amount:    budget.Total,
+		Total:     budget.Total,
+		Spent:     budget.Spent,
+		Remaining: budget.Remaining,
+	}); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// CreateBudget creates the budget of a period and records it in the budget history,
+// a department budget when departmentID is set
+func (r *BudgetRepository) CreateBudget(
+	ctx context.Context, period models.BudgetPeriod, departmentID *uint, total float64, userID *uint,
+) (*models.Budget, error) {
+	query := `
+		INSERT INTO budgets (period_type, year, month, period_start, period_end, total, spent, remaining, created_at, updated_at, department_id)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $6, $7, $7, $8)
+		RETURNING ` + budgetColumns
+
+	budget, err := scanBudget(r.client.QueryRow(
+		ctx, query,
+		period.Type, period.Year, period.Month, period.Start, period.End, total, time.Now().UTC(), departmentID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("CreateBudget: %w", err)
+	}
+
+	if err = r.AddBudgetHistory(ctx, &models.BudgetHistoryEntry{
+		BudgetID:  budget.ID,
+		Action:    models.BudgetActionCreated,
+		Amount:    budget.Total,
+		Total:     budget.Total,
+		Spent:     budget.Spent,
+		Remaining: budget.Remaining,
+		UserID:    userID,
+	}); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// UpdateBudgetTotal changes the total of a budget.
+// The update is skipped when the new total is below the already spent amount.
+func (r *BudgetRepository) UpdateBudgetTotal(ctx context.Context, budgetID uint, total float64, userID uint) (*models.Budget, error) {
+	query := `
+		UPDATE budgets
+		SET total = $1, remaining = $1 - spent, updated_at = $2
+		WHERE id = $3 AND spent <= $1
+		RETURNING ` + budgetColumns
+
+	budget, err := scanBudget(r.client.QueryRow(ctx, query, total, time.Now().UTC(), budgetID))
+	if err != nil {
+		return nil, fmt.Errorf("UpdateBudgetTotal: %w", err)
+	}
+
+	if err = r.AddBudgetHistory(ctx, &models.BudgetHistoryEntry{
+		BudgetID:  budget.ID,
+		Action:    models.BudgetActionTotalChanged,
+		Amount:    budget.Total,
+		Total:     budget.Total,
+		Spent:     budget.Spent,
+		Remaining: budget.Remaining,
+		UserID:    &userID,
+	}); err != nil {
+		return nil, err
+	}
+	return budget, nil
 }
 
 // UpdateBudgetSpent updates the spent amount in budget
-func (r *BudgetRepository) UpdateBudgetSpent(ctx context.Context, year, month int, amount float64) error {
+func (r *BudgetRepository) UpdateBudgetSpent(ctx context.Context, budgetID uint, amount float64, requestID uint) error {
 	query := `
 		UPDATE budgets 
-		SET spent = spent + $1, remaining = remaining - $2, updated_at = $3
-		WHERE year = $4 AND month = $5
+		SET spent = spent + $1, remaining = remaining - $1, updated_at = $2
+		WHERE id = $3
+		RETURNING total, spent, remaining
 	`
 
-	_, err := r.client.Exec(ctx, query, amount, amount, time.Now().UTC(), year, month)
-	return err
+	entry := models.BudgetHistoryEntry{
+		BudgetID:         budgetID,
+		Action:           models.BudgetActionSpent,
+		Amount:           amount,
+		ExpenseRequestID: &requestID,
+	}
+	err := r.client.QueryRow(ctx, query, amount, time.Now().UTC(), budgetID).Scan(
+		&entry.Total, &entry.Spent, &entry.Remaining,
+	)
+	if err != nil {
+		return fmt.Errorf("UpdateBudgetSpent: %w", err)
+	}
+	return r.AddBudgetHistory(ctx, &entry)
 }
 
-// GetBudgetByMonth gets budget for specific month
-func (r *BudgetRepository) GetBudgetByMonth(ctx context.Context, year, month int) (*models.Budget, error) {
-	query := `SELECT id, year, month, total, spent, remaining, created_at, updated_at 
-				FROM budgets 
-				WHERE year = $1 AND month = $2;`
+// ListBudgetsByYear gets all budgets whose period starts in a year
+func (r *BudgetRepository) ListBudgetsByYear(ctx context.Context, year int) ([]models.Budget, error) {
+	query := `
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE year = $1
+		ORDER BY period_start, period_type, department_id NULLS FIRST
+	`
 
-	var budget models.Budget
-	err := r.client.QueryRow(ctx, query, year, month).Scan(
-		&budget.ID, &budget.Year, &budget.Month, &budget.Total,
-		&budget.Spent, &budget.Remaining, &budget.CreatedAt, &budget.UpdatedAt,
-	)
+	rows, err := r.client.Query(ctx, query, year)
+	if err != nil {
+		return nil, fmt.Errorf("ListBudgetsByYear: %w", err)
+	}
+	defer rows.Close()
 
+	budgets := []models.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListBudgetsByYear scan: %w", err)
+		}
+		budgets = append(budgets, *budget)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListBudgetsByYear rows: %w", err)
+	}
+	return budgets, nil
+}
+
+// ListDepartmentBudgets gets the department budgets of a period below a department,
+// below the company when departmentID is nil
+func (r *BudgetRepository) ListDepartmentBudgets(
+	ctx context.Context, period models.BudgetPeriod, departmentID *uint,
+) ([]models.Budget, error) {
+	query := `
+		WITH RECURSIVE below AS (
+			SELECT id FROM departments WHERE parent_id IS NOT DISTINCT FROM $4
+			UNION ALL
+			SELECT d.id FROM departments d JOIN below b ON d.parent_id = b.id
+		)
+		SELECT ` + budgetColumns + `
+		FROM budgets
+		WHERE period_type = $1 AND year = $2 AND month = $3 AND department_id IN (SELECT id FROM below)
+		ORDER BY department_id
+	`
+
+	rows, err := r.client.Query(ctx, query, period.Type, period.Year, period.Month, departmentID)
 	if err != nil {
-		return nil, fmt.Errorf("budget not found for %d-%d", year, month)
+		return nil, fmt.Errorf("ListDepartmentBudgets: %w", err)
 	}
-	return &budget, nil
+	defer rows.Close()
+
+	budgets := []models.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListDepartmentBudgets scan: %w", err)
+		}
+		budgets = append(budgets, *budget)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListDepartmentBudgets rows: %w", err)
+	}
+	return budgets, nil
+}
+
+// GetAllocatedTotal sums the totals of the budgets of a period one level below a department,
+// below the company when departmentID is nil. The budget excludeID is left out of the sum.
+func (r *BudgetRepository) GetAllocatedTotal(
+	ctx context.Context, period models.BudgetPeriod, departmentID *uint, excludeID uint,
+) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(b.total), 0)
+		FROM budgets b
+		JOIN departments d ON d.id = b.department_id
+		WHERE b.period_type = $1 AND b.year = $2 AND b.month = $3
+		  AND d.parent_id IS NOT DISTINCT FROM $4 AND b.id <> $5
+	`
+	var allocated float64
+	err := r.client.QueryRow(ctx, query, period.Type, period.Year, period.Month, departmentID, excludeID).Scan(&allocated)
+	if err != nil {
+		return 0, fmt.Errorf("GetAllocatedTotal: %w", err)
+	}
+	return allocated, nil
+}
+
+// AddBudgetHistory appends an entry to the budget history
+func (r *BudgetRepository) AddBudgetHistory(ctx context.Context, entry *models.BudgetHistoryEntry) error {
+	query := `
+		INSERT INTO budget_history (budget_id, action, amount, total, spent, remaining, user_id, expense_request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query,
+		entry.BudgetID, entry.Action, entry.Amount, entry.Total, entry.Spent, entry.Remaining,
+		entry.UserID, entry.ExpenseRequestID, time.Now().UTC(),
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("AddBudgetHistory: %w", err)
+	}
+	return nil
+}
+
+// GetBudgetHistory gets the change history of a budget, oldest first
+func (r *BudgetRepository) GetBudgetHistory(ctx context.Context, budgetID uint) ([]models.BudgetHistoryEntry, error) {
+	query := `
+		SELECT id, budget_id, action, amount, total, spent, remaining, user_id, expense_request_id, created_at
+		FROM budget_history
+		WHERE budget_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.client.Query(ctx, query, budgetID)
+	if err != nil {
+		return nil, fmt.Errorf("GetBudgetHistory: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.BudgetHistoryEntry{}
+	for rows.Next() {
+		var entry models.BudgetHistoryEntry
+		if err = rows.Scan(
+			&entry.ID, &entry.BudgetID, &entry.Action, &entry.Amount, &entry.Total, &entry.Spent,
+			&entry.Remaining, &entry.UserID, &entry.ExpenseRequestID, &entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetBudgetHistory scan: %w", err)
+		}
+		history = append(history, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetBudgetHistory rows: %w", err)
+	}
+	return history, nil
 }
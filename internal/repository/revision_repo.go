@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RevisionRepository handles edit history of expense requests and what reviewers have seen of it
+type RevisionRepository struct {
+	client *postgres.Client
+}
+
+func NewRevisionRepository(client *postgres.Client) *RevisionRepository {
+	return &RevisionRepository{client: client}
+}
+
+// CreateRevision stores the changes of one edit
+func (r *RevisionRepository) CreateRevision(ctx context.Context, revision *models.ExpenseRevision) error {
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return fmt.Errorf("CreateRevision: %w", err)
+	}
+
+	query := `
+		INSERT INTO expense_request_revisions (request_id, revision, changed_by, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err = r.client.QueryRow(
+		ctx, query,
+		revision.RequestID, revision.Revision, revision.ChangedBy, string(changes), time.Now().UTC(),
+	).Scan(&revision.ID, &revision.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateRevision: %w", err)
+	}
+	return nil
+}
+
+// GetRevisions gets the revisions of a request newer than since, oldest first
+func (r *RevisionRepository) GetRevisions(ctx context.Context, requestID uint, since int) ([]models.ExpenseRevision, error) {
+	query := `
+		SELECT id, request_id, revision, changed_by, changes, created_at
+		FROM expense_request_revisions
+		WHERE request_id = $1 AND revision > $2
+		ORDER BY revision
+	`
+	rows, err := r.client.Query(ctx, query, requestID, since)
+	if err != nil {
+		return nil, fmt.Errorf("GetRevisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.ExpenseRevision{}
+	for rows.Next() {
+		var revision models.ExpenseRevision
+		var changes []byte
+		if err = rows.Scan(
+			&revision.ID, &revision.RequestID, &revision.Revision, &revision.ChangedBy, &changes, &revision.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("GetRevisions scan: %w", err)
+		}
+		if err = json.Unmarshal(changes, &revision.Changes); err != nil {
+			return nil, fmt.Errorf("GetRevisions changes: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetRevisions rows: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetLastViewedRevision gets the revision of a request the user saw last
+func (r *RevisionRepository) GetLastViewedRevision(ctx context.Context, requestID, userID uint) (*int, error) {
+	query := `SELECT revision FROM expense_request_views WHERE request_id = $1 AND user_id = $2`
+
+	var revision int
+	err := r.client.QueryRow(ctx, query, requestID, userID).Scan(&revision)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetLastViewedRevision: %w", err)
+	}
+	return &revision, nil
+}
+
+// MarkViewed remembers that the user has seen a revision of a request
+func (r *RevisionRepository) MarkViewed(ctx context.Context, requestID, userID uint, revision int) error {
+	query := `
+		INSERT INTO expense_request_views (request_id, user_id, revision, viewed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (request_id, user_id) DO UPDATE SET revision = EXCLUDED.revision, viewed_at = EXCLUDED.viewed_at
+	`
+	if _, err := r.client.Exec(ctx, query, requestID, userID, revision, time.Now().UTC()); err != nil {
+		return fmt.Errorf("MarkViewed: %w", err)
+	}
+	return nil
+}
+
+// HasEdited tells whether a user has ever edited a request
+func (r *RevisionRepository) HasEdited(ctx context.Context, requestID, userID uint) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM expense_request_revisions WHERE request_id = $1 AND changed_by = $2)`
+
+	var edited bool
+	if err := r.client.QueryRow(ctx, query, requestID, userID).Scan(&edited); err != nil {
+		return false, fmt.Errorf("HasEdited: %w", err)
+	}
+	return edited, nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RoleRepository handles roles, their permissions and the roles of users
+type RoleRepository struct {
+	client *postgres.Client
+}
+
+func NewRoleRepository(client *postgres.Client) *RoleRepository {
+	return &RoleRepository{client: client}
+}
+
+const roleColumns = `r.id, r.name, r.description, r.built_in, r.created_at,
+	ARRAY(SELECT rp.permission FROM role_permissions rp WHERE rp.role = r.name ORDER BY rp.permission)`
+
+func scanRole(row pgx.Row) (*models.Role, error) {
+	var role models.Role
+	var permissions []string
+	if err := row.Scan(&role.ID, &role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &permissions); err != nil {
+		return nil, err
+	}
+	role.Permissions = make([]models.Permission, len(permissions))
+	for i, permission := range permissions {
+		role.Permissions[i] = models.Permission(permission)
+	}
+	return &role, nil
+}
+
+// ListPermissions gets every permission known to the system
+func (r *RoleRepository) ListPermissions(ctx context.Context) ([]models.PermissionInfo, error) {
+	rows, err := r.client.Query(ctx, `SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("ListPermissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.PermissionInfo{}
+	for rows.Next() {
+		var permission models.PermissionInfo
+		if err = rows.Scan(&permission.Name, &permission.Description); err != nil {
+			return nil, fmt.Errorf("ListPermissions scan: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListPermissions rows: %w", err)
+	}
+	return permissions, nil
+}
+
+// ListRoles gets all roles with their permissions, built-in ones first
+func (r *RoleRepository) ListRoles(ctx context.Context) ([]models.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles r ORDER BY r.built_in DESC, r.name`
+
+	rows, err := r.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ListRoles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListRoles scan: %w", err)
+		}
+		roles = append(roles, *role)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListRoles rows: %w", err)
+	}
+	return roles, nil
+}
+
+// GetRole gets a role by name
+func (r *RoleRepository) GetRole(ctx context.Context, name models.UserRole) (*models.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles r WHERE r.name = $1`
+
+	role, err := scanRole(r.client.QueryRow(ctx, query, name))
+	if err != nil {
+		return nil, fmt.Errorf("GetRole: %w", err)
+	}
+	return role, nil
+}
+
+// CreateRole stores a new role without permissions
+func (r *RoleRepository) CreateRole(ctx context.Context, role *models.Role) error {
+	query := `
+		INSERT INTO roles (name, description, built_in, created_at)
+		VALUES ($1, $2, FALSE, $3)
+		RETURNING id, created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query, role.Name, role.Description, time.Now().UTC(),
+	).Scan(&role.ID, &role.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateRole: %w", err)
+	}
+	return nil
+}
+
+// UpdateRoleDescription changes the description of a role
+func (r *RoleRepository) UpdateRoleDescription(ctx context.Context, name models.UserRole, description string) error {
+	if _, err := r.client.Exec(ctx, `UPDATE roles SET description = $1 WHERE name = $2`, description, name); err != nil {
+		return fmt.Errorf("UpdateRoleDescription: %w", err)
+	}
+	return nil
+}
+
+// SetRolePermissions replaces the permissions of a role
+func (r *RoleRepository) SetRolePermissions(ctx context.Context, name models.UserRole, permissions []models.Permission) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM role_permissions WHERE role = $1`, name); err != nil {
+		return fmt.Errorf("SetRolePermissions delete: %w", err)
+	}
+
+	query := `INSERT INTO role_permissions (role, permission) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	for _, permission := range permissions {
+		if _, err := r.client.Exec(ctx, query, name, permission); err != nil {
+			return fmt.Errorf("SetRolePermissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteRole deletes a role with its permissions
+func (r *RoleRepository) DeleteRole(ctx context.Context, name models.UserRole) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM roles WHERE name = $1`, name); err != nil {
+		return fmt.Errorf("DeleteRole: %w", err)
+	}
+	return nil
+}
+
+// CountRoleHolders counts users and open invitations that use a role
+func (r *RoleRepository) CountRoleHolders(ctx context.Context, name models.UserRole) (int, error) {
+	query := `
+		SELECT (SELECT COUNT(*) FROM users WHERE role = $1)
+			+ (SELECT COUNT(*) FROM user_roles WHERE role = $1)
+			+ (SELECT COUNT(*) FROM invitations WHERE role = $1 AND used_at IS NULL)
+	`
+	var count int
+	if err := r.client.QueryRow(ctx, query, name).Scan(&count); err != nil {
+		return 0, fmt.Errorf("CountRoleHolders: %w", err)
+	}
+	return count, nil
+}
+
+// SetUserRoles replaces the additional roles of a user
+func (r *RoleRepository) SetUserRoles(ctx context.Context, userID uint, roles []models.UserRole) error {
+	if _, err := r.client.Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("SetUserRoles delete: %w", err)
+	}
+
+	query := `INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	for _, role := range roles {
+		if _, err := r.client.Exec(ctx, query, userID, role); err != nil {
+			return fmt.Errorf("SetUserRoles: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetUserPermissions gets the permissions a user holds through the primary role and the additional ones
+func (r *RoleRepository) GetUserPermissions(ctx context.Context, userID uint) (models.PermissionSet, error) {
+	query := `
+		SELECT DISTINCT rp.permission
+		FROM role_permissions rp
+		WHERE rp.role IN (
+			SELECT role FROM users WHERE id = $1
+			UNION
+			SELECT role FROM user_roles WHERE user_id = $1
+		)
+		ORDER BY rp.permission
+	`
+
+	rows, err := r.client.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserPermissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := models.PermissionSet{}
+	for rows.Next() {
+		var permission models.Permission
+		if err = rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("GetUserPermissions scan: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUserPermissions rows: %w", err)
+	}
+	return permissions, nil
+}
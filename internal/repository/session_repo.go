@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/pkg/adapters/postgres"
+)
+
+// SessionRepository handles login sessions and their refresh tokens
+type SessionRepository struct {
+	client *postgres.Client
+}
+
+func NewSessionRepository(client *postgres.Client) *SessionRepository {
+	return &SessionRepository{client: client}
+}
+
+// CreateSession stores a new session
+func (r *SessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO auth_sessions (id, user_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query, session.ID, session.UserID, time.Now().UTC(), session.ExpiresAt,
+	).Scan(&session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateSession: %w", err)
+	}
+	return nil
+}
+
+// GetSession gets a session by ID
+func (r *SessionRepository) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	query := `SELECT id, user_id, created_at, expires_at, revoked_at FROM auth_sessions WHERE id = $1`
+
+	var session models.Session
+	err := r.client.QueryRow(ctx, query, id).Scan(
+		&session.ID, &session.UserID, &session.CreatedAt, &session.ExpiresAt, &session.RevokedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetSession: %w", err)
+	}
+	return &session, nil
+}
+
+// RevokeSession revokes a session, revoking it again keeps the original time
+func (r *SessionRepository) RevokeSession(ctx context.Context, id string) error {
+	query := `UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+	if _, err := r.client.Exec(ctx, query, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("RevokeSession: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every active session of a user
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID uint) error {
+	query := `UPDATE auth_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+	if _, err := r.client.Exec(ctx, query, time.Now().UTC(), userID); err != nil {
+		return fmt.Errorf("RevokeUserSessions: %w", err)
+	}
+	return nil
+}
+
+// CreateRefreshToken stores the hash of a refresh token
+func (r *SessionRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (hash, session_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	err := r.client.QueryRow(
+		ctx, query, token.Hash, token.SessionID, time.Now().UTC(), token.ExpiresAt,
+	).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("CreateRefreshToken: %w", err)
+	}
+	return nil
+}
+
+// LockRefreshToken gets a refresh token by hash and locks it until the end of the current transaction
+func (r *SessionRepository) LockRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	query := `
+		SELECT hash, session_id, created_at, expires_at, used_at
+		FROM refresh_tokens
+		WHERE hash = $1
+		FOR UPDATE
+	`
+
+	var token models.RefreshToken
+	err := r.client.QueryRow(ctx, query, hash).Scan(
+		&token.Hash, &token.SessionID, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("LockRefreshToken: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed marks a refresh token as spent on a rotation
+func (r *SessionRepository) MarkRefreshTokenUsed(ctx context.Context, hash string) error {
+	query := `UPDATE refresh_tokens SET used_at = $1 WHERE hash = $2`
+	if _, err := r.client.Exec(ctx, query, time.Now().UTC(), hash); err != nil {
+		return fmt.Errorf("MarkRefreshTokenUsed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+const (
+	// anomalyHistoryDays is how far back requests are scored against
+	anomalyHistoryDays = 365
+	// anomalyMinSamples is the history a factor needs before it is scored
+	anomalyMinSamples = 5
+	// anomalyRareShare is the share of history below which a weekday or an hour is unusual
+	anomalyRareShare = 0.05
+	// velocityWindowDays is the period recent spending is summed over
+	velocityWindowDays = 30
+	// anomalyTopRequests is how many unusual pending requests the statistics list
+	anomalyTopRequests = 5
+)
+
+// anomalyWeights are the shares of the factors in the anomaly score
+var anomalyWeights = map[models.AnomalyKind]float64{
+	models.AnomalyAmount:   0.4,
+	models.AnomalyVendor:   0.2,
+	models.AnomalyTiming:   0.15,
+	models.AnomalyVelocity: 0.25,
+}
+
+// scoreRequest scores how unusual a request is against the last year and stores the score.
+// Factors without enough history are left out.
+func (s *ExpenseService) scoreRequest(ctx context.Context, request *models.ExpenseRequest) error {
+	now := time.Now().UTC()
+	history, err := s.expenseRepo.GetAnomalyHistory(ctx, request, now.AddDate(0, 0, -anomalyHistoryDays))
+	if err != nil {
+		return err
+	}
+
+	factors := []models.AnomalyFactor{}
+	for _, factor := range []*models.AnomalyFactor{
+		amountAnomaly(request, history),
+		vendorAnomaly(request, history.Employee),
+		timingAnomaly(request, history.Employee),
+		velocityAnomaly(request, history.Employee, now),
+	} {
+		if factor != nil {
+			factor.Weight = anomalyWeights[factor.Kind]
+			factors = append(factors, *factor)
+		}
+	}
+
+	score := 0.0
+	for _, factor := range factors {
+		score += factor.Weight * factor.Score * 100
+	}
+	score = math.Round(score*100) / 100
+
+	if err = s.expenseRepo.SetAnomalyScore(ctx, request.ID, score, factors); err != nil {
+		return err
+	}
+	request.AnomalyScore = &score
+	request.AnomalyFactors = factors
+	return nil
+}
+
+// amountAnomaly rates the z-score of the amount within its category
+func amountAnomaly(request *models.ExpenseRequest, history *models.AnomalyHistory) *models.AnomalyFactor {
+	if history.CategoryCount < anomalyMinSamples || history.CategoryStddev == 0 {
+		return nil
+	}
+
+	z := (request.Amount - history.CategoryMean) / history.CategoryStddev
+	direction := "above"
+	if z < 0 {
+		direction = "below"
+	}
+	return &models.AnomalyFactor{
+		Kind:  models.AnomalyAmount,
+		Score: clamp01(z / 3),
+		Reason: fmt.Sprintf(
+			"amount %.2f is %.1f standard deviations %s the %q average of %.2f over %d requests",
+			request.Amount, math.Abs(z), direction, request.Category, history.CategoryMean, history.CategoryCount,
+		),
+	}
+}
+
+// vendorAnomaly rates a vendor new to the employee
+func vendorAnomaly(request *models.ExpenseRequest, earlier []models.ExpenseRequest) *models.AnomalyFactor {
+	if len(earlier) < anomalyMinSamples {
+		return nil
+	}
+
+	used := 0
+	for i := range earlier {
+		if sameVendor(&earlier[i], request) {
+			used++
+		}
+	}
+	if used > 0 {
+		return &models.AnomalyFactor{
+			Kind:   models.AnomalyVendor,
+			Reason: fmt.Sprintf("vendor %q is in %d of %d earlier requests of the employee", request.Vendor, used, len(earlier)),
+		}
+	}
+	return &models.AnomalyFactor{
+		Kind:   models.AnomalyVendor,
+		Score:  1,
+		Reason: fmt.Sprintf("vendor %q is in none of %d earlier requests of the employee", request.Vendor, len(earlier)),
+	}
+}
+
+// timingAnomaly rates an unusual weekday of the expense and filing hour (UTC) for half each
+func timingAnomaly(request *models.ExpenseRequest, earlier []models.ExpenseRequest) *models.AnomalyFactor {
+	if len(earlier) < anomalyMinSamples {
+		return nil
+	}
+
+	weekday, hour := request.ExpenseDate.Weekday(), request.CreatedAt.UTC().Hour()
+	sameWeekday, sameHour := 0, 0
+	for _, other := range earlier {
+		if other.ExpenseDate.Weekday() == weekday {
+			sameWeekday++
+		}
+		if hoursApart(other.CreatedAt.UTC().Hour(), hour) <= 1 {
+			sameHour++
+		}
+	}
+
+	factor := &models.AnomalyFactor{
+		Kind: models.AnomalyTiming,
+		Reason: fmt.Sprintf(
+			"%d of %d earlier requests of the employee are for a %s and %d were filed within an hour of %02d:00 UTC",
+			sameWeekday, len(earlier), weekday, sameHour, hour,
+		),
+	}
+	if float64(sameWeekday)/float64(len(earlier)) < anomalyRareShare {
+		factor.Score += 0.5
+	}
+	if float64(sameHour)/float64(len(earlier)) < anomalyRareShare {
+		factor.Score += 0.5
+	}
+	return factor
+}
+
+// velocityAnomaly rates the spending of the last 30 days against the usual 30 days before
+func velocityAnomaly(request *models.ExpenseRequest, earlier []models.ExpenseRequest, now time.Time) *models.AnomalyFactor {
+	windowStart := now.AddDate(0, 0, -velocityWindowDays)
+
+	recent, baseline := request.Amount, 0.0
+	var baselineCount int
+	var first time.Time
+	for _, other := range earlier {
+		if !other.CreatedAt.Before(windowStart) {
+			recent += other.Amount
+			continue
+		}
+		if baselineCount == 0 {
+			first = other.CreatedAt
+		}
+		baseline += other.Amount
+		baselineCount++
+	}
+	if baselineCount < anomalyMinSamples {
+		return nil
+	}
+
+	windows := math.Max(1, math.Ceil(windowStart.Sub(first).Hours()/24/velocityWindowDays))
+	usual := baseline / windows
+	ratio := recent / usual
+	return &models.AnomalyFactor{
+		Kind:  models.AnomalyVelocity,
+		Score: clamp01((ratio - 1) / 2),
+		Reason: fmt.Sprintf(
+			"%.2f spent in the last %d days with this request, %.1f times the usual %.2f",
+			recent, velocityWindowDays, ratio, usual,
+		),
+	}
+}
+
+// hoursApart is the distance between two hours of the day
+func hoursApart(a, b int) int {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	return min(diff, 24-diff)
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+func TestAmountAnomaly(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    float64
+		history   models.AnomalyHistory
+		wantNil   bool
+		wantScore float64
+		wantWord  string
+	}{
+		{"three deviations above", 1300, models.AnomalyHistory{CategoryCount: 10, CategoryMean: 1000, CategoryStddev: 100}, false, 1, "above"},
+		{"further above is capped", 2000, models.AnomalyHistory{CategoryCount: 10, CategoryMean: 1000, CategoryStddev: 100}, false, 1, "above"},
+		{"one and a half deviations", 1150, models.AnomalyHistory{CategoryCount: 10, CategoryMean: 1000, CategoryStddev: 100}, false, 0.5, "above"},
+		{"below the mean", 500, models.AnomalyHistory{CategoryCount: 10, CategoryMean: 1000, CategoryStddev: 100}, false, 0, "below"},
+		{"too few samples", 5000, models.AnomalyHistory{CategoryCount: 4, CategoryMean: 1000, CategoryStddev: 100}, true, 0, ""},
+		{"no spread", 5000, models.AnomalyHistory{CategoryCount: 10, CategoryMean: 1000}, true, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := tt.history
+			factor := amountAnomaly(&models.ExpenseRequest{Amount: tt.amount, Category: "travel"}, &history)
+			if tt.wantNil {
+				if factor != nil {
+					t.Errorf("amountAnomaly() = %+v, want nil", factor)
+				}
+				return
+			}
+			if factor == nil || factor.Kind != models.AnomalyAmount || math.Abs(factor.Score-tt.wantScore) > 1e-9 {
+				t.Fatalf("amountAnomaly() = %+v, want score %v", factor, tt.wantScore)
+			}
+			if !strings.Contains(factor.Reason, tt.wantWord) {
+				t.Errorf("reason %q does not say %q", factor.Reason, tt.wantWord)
+			}
+		})
+	}
+}
+
+func TestVendorAnomaly(t *testing.T) {
+	earlier := func(vendors ...string) []models.ExpenseRequest {
+		requests := make([]models.ExpenseRequest, len(vendors))
+		for i, vendor := range vendors {
+			requests[i].Vendor = vendor
+		}
+		return requests
+	}
+
+	tests := []struct {
+		name      string
+		earlier   []models.ExpenseRequest
+		wantNil   bool
+		wantScore float64
+	}{
+		{"known vendor", earlier("Aeroflot", "S7", "aeroflot ", "Uber", "Uber"), false, 0},
+		{"new vendor", earlier("S7", "S7", "Uber", "Uber", "Hilton"), false, 1},
+		{"too little history", earlier("S7", "Uber"), true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor := vendorAnomaly(&models.ExpenseRequest{Vendor: "Aeroflot"}, tt.earlier)
+			if tt.wantNil {
+				if factor != nil {
+					t.Errorf("vendorAnomaly() = %+v, want nil", factor)
+				}
+				return
+			}
+			if factor == nil || factor.Kind != models.AnomalyVendor || factor.Score != tt.wantScore {
+				t.Errorf("vendorAnomaly() = %+v, want score %v", factor, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestTimingAnomaly(t *testing.T) {
+	// Five earlier requests for Mondays, filed at 10:00 UTC
+	var earlier []models.ExpenseRequest
+	for week := 0; week < 5; week++ {
+		monday := date(2024, time.March, 4).AddDate(0, 0, 7*week)
+		earlier = append(earlier, models.ExpenseRequest{ExpenseDate: monday, CreatedAt: monday.Add(10 * time.Hour)})
+	}
+	monday, sunday := date(2024, time.May, 6), date(2024, time.May, 5)
+
+	tests := []struct {
+		name      string
+		request   models.ExpenseRequest
+		earlier   []models.ExpenseRequest
+		wantNil   bool
+		wantScore float64
+	}{
+		{"usual day and hour", models.ExpenseRequest{ExpenseDate: monday, CreatedAt: monday.Add(11 * time.Hour)}, earlier, false, 0},
+		{"unusual hour", models.ExpenseRequest{ExpenseDate: monday, CreatedAt: monday.Add(3 * time.Hour)}, earlier, false, 0.5},
+		{"unusual day", models.ExpenseRequest{ExpenseDate: sunday, CreatedAt: monday.Add(9 * time.Hour)}, earlier, false, 0.5},
+		{"unusual day and hour", models.ExpenseRequest{ExpenseDate: sunday, CreatedAt: sunday.Add(22 * time.Hour)}, earlier, false, 1},
+		{"too little history", models.ExpenseRequest{ExpenseDate: sunday, CreatedAt: sunday}, earlier[:4], true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor := timingAnomaly(&tt.request, tt.earlier)
+			if tt.wantNil {
+				if factor != nil {
+					t.Errorf("timingAnomaly() = %+v, want nil", factor)
+				}
+				return
+			}
+			if factor == nil || factor.Kind != models.AnomalyTiming || factor.Score != tt.wantScore {
+				t.Errorf("timingAnomaly() = %+v, want score %v", factor, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestVelocityAnomaly(t *testing.T) {
+	now := date(2024, time.June, 30)
+	windowStart := now.AddDate(0, 0, -velocityWindowDays)
+
+	// Five requests of 1000 over the two 30-day windows before the last one: 2500 per window usually
+	var baseline []models.ExpenseRequest
+	for i := 0; i < 5; i++ {
+		baseline = append(baseline, models.ExpenseRequest{Amount: 1000, CreatedAt: windowStart.AddDate(0, 0, -60+12*i)})
+	}
+	recent := models.ExpenseRequest{Amount: 2500, CreatedAt: now.AddDate(0, 0, -3)}
+
+	tests := []struct {
+		name      string
+		amount    float64
+		earlier   []models.ExpenseRequest
+		wantNil   bool
+		wantScore float64
+	}{
+		{"usual spending", 2500, baseline, false, 0},
+		{"twice the usual", 5000, baseline, false, 0.5},
+		{"three times with recent requests", 5000, append(slices.Clone(baseline), recent), false, 1},
+		{"less than usual", 100, baseline, false, 0},
+		{"too little history", 5000, baseline[:4], true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor := velocityAnomaly(&models.ExpenseRequest{Amount: tt.amount}, tt.earlier, now)
+			if tt.wantNil {
+				if factor != nil {
+					t.Errorf("velocityAnomaly() = %+v, want nil", factor)
+				}
+				return
+			}
+			if factor == nil || factor.Kind != models.AnomalyVelocity || math.Abs(factor.Score-tt.wantScore) > 1e-9 {
+				t.Errorf("velocityAnomaly() = %+v, want score %v", factor, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestHoursApart(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want int
+	}{
+		{10, 10, 0},
+		{10, 13, 3},
+		{13, 10, 3},
+		{23, 0, 1},
+		{0, 23, 1},
+		{6, 18, 12},
+		{2, 20, 6},
+	}
+	for _, tt := range tests {
+		if got := hoursApart(tt.a, tt.b); got != tt.want {
+			t.Errorf("hoursApart(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClamp01(t *testing.T) {
+	tests := []struct {
+		value, want float64
+	}{
+		{-1, 0},
+		{0, 0},
+		{0.3, 0.3},
+		{1, 1},
+		{2.5, 1},
+	}
+	for _, tt := range tests {
+		if got := clamp01(tt.value); got != tt.want {
+			t.Errorf("clamp01(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+)
+
+var ErrStepRoleRequired = errors.New("the current approval step needs another role")
+
+// ApprovalLevel describes one step of the approval chain.
+// The level is required when the request amount is above MinAmount.
+// When Role is set only its holders may decide the level.
+type ApprovalLevel struct {
+	Name      string
+	MinAmount float64
+	Role      models.UserRole
+}
+
+// ApprovalChain is an ordered list of approval levels
+type ApprovalChain []ApprovalLevel
+
+// DefaultApprovalChain requires a single management approval for every request
+var DefaultApprovalChain = ApprovalChain{{Name: "manager", MinAmount: 0}}
+
+// ParseApprovalChain parses a chain definition like
+// "line_manager:0,finance:50000:accountant,director:200000:director".
+// An empty definition yields DefaultApprovalChain.
+func ParseApprovalChain(definition string) (ApprovalChain, error) {
+	definition = strings.TrimSpace(definition)
+	if definition == "" {
+		return DefaultApprovalChain, nil
+	}
+
+	var chain ApprovalChain
+	for _, item := range strings.Split(definition, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 3)
+		name := strings.TrimSpace(parts[0])
+		if name == "" {
+			return nil, fmt.Errorf("invalid approval level %q: empty name", item)
+		}
+
+		var minAmount float64
+		if len(parts) > 1 {
+			var err error
+			minAmount, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+			if err != nil || minAmount < 0 {
+				return nil, fmt.Errorf("invalid approval level %q: bad amount", item)
+			}
+		}
+
+		var role models.UserRole
+		if len(parts) > 2 {
+			role = models.UserRole(strings.TrimSpace(parts[2]))
+			if role == "" {
+				return nil, fmt.Errorf("invalid approval level %q: empty role", item)
+			}
+		}
+
+		chain = append(chain, ApprovalLevel{Name: name, MinAmount: minAmount, Role: role})
+	}
+
+	sort.SliceStable(chain, func(i, j int) bool {
+		return chain[i].MinAmount < chain[j].MinAmount
+	})
+	return chain, nil
+}
+
+// CheckApprovalChain makes sure every role the chain names exists
+func CheckApprovalChain(ctx context.Context, roleRepo *repository.RoleRepository, chain ApprovalChain) error {
+	for _, level := range chain {
+		if level.Role == "" {
+			continue
+		}
+		if err := checkRoles(ctx, roleRepo, level.Role); err != nil {
+			return fmt.Errorf("approval level %q: %w", level.Name, err)
+		}
+	}
+	return nil
+}
+
+// StepsFor builds the approval steps required for a request
+func (c ApprovalChain) StepsFor(request *models.ExpenseRequest) []models.ApprovalStep {
+	var steps []models.ApprovalStep
+	for _, level := range c {
+		if level.MinAmount > 0 && request.Amount <= level.MinAmount {
+			continue
+		}
+		steps = append(steps, models.ApprovalStep{
+			RequestID: request.ID,
+			StepOrder: len(steps) + 1,
+			Name:      level.Name,
+			MinAmount: level.MinAmount,
+			Role:      level.Role,
+			Status:    models.ApprovalPending,
+		})
+	}
+
+	// Every request needs at least one approval, even below all thresholds
+	if len(steps) == 0 && len(c) > 0 {
+		steps = append(steps, models.ApprovalStep{
+			RequestID: request.ID,
+			StepOrder: 1,
+			Name:      c[0].Name,
+			MinAmount: c[0].MinAmount,
+			Role:      c[0].Role,
+			Status:    models.ApprovalPending,
+		})
+	}
+	return steps
+}
+
+// currentApprovalStep returns the first undecided step and whether it is the last one
+func currentApprovalStep(steps []models.ApprovalStep) (*models.ApprovalStep, bool) {
+	for i := range steps {
+		if steps[i].Status == models.ApprovalPending {
+			return &steps[i], i == len(steps)-1
+		}
+	}
+	return nil, false
+}
+
+// checkStepRole makes sure the reviewer holds the role the step asks for.
+// Under a delegation the roles of the delegator count.
+func (s *ExpenseService) checkStepRole(ctx context.Context, step *models.ApprovalStep, reviewerID uint, onBehalfOf *uint) error {
+	if step.Role == "" {
+		return nil
+	}
+
+	deciderID := reviewerID
+	if onBehalfOf != nil {
+		deciderID = *onBehalfOf
+	}
+	decider, err := s.userRepo.GetUserByID(ctx, deciderID)
+	if err != nil {
+		return fmt.Errorf("failed to get reviewer: %w", err)
+	}
+	if !decider.HasRole(step.Role) {
+		return fmt.Errorf("%w: step %q is decided by the %s role", ErrStepRoleRequired, step.Name, step.Role)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"curswork-trpo/internal/models"
+)
+
+func TestParseApprovalChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		want       ApprovalChain
+		wantErr    bool
+	}{
+		{"empty", "", DefaultApprovalChain, false},
+		{"sorted by amount", "director:200000,manager:0", ApprovalChain{
+			{Name: "manager"}, {Name: "director", MinAmount: 200000},
+		}, false},
+		{"roles", "manager:0,finance:50000:accountant", ApprovalChain{
+			{Name: "manager"}, {Name: "finance", MinAmount: 50000, Role: "accountant"},
+		}, false},
+		{"name only", "manager", ApprovalChain{{Name: "manager"}}, false},
+		{"empty name", ":100", nil, true},
+		{"bad amount", "finance:lots", nil, true},
+		{"negative amount", "finance:-1", nil, true},
+		{"empty role", "finance:100:", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := ParseApprovalChain(tt.definition)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseApprovalChain(%q) error = %v, wantErr %v", tt.definition, err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(chain, tt.want) {
+				t.Errorf("ParseApprovalChain(%q) = %+v, want %+v", tt.definition, chain, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepsForCarriesRoles(t *testing.T) {
+	chain := ApprovalChain{{Name: "manager"}, {Name: "finance", MinAmount: 50000, Role: "accountant"}}
+
+	steps := chain.StepsFor(&models.ExpenseRequest{ID: 7, Amount: 60000})
+	if len(steps) != 2 {
+		t.Fatalf("StepsFor returned %d steps, want 2", len(steps))
+	}
+	if steps[0].Role != "" || steps[1].Role != "accountant" {
+		t.Errorf("step roles = %q, %q, want \"\", \"accountant\"", steps[0].Role, steps[1].Role)
+	}
+
+	steps = chain.StepsFor(&models.ExpenseRequest{ID: 7, Amount: 1000})
+	if len(steps) != 1 || steps[0].Name != "manager" {
+		t.Errorf("StepsFor below the finance threshold = %+v, want the manager step only", steps)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+	"curswork-trpo/pkg/adapters/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAttachmentNotFound      = errors.New("attachment not found")
+	ErrAttachmentTooLarge      = errors.New("attachment is too large")
+	ErrAttachmentTypeForbidden = errors.New("attachment type is not allowed")
+	ErrRequestNotFound         = errors.New("request not found")
+	ErrRequestNotEditable      = errors.New("only the owner can change a request")
+	ErrRequestAccessDenied     = errors.New("access to the request denied")
+)
+
+// AttachmentConfig limits uploaded files
+type AttachmentConfig struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+// DefaultAttachmentTypes are the content types accepted for receipts and invoices
+var DefaultAttachmentTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/webp"}
+
+// AttachmentService handles receipts and invoices attached to expense requests
+type AttachmentService struct {
+	db             *postgres.Client
+	attachmentRepo *repository.AttachmentRepository
+	expenseRepo    *repository.ExpenseRepository
+	storage        storage.Storage
+	audit          *AuditService
+	config         AttachmentConfig
+}
+
+func NewAttachmentService(
+	db *postgres.Client,
+	attachmentRepo *repository.AttachmentRepository,
+	expenseRepo *repository.ExpenseRepository,
+	store storage.Storage,
+	audit *AuditService,
+	config AttachmentConfig,
+) *AttachmentService {
+	return &AttachmentService{
+		db:             db,
+		attachmentRepo: attachmentRepo,
+		expenseRepo:    expenseRepo,
+		storage:        store,
+		audit:          audit,
+		config:         config,
+	}
+}
+
+// UploadAttachment stores a file and attaches it to a pending request of the uploader.
+// The content type is detected from the file content, not taken from the client.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, requestID, userID uint, fileName string, content io.Reader, size int64) (*models.Attachment, error) {
+	if size > s.config.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrAttachmentTooLarge, size, s.config.MaxSize)
+	}
+
+	if _, err := s.editableRequest(ctx, requestID, userID); err != nil {
+		return nil, err
+	}
+
+	// Sniff the content type from the first bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	head = head[:n]
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	if !slices.Contains(s.config.AllowedTypes, contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeForbidden, contentType)
+	}
+
+	key, err := attachmentKey(requestID, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), content), hash)
+	if err = s.storage.Put(ctx, key, body, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		RequestID:   requestID,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+		UploadedBy:  userID,
+	}
+
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.attachmentRepo.CreateAttachment(ctx, attachment); err != nil {
+			return fmt.Errorf("failed to save attachment: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditAttachmentAdded,
+			EntityType: models.AuditEntityAttachment,
+			EntityID:   attachment.ID,
+			After:      attachment,
+		})
+	})
+	if err != nil {
+		if delErr := s.storage.Delete(ctx, key); delErr != nil {
+			log.Printf("failed to remove orphaned attachment %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// ListAttachments lists attachments of a request visible to the user
+func (s *AttachmentService) ListAttachments(ctx context.Context, requestID, userID uint, perms models.PermissionSet) ([]models.Attachment, error) {
+	if _, err := s.visibleRequest(ctx, requestID, userID, perms); err != nil {
+		return nil, err
+	}
+	return s.attachmentRepo.GetAttachmentsByRequest(ctx, requestID)
+}
+
+// OpenAttachment returns attachment metadata and its content
+func (s *AttachmentService) OpenAttachment(ctx context.Context, requestID, attachmentID, userID uint, perms models.PermissionSet) (*models.Attachment, io.ReadCloser, error) {
+	if _, err := s.visibleRequest(ctx, requestID, userID, perms); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.getAttachment(ctx, requestID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.storage.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// DeleteAttachment removes an attachment from a pending request of its owner
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, requestID, attachmentID, userID uint) error {
+	var attachment *models.Attachment
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.expenseRepo.LockExpenseRequest(ctx, requestID); err != nil {
+			return ErrRequestNotFound
+		}
+		if _, err := s.editableRequest(ctx, requestID, userID); err != nil {
+			return err
+		}
+
+		var err error
+		if attachment, err = s.getAttachment(ctx, requestID, attachmentID); err != nil {
+			return err
+		}
+		if err = s.attachmentRepo.DeleteAttachment(ctx, attachment.ID); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditAttachmentDeleted,
+			EntityType: models.AuditEntityAttachment,
+			EntityID:   attachment.ID,
+			Before:     attachment,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// A leftover object is harmless once the metadata is gone
+	if err = s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		log.Printf("failed to remove attachment %s: %v", attachment.StorageKey, err)
+	}
+	return nil
+}
+
+func (s *AttachmentService) getAttachment(ctx context.Context, requestID, attachmentID uint) (*models.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetAttachment(ctx, requestID, attachmentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+// visibleRequest gets a request the user may look at
+func (s *AttachmentService) visibleRequest(ctx context.Context, requestID, userID uint, perms models.PermissionSet) (*models.ExpenseRequest, error) {
+	request, err := s.expenseRepo.GetExpenseRequestByID(ctx, requestID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = CheckView(request, userID, perms); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// editableRequest gets a request whose attachments the user may change
+func (s *AttachmentService) editableRequest(ctx context.Context, requestID, userID uint) (*models.ExpenseRequest, error) {
+	request, err := s.expenseRepo.GetExpenseRequestByID(ctx, requestID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = CheckEdit(request, userID); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+var attachmentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// attachmentKey builds a unique storage key
+func attachmentKey(requestID uint, contentType string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate attachment key: %w", err)
+	}
+	return fmt.Sprintf("expenses/%d/%s%s", requestID, hex.EncodeToString(random), attachmentExtensions[contentType]), nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/internal/reqctx"
+	"curswork-trpo/pkg/adapters/postgres"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+	auditChainBatch   = 1000
+	// minAuditKeyLength is the shortest key the audit log is signed with, in bytes
+	minAuditKeyLength = 32
+)
+
+var ErrAuditKeyTooShort = errors.New("audit log key must be at least 32 bytes")
+
+// AuditEvent describes a change to record in the audit log.
+// Before and After are snapshots of the entity or nil where it did not exist.
+type AuditEvent struct {
+	ActorID    *uint
+	Action     models.AuditAction
+	EntityType models.AuditEntity
+	EntityID   uint
+	Before     interface{}
+	After      interface{}
+}
+
+// AuditService writes and reads the append-only audit log.
+// Records are chained and signed with an HMAC key the database does not hold.
+type AuditService struct {
+	db        *postgres.Client
+	auditRepo *repository.AuditRepository
+	key       []byte
+}
+
+func NewAuditService(db *postgres.Client, auditRepo *repository.AuditRepository, key []byte) (*AuditService, error) {
+	if len(key) < minAuditKeyLength {
+		return nil, ErrAuditKeyTooShort
+	}
+	return &AuditService{db: db, auditRepo: auditRepo, key: key}, nil
+}
+
+// Record appends an event to the audit log. Called inside a transaction it is
+// written atomically with the change it describes just before the commit.
+func (s *AuditService) Record(ctx context.Context, event AuditEvent) error {
+	entry := &models.AuditEntry{
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		RequestID:  reqctx.RequestID(ctx),
+	}
+
+	var err error
+	if entry.Before, err = auditSnapshot(event.Before); err != nil {
+		return err
+	}
+	if entry.After, err = auditSnapshot(event.After); err != nil {
+		return err
+	}
+
+	return s.db.BeforeCommit(ctx, func(ctx context.Context) error {
+		if err := s.appendEntry(ctx, entry); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+}
+
+// appendEntry links an entry to the last record of the log, signs it and appends it
+func (s *AuditService) appendEntry(ctx context.Context, entry *models.AuditEntry) error {
+	if err := s.auditRepo.LockAuditChain(ctx); err != nil {
+		return err
+	}
+	if err := s.auditRepo.PrepareAuditEntry(ctx, entry); err != nil {
+		return err
+	}
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	entry.Hash = s.sign(entry)
+	return s.auditRepo.CreateAuditEntry(ctx, entry)
+}
+
+// RecordBestEffort records an event whose failure must not break the operation, e.g. a login
+func (s *AuditService) RecordBestEffort(ctx context.Context, event AuditEvent) {
+	if err := s.Record(ctx, event); err != nil {
+		log.Printf("audit %s of %s %d: %v", event.Action, event.EntityType, event.EntityID, err)
+	}
+}
+
+// ListEntries gets audit entries matching the filter, newest first
+func (s *AuditService) ListEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.auditRepo.ListAuditEntries(ctx, filter)
+}
+
+// VerifyChain walks the audit log hash chain from the first record and reports the first broken link.
+// A record is broken when its content does not match its signature or it does not point to the record before it.
+// Unsigned legacy records are accepted only before the first signed one.
+func (s *AuditService) VerifyChain(ctx context.Context) (*models.AuditChainReport, error) {
+	report := &models.AuditChainReport{Valid: true}
+	signed := false
+
+	for {
+		entries, err := s.auditRepo.GetAuditChain(ctx, report.LastID, auditChainBatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			switch {
+			case entry.PrevHash != report.LastHash:
+				report.Reason = "previous hash does not match the preceding record"
+			case hmac.Equal([]byte(entry.Hash), []byte(s.sign(entry))):
+				signed = true
+			case !signed && entry.Hash == legacyAuditHash(entry):
+				report.Legacy++
+			default:
+				report.Reason = "record content does not match its hash"
+			}
+			if report.Reason != "" {
+				report.Valid = false
+				report.BrokenAt = &entry.ID
+				return report, nil
+			}
+
+			report.Checked++
+			report.LastID = entry.ID
+			report.LastHash = entry.Hash
+		}
+
+		if len(entries) < auditChainBatch {
+			return report, nil
+		}
+	}
+}
+
+// sign is the HMAC-SHA256 of the content of an audit record
+func (s *AuditService) sign(entry *models.AuditEntry) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(auditContent(entry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// legacyAuditHash is the plain SHA-256 the database hashed records with before they were signed
+func legacyAuditHash(entry *models.AuditEntry) string {
+	sum := sha256.Sum256(auditContent(entry))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditContent lays out what the hash of an audit record covers
+func auditContent(entry *models.AuditEntry) []byte {
+	actorID := ""
+	if entry.ActorID != nil {
+		actorID = strconv.FormatUint(uint64(*entry.ActorID), 10)
+	}
+	return []byte(strings.Join([]string{
+		entry.PrevHash,
+		strconv.FormatUint(uint64(entry.ID), 10),
+		actorID,
+		string(entry.Action),
+		string(entry.EntityType),
+		strconv.FormatUint(uint64(entry.EntityID), 10),
+		string(entry.Before),
+		string(entry.After),
+		entry.RequestID,
+		entry.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000"),
+	}, "|"))
+}
+
+func auditSnapshot(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAutoApprovalRuleNotFound = errors.New("auto-approval rule not found")
+
+// AutoApprovalService manages the rules that approve low-risk requests
+type AutoApprovalService struct {
+	db               *postgres.Client
+	autoApprovalRepo *repository.AutoApprovalRepository
+	categoryRepo     *repository.CategoryRepository
+	audit            *AuditService
+}
+
+func NewAutoApprovalService(
+	db *postgres.Client,
+	autoApprovalRepo *repository.AutoApprovalRepository,
+	categoryRepo *repository.CategoryRepository,
+	audit *AuditService,
+) *AutoApprovalService {
+	return &AutoApprovalService{db: db, autoApprovalRepo: autoApprovalRepo, categoryRepo: categoryRepo, audit: audit}
+}
+
+// ListAutoApprovalRules gets the active rules, or all of them with includeInactive
+func (s *AutoApprovalService) ListAutoApprovalRules(ctx context.Context, includeInactive bool) ([]models.AutoApprovalRule, error) {
+	return s.autoApprovalRepo.ListAutoApprovalRules(ctx, includeInactive)
+}
+
+// GetAutoApprovalRule gets a rule
+func (s *AutoApprovalService) GetAutoApprovalRule(ctx context.Context, id uint) (*models.AutoApprovalRule, error) {
+	rule, err := s.autoApprovalRepo.GetAutoApprovalRule(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAutoApprovalRuleNotFound
+	}
+	return rule, err
+}
+
+// CreateAutoApprovalRule adds a rule
+func (s *AutoApprovalService) CreateAutoApprovalRule(
+	ctx context.Context, dto *models.AutoApprovalRuleDTO, actorID uint,
+) (*models.AutoApprovalRule, error) {
+	rule := &models.AutoApprovalRule{}
+	applyAutoApprovalRuleDTO(rule, dto)
+
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.checkCategory(ctx, rule); err != nil {
+			return err
+		}
+		if err := s.autoApprovalRepo.CreateAutoApprovalRule(ctx, rule); err != nil {
+			return fmt.Errorf("failed to create auto-approval rule: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditAutoRuleCreated,
+			EntityType: models.AuditEntityAutoRule,
+			EntityID:   rule.ID,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateAutoApprovalRule changes a rule or deactivates it
+func (s *AutoApprovalService) UpdateAutoApprovalRule(
+	ctx context.Context, id uint, dto *models.AutoApprovalRuleDTO, actorID uint,
+) (*models.AutoApprovalRule, error) {
+	var after *models.AutoApprovalRule
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetAutoApprovalRule(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updated := *before
+		applyAutoApprovalRuleDTO(&updated, dto)
+		if err = s.checkCategory(ctx, &updated); err != nil {
+			return err
+		}
+		if err = s.autoApprovalRepo.UpdateAutoApprovalRule(ctx, &updated); err != nil {
+			return fmt.Errorf("failed to update auto-approval rule: %w", err)
+		}
+
+		after = &updated
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditAutoRuleUpdated,
+			EntityType: models.AuditEntityAutoRule,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteAutoApprovalRule deletes a rule
+func (s *AutoApprovalService) DeleteAutoApprovalRule(ctx context.Context, id, actorID uint) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetAutoApprovalRule(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = s.autoApprovalRepo.DeleteAutoApprovalRule(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditAutoRuleDeleted,
+			EntityType: models.AuditEntityAutoRule,
+			EntityID:   id,
+			Before:     before,
+		})
+	})
+}
+
+// checkCategory makes sure the category of a rule is in the registry
+func (s *AutoApprovalService) checkCategory(ctx context.Context, rule *models.AutoApprovalRule) error {
+	if rule.Category == nil {
+		return nil
+	}
+	_, err := s.categoryRepo.GetCategoryByCode(ctx, *rule.Category)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %q", ErrUnknownCategory, *rule.Category)
+	}
+	return err
+}
+
+// applyAutoApprovalRuleDTO copies the editable fields onto a rule
+func applyAutoApprovalRuleDTO(rule *models.AutoApprovalRule, dto *models.AutoApprovalRuleDTO) {
+	rule.Name = dto.Name
+	rule.Category = dto.Category
+	rule.MaxAmount = dto.MaxAmount
+	rule.MinTrustLevel = dto.MinTrustLevel
+	rule.MinBudgetRemaining = dto.MinBudgetRemaining
+	rule.IsActive = dto.IsActive == nil || *dto.IsActive
+}
+
+// autoApprove approves a request just sent to review when an auto-approval rule covers it.
+// The system user approves every step with the checks and budget debiting of a manual approval.
+// Requests needing two approvers, flagged ones, missing a receipt or with policy findings are left to reviewers.
+// It returns the rule applied, or nil when no rule covers the request.
+// On an error nothing is changed and the request stays pending.
+func (s *ExpenseService) autoApprove(ctx context.Context, id uint) (*models.AutoApprovalRule, error) {
+	var applied *models.AutoApprovalRule
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		rules, err := s.autoRepo.ListAutoApprovalRules(ctx, false)
+		if err != nil || len(rules) == 0 {
+			return err
+		}
+
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if request.Status != models.StatusPending || s.duties.needsFourEyes(request) {
+			return nil
+		}
+		flags, err := s.flagRepo.GetFlagsByRequests(ctx, []uint{id})
+		if err != nil || len(flags[id]) > 0 {
+			return err
+		}
+		employee, err := s.userRepo.GetUserByID(ctx, request.EmployeeID)
+		if err != nil {
+			return fmt.Errorf("failed to get employee: %w", err)
+		}
+
+		var candidates []*models.AutoApprovalRule
+		for i := range rules {
+			if rules[i].Covers(request, employee) {
+				candidates = append(candidates, &rules[i])
+			}
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		if err = s.checkReceipt(ctx, request); errors.Is(err, ErrReceiptRequired) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		system, err := s.userRepo.GetSystemUser(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get system user: %w", err)
+		}
+		evaluation, err := s.evaluatePolicies(ctx, request, models.PolicyStageApprove, system.ID)
+		if err != nil {
+			return err
+		}
+		if evaluation != nil && evaluation.Outcome != models.PolicyPassed {
+			return nil
+		}
+
+		charged := *request
+		if err = s.convertOnApproval(ctx, &charged, system.ID); err != nil {
+			return err
+		}
+		period := s.budgetConfig.ChargePeriod(&charged, time.Now().UTC())
+		budgets, err := s.lockChargedBudgets(ctx, &charged, period)
+		if err != nil {
+			return err
+		}
+		for _, rule := range candidates {
+			if budgetsAllow(rule, budgets, charged.Amount) {
+				applied = rule
+				break
+			}
+		}
+		if applied == nil {
+			return nil
+		}
+
+		if evaluation != nil {
+			if err = s.policyRepo.CreatePolicyEvaluation(ctx, evaluation); err != nil {
+				return err
+			}
+		}
+		comments := fmt.Sprintf("approved automatically by rule %q", applied.Name)
+		steps, err := s.approvalRepo.GetApprovalSteps(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get approval steps: %w", err)
+		}
+		for _, step := range steps {
+			if step.Status != models.ApprovalPending {
+				continue
+			}
+			if err = s.approvalRepo.DecideApprovalStep(
+				ctx, step.ID, system.ID, nil, models.ApprovalApproved, comments,
+			); err != nil {
+				return fmt.Errorf("failed to approve step %q: %w", step.Name, err)
+			}
+		}
+
+		if err = s.debitBudgets(ctx, &charged, budgets, system.ID); err != nil {
+			return err
+		}
+		if err = s.expenseRepo.UpdateExpenseRequestStatus(
+			ctx, id, system.ID, nil, models.StatusApproved, comments,
+		); err != nil {
+			return fmt.Errorf("failed to approve request: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &system.ID,
+			Action:     models.AuditExpenseAutoApproved,
+			EntityType: models.AuditEntityExpense,
+			EntityID:   id,
+			Before:     request,
+			After:      map[string]interface{}{"status": models.StatusApproved, "rule": applied},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// budgetsAllow tells whether every budget keeps the share a rule asks for after an expense
+func budgetsAllow(rule *models.AutoApprovalRule, budgets []*models.Budget, amount float64) bool {
+	for _, budget := range budgets {
+		remaining := budget.Remaining - amount
+		if remaining < 0 {
+			return false
+		}
+		if rule.MinBudgetRemaining != nil && remaining < budget.Total**rule.MinBudgetRemaining/100 {
+			return false
+		}
+	}
+	return true
+}
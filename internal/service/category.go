@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category with this code already exists")
+	ErrCategoryInUse    = errors.New("category has sub-categories or is used by requests or delegations, deactivate it instead")
+	ErrCategoryCycle    = errors.New("a category cannot be placed below itself or its sub-categories")
+	ErrUnknownCategory  = errors.New("unknown or inactive category")
+	ErrCategoryLimit    = errors.New("category limit exceeded")
+	ErrReceiptRequired  = errors.New("a receipt has to be attached to requests of this category")
+)
+
+// CategoryService manages the registry of expense categories and their limits
+type CategoryService struct {
+	db           *postgres.Client
+	categoryRepo *repository.CategoryRepository
+	audit        *AuditService
+}
+
+func NewCategoryService(db *postgres.Client, categoryRepo *repository.CategoryRepository, audit *AuditService) *CategoryService {
+	return &CategoryService{db: db, categoryRepo: categoryRepo, audit: audit}
+}
+
+// ListCategories gets the active categories, or all of them with includeInactive
+func (s *CategoryService) ListCategories(ctx context.Context, includeInactive bool) ([]models.Category, error) {
+	return s.categoryRepo.ListCategories(ctx, includeInactive)
+}
+
+// GetCategory gets a category
+func (s *CategoryService) GetCategory(ctx context.Context, id uint) (*models.Category, error) {
+	category, err := s.categoryRepo.GetCategory(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// CreateCategory adds a category to the registry
+func (s *CategoryService) CreateCategory(ctx context.Context, dto *models.CreateCategoryDTO, actorID uint) (*models.Category, error) {
+	category := &models.Category{Code: dto.Code}
+	applyCategoryDTO(category, &dto.UpdateCategoryDTO)
+
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		_, err := s.categoryRepo.GetCategoryByCode(ctx, dto.Code)
+		if err == nil {
+			return ErrCategoryExists
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err = s.checkParent(ctx, category); err != nil {
+			return err
+		}
+
+		if err = s.categoryRepo.CreateCategory(ctx, category); err != nil {
+			return fmt.Errorf("failed to create category: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditCategoryCreated,
+			EntityType: models.AuditEntityCategory,
+			EntityID:   category.ID,
+			After:      category,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// UpdateCategory changes the names, parent, limits and active flag of a category.
+// New limits apply to requests submitted from then on.
+func (s *CategoryService) UpdateCategory(
+	ctx context.Context, id uint, dto *models.UpdateCategoryDTO, actorID uint,
+) (*models.Category, error) {
+	var after *models.Category
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetCategory(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updated := *before
+		applyCategoryDTO(&updated, dto)
+		if err = s.checkParent(ctx, &updated); err != nil {
+			return err
+		}
+		if err = s.categoryRepo.UpdateCategory(ctx, &updated); err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+
+		after = &updated
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditCategoryUpdated,
+			EntityType: models.AuditEntityCategory,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteCategory deletes a category nothing refers to, used ones can only be deactivated
+func (s *CategoryService) DeleteCategory(ctx context.Context, id, actorID uint) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetCategory(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		inUse, err := s.categoryRepo.CategoryInUse(ctx, before)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrCategoryInUse
+		}
+
+		if err = s.categoryRepo.DeleteCategory(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditCategoryDeleted,
+			EntityType: models.AuditEntityCategory,
+			EntityID:   id,
+			Before:     before,
+		})
+	})
+}
+
+// checkParent makes sure the parent of a category exists and does not lie below the category itself
+func (s *CategoryService) checkParent(ctx context.Context, category *models.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	if _, err := s.GetCategory(ctx, *category.ParentID); errors.Is(err, ErrCategoryNotFound) {
+		return fmt.Errorf("%w: unknown parent category", ErrCategoryNotFound)
+	} else if err != nil {
+		return err
+	}
+
+	// A new category has no ID yet and cannot be anyone's ancestor
+	if category.ID == 0 {
+		return nil
+	}
+	path, err := s.categoryRepo.GetCategoryPath(ctx, *category.ParentID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(path, category.ID) {
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+// applyCategoryDTO copies the editable fields onto a category
+func applyCategoryDTO(category *models.Category, dto *models.UpdateCategoryDTO) {
+	category.Name = dto.Name
+	category.Names = dto.Names
+	if category.Names == nil {
+		category.Names = map[string]string{}
+	}
+	category.ParentID = dto.ParentID
+	category.IsActive = dto.IsActive == nil || *dto.IsActive
+	category.MaxAmount = dto.MaxAmount
+	category.MonthlyCap = dto.MonthlyCap
+	category.ReceiptRequired = dto.ReceiptRequired
+}
+
+// checkCategory makes sure a request goes to an active category of the registry
+// and keeps within its per-request and monthly limits
+func (s *ExpenseService) checkCategory(ctx context.Context, request *models.ExpenseRequest) error {
+	category, err := s.categoryRepo.GetCategoryByCode(ctx, request.Category)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !category.IsActive) {
+		return fmt.Errorf("%w: %q", ErrUnknownCategory, request.Category)
+	}
+	if err != nil {
+		return err
+	}
+
+	if category.MaxAmount != nil && request.Amount > *category.MaxAmount {
+		return fmt.Errorf(
+			"%w: a %s request may be at most %.2f, got %.2f", ErrCategoryLimit, category.Code, *category.MaxAmount, request.Amount,
+		)
+	}
+
+	if category.MonthlyCap != nil {
+		date := request.ExpenseDate
+		from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		claimed, err := s.categoryRepo.GetMonthlyTotal(ctx, request.EmployeeID, category.Code, from, from.AddDate(0, 1, 0), request.ID)
+		if err != nil {
+			return err
+		}
+		if claimed+request.Amount > *category.MonthlyCap {
+			return fmt.Errorf(
+				"%w: %.2f of the monthly %s cap of %.2f is already claimed for %s, got %.2f",
+				ErrCategoryLimit, claimed, category.Code, *category.MonthlyCap, from.Format("2006-01"), request.Amount,
+			)
+		}
+	}
+	return nil
+}
+
+// checkReceipt makes sure a request of a category requiring receipts has an attachment.
+// Requests whose category has left the registry are not held back.
+func (s *ExpenseService) checkReceipt(ctx context.Context, request *models.ExpenseRequest) error {
+	category, err := s.categoryRepo.GetCategoryByCode(ctx, request.Category)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !category.ReceiptRequired {
+		return nil
+	}
+
+	attachments, err := s.attachmentRepo.GetAttachmentsByRequest(ctx, request.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+	if len(attachments) == 0 {
+		return ErrReceiptRequired
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrNoExchangeRate      = errors.New("no exchange rate")
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+)
+
+// ConvertDate tells on which date foreign amounts are converted to the base currency
+type ConvertDate string
+
+const (
+	// ConvertOnSubmission uses the rate of the day the request is sent to review
+	ConvertOnSubmission ConvertDate = "submission"
+	// ConvertOnApproval estimates on submission and charges budgets with the rate of the final approval day
+	ConvertOnApproval ConvertDate = "approval"
+)
+
+// CurrencyConfig describes when requests in foreign currencies are converted
+type CurrencyConfig struct {
+	ConvertOn ConvertDate
+}
+
+// NewCurrencyConfig validates currency conversion settings
+func NewCurrencyConfig(convertOn string) (CurrencyConfig, error) {
+	cfg := CurrencyConfig{ConvertOn: ConvertDate(convertOn)}
+	switch cfg.ConvertOn {
+	case ConvertOnSubmission, ConvertOnApproval:
+	default:
+		return cfg, fmt.Errorf("unknown exchange rate date %q", convertOn)
+	}
+	return cfg, nil
+}
+
+// ExchangeRateService maintains the exchange rates of foreign currencies to the base currency
+type ExchangeRateService struct {
+	db       *postgres.Client
+	rateRepo *repository.ExchangeRateRepository
+	audit    *AuditService
+}
+
+func NewExchangeRateService(
+	db *postgres.Client, rateRepo *repository.ExchangeRateRepository, audit *AuditService,
+) *ExchangeRateService {
+	return &ExchangeRateService{db: db, rateRepo: rateRepo, audit: audit}
+}
+
+// ListExchangeRates gets the rates matching the filter, latest first
+func (s *ExchangeRateService) ListExchangeRates(ctx context.Context, dto *models.ListExchangeRatesDTO) ([]models.ExchangeRate, error) {
+	var from, to *time.Time
+	for _, date := range []struct {
+		value  string
+		target **time.Time
+	}{{dto.From, &from}, {dto.To, &to}} {
+		if date.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, date.value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+		}
+		*date.target = &parsed
+	}
+	return s.rateRepo.ListExchangeRates(ctx, dto.Currency, from, to)
+}
+
+// SetExchangeRate adds or corrects the rate of a currency on a date.
+// Requests already converted keep their rate.
+func (s *ExchangeRateService) SetExchangeRate(
+	ctx context.Context, dto *models.SetExchangeRateDTO, actorID uint,
+) (*models.ExchangeRate, error) {
+	rate, err := newExchangeRate(dto.Currency, dto.Date, dto.Rate, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		return s.upsertExchangeRate(ctx, rate, models.AuditExchangeRateSet, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// ImportExchangeRates sets the rates of a currency,date,rate CSV file with an optional header.
+// The file is imported whole or not at all.
+func (s *ExchangeRateService) ImportExchangeRates(ctx context.Context, file io.Reader, actorID uint) (int, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var rates []*models.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d: rate %q is not a number", ErrInvalidExchangeRate, line, record[2])
+		}
+		rate, err := newExchangeRate(strings.TrimSpace(record[0]), strings.TrimSpace(record[1]), value, actorID)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 {
+		return 0, fmt.Errorf("%w: the file has no rates", ErrInvalidExchangeRate)
+	}
+
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		for _, rate := range rates {
+			if err := s.upsertExchangeRate(ctx, rate, models.AuditExchangeRateImported, actorID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// upsertExchangeRate stores a rate and audits it with the rate it replaced
+func (s *ExchangeRateService) upsertExchangeRate(
+	ctx context.Context, rate *models.ExchangeRate, action models.AuditAction, actorID uint,
+) error {
+	before, err := s.rateRepo.GetExchangeRate(ctx, rate.Currency, rate.RateDate)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err = s.rateRepo.UpsertExchangeRate(ctx, rate); err != nil {
+		return err
+	}
+
+	event := AuditEvent{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: models.AuditEntityRate,
+		EntityID:   rate.ID,
+		After:      rate,
+	}
+	if before != nil {
+		event.Before = before
+	}
+	return s.audit.Record(ctx, event)
+}
+
+// newExchangeRate validates the rate of a foreign currency
+func newExchangeRate(currency, date string, value float64, actorID uint) (*models.ExchangeRate, error) {
+	currency = strings.ToUpper(currency)
+	if err := submitValidator.Var(currency, "iso4217"); err != nil {
+		return nil, fmt.Errorf("%w: unknown currency %q", ErrInvalidExchangeRate, currency)
+	}
+	if currency == models.BaseCurrency {
+		return nil, fmt.Errorf("%w: %s is the base currency", ErrInvalidExchangeRate, currency)
+	}
+	rateDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date %q", ErrInvalidExchangeRate, date)
+	}
+	if value <= 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil, fmt.Errorf("%w: rate must be positive, got %v", ErrInvalidExchangeRate, value)
+	}
+	return &models.ExchangeRate{Currency: currency, RateDate: rateDate, Rate: value, UpdatedBy: &actorID}, nil
+}
+
+// convertAmount converts the original amount of a request with the rate in effect on a date.
+// Without a rate strict fails with ErrNoExchangeRate and otherwise leaves the amount at zero.
+func (s *ExpenseService) convertAmount(ctx context.Context, request *models.ExpenseRequest, date time.Time, strict bool) error {
+	day := date.UTC().Truncate(24 * time.Hour)
+	if request.Currency == models.BaseCurrency {
+		one := 1.0
+		request.Amount, request.ExchangeRate, request.RateDate = request.OriginalAmount, &one, &day
+		return nil
+	}
+
+	rate, err := s.rateRepo.GetRateOn(ctx, request.Currency, day)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if strict {
+			return fmt.Errorf("%w for %s on %s", ErrNoExchangeRate, request.Currency, day.Format(time.DateOnly))
+		}
+		request.Amount, request.ExchangeRate, request.RateDate = 0, nil, nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	request.Amount = math.Round(request.OriginalAmount*rate.Rate*100) / 100
+	request.ExchangeRate, request.RateDate = &rate.Rate, &rate.RateDate
+	return nil
+}
+
+// checkConverted refuses a request without a positive amount in the base currency
+func checkConverted(request *models.ExpenseRequest) error {
+	if request.ExchangeRate == nil {
+		return fmt.Errorf("%w: %s amount is not converted", ErrNoExchangeRate, request.Currency)
+	}
+	if request.Amount <= 0 {
+		return fmt.Errorf("%w: amount in %s must be positive", ErrRequestIncomplete, models.BaseCurrency)
+	}
+	return nil
+}
+
+// convertOnSubmit converts a request sent to review with today's rate and saves it
+func (s *ExpenseService) convertOnSubmit(ctx context.Context, request *models.ExpenseRequest) error {
+	if err := s.convertAmount(ctx, request, time.Now(), true); err != nil {
+		return err
+	}
+	if err := checkConverted(request); err != nil {
+		return err
+	}
+	return s.expenseRepo.UpdateConversion(ctx, request)
+}
+
+// convertOnApproval converts a foreign request again with today's rate before it is charged.
+// It applies only with ConvertOnApproval and saves and audits the new conversion.
+// A request without a positive amount in the base currency is refused.
+func (s *ExpenseService) convertOnApproval(ctx context.Context, request *models.ExpenseRequest, actorID uint) error {
+	if s.currency.ConvertOn != ConvertOnApproval || request.Currency == models.BaseCurrency {
+		return checkConverted(request)
+	}
+
+	before := *request
+	if err := s.convertAmount(ctx, request, time.Now(), true); err != nil {
+		return err
+	}
+	if err := checkConverted(request); err != nil {
+		return err
+	}
+	if err := s.expenseRepo.UpdateConversion(ctx, request); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, AuditEvent{
+		ActorID:    &actorID,
+		Action:     models.AuditExpenseConverted,
+		EntityType: models.AuditEntityExpense,
+		EntityID:   request.ID,
+		Before: map[string]interface{}{
+			"amount": before.Amount, "exchangeRate": before.ExchangeRate, "rateDate": before.RateDate,
+		},
+		After: map[string]interface{}{
+			"currency": request.Currency, "originalAmount": request.OriginalAmount,
+			"amount": request.Amount, "exchangeRate": request.ExchangeRate, "rateDate": request.RateDate,
+		},
+	})
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDelegationNotFound = errors.New("delegation not found")
+	ErrInvalidDelegation  = errors.New("invalid delegation")
+	ErrNotDelegated       = errors.New("no active delegation covers this request")
+)
+
+// DelegationService lets reviewers hand their decisions to someone else while they are away
+type DelegationService struct {
+	db             *postgres.Client
+	delegationRepo *repository.DelegationRepository
+	userRepo       *repository.UserRepository
+	audit          *AuditService
+}
+
+func NewDelegationService(
+	db *postgres.Client,
+	delegationRepo *repository.DelegationRepository,
+	userRepo *repository.UserRepository,
+	audit *AuditService,
+) *DelegationService {
+	return &DelegationService{
+		db:             db,
+		delegationRepo: delegationRepo,
+		userRepo:       userRepo,
+		audit:          audit,
+	}
+}
+
+// CreateDelegation lets the delegate decide on requests on behalf of the delegator for a range of days
+func (s *DelegationService) CreateDelegation(ctx context.Context, dto *models.CreateDelegationDTO, delegatorID uint) (*models.Delegation, error) {
+	startsOn, err := time.Parse(time.DateOnly, dto.StartsOn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad start date", ErrInvalidDelegation)
+	}
+	endsOn, err := time.Parse(time.DateOnly, dto.EndsOn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad end date", ErrInvalidDelegation)
+	}
+	switch {
+	case dto.DelegateID == delegatorID:
+		return nil, fmt.Errorf("%w: you cannot delegate to yourself", ErrInvalidDelegation)
+	case endsOn.Before(startsOn):
+		return nil, fmt.Errorf("%w: the end date is before the start date", ErrInvalidDelegation)
+	case endsOn.Before(time.Now().UTC().Truncate(24 * time.Hour)):
+		return nil, fmt.Errorf("%w: the delegation would already be over", ErrInvalidDelegation)
+	}
+
+	delegate, err := s.userRepo.GetUserByID(ctx, dto.DelegateID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !delegate.IsActive) {
+		return nil, fmt.Errorf("%w: unknown or deactivated delegate", ErrInvalidDelegation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delegation := &models.Delegation{
+		DelegatorID: delegatorID,
+		DelegateID:  dto.DelegateID,
+		StartsOn:    startsOn,
+		EndsOn:      endsOn,
+		Category:    dto.Category,
+		MaxAmount:   dto.MaxAmount,
+	}
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.delegationRepo.CreateDelegation(ctx, delegation); err != nil {
+			return fmt.Errorf("failed to create delegation: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &delegatorID,
+			Action:     models.AuditDelegationCreated,
+			EntityType: models.AuditEntityDelegation,
+			EntityID:   delegation.ID,
+			After:      delegation,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delegation, nil
+}
+
+// ListDelegations gets the delegations the user gave or received
+func (s *DelegationService) ListDelegations(ctx context.Context, userID uint) ([]models.Delegation, error) {
+	return s.delegationRepo.ListUserDelegations(ctx, userID)
+}
+
+// RevokeDelegation ends a delegation early
+func (s *DelegationService) RevokeDelegation(ctx context.Context, id, userID uint) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.delegationRepo.GetDelegation(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDelegationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// Delegations of others stay hidden
+		if before.DelegatorID != userID {
+			return ErrDelegationNotFound
+		}
+
+		if err = s.delegationRepo.RevokeDelegation(ctx, id); err != nil {
+			return err
+		}
+		after, err := s.delegationRepo.GetDelegation(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditDelegationRevoked,
+			EntityType: models.AuditEntityDelegation,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+}
+
+// actingPermissions returns the permissions a reviewer decides on a request with: their own,
+// or, acting on behalf of someone, those of the delegator under an active delegation covering the request
+func (s *ExpenseService) actingPermissions(
+	ctx context.Context, request *models.ExpenseRequest, reviewerID uint, perms models.PermissionSet, onBehalfOf *uint,
+) (models.PermissionSet, error) {
+	if onBehalfOf == nil {
+		return perms, nil
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	delegations, err := s.delegationRepo.ListActiveDelegations(ctx, *onBehalfOf, reviewerID, today)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(delegations, func(d models.Delegation) bool { return d.Covers(request, today) }) {
+		return nil, ErrNotDelegated
+	}
+
+	delegator, err := s.userRepo.GetUserByID(ctx, *onBehalfOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegator: %w", err)
+	}
+	if !delegator.IsActive {
+		return nil, ErrNotDelegated
+	}
+	return s.roleRepo.GetUserPermissions(ctx, delegator.ID)
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDepartmentNotFound = errors.New("department not found")
+	ErrDepartmentExists   = errors.New("department with this code already exists")
+	ErrDepartmentCycle    = errors.New("a department cannot be placed below itself or its sub-departments")
+	ErrDepartmentInUse    = errors.New("department still has sub-departments, members or budgets")
+)
+
+// DepartmentService manages the department tree the company budget is split along
+type DepartmentService struct {
+	db             *postgres.Client
+	departmentRepo *repository.DepartmentRepository
+	audit          *AuditService
+}
+
+func NewDepartmentService(db *postgres.Client, departmentRepo *repository.DepartmentRepository, audit *AuditService) *DepartmentService {
+	return &DepartmentService{db: db, departmentRepo: departmentRepo, audit: audit}
+}
+
+// ListDepartments gets all departments
+func (s *DepartmentService) ListDepartments(ctx context.Context) ([]models.Department, error) {
+	return s.departmentRepo.ListDepartments(ctx)
+}
+
+// GetDepartment gets a department
+func (s *DepartmentService) GetDepartment(ctx context.Context, id uint) (*models.Department, error) {
+	return getDepartment(ctx, s.departmentRepo, id)
+}
+
+// CreateDepartment adds a department below the company or below another department
+func (s *DepartmentService) CreateDepartment(ctx context.Context, dto *models.DepartmentDTO, actorID uint) (*models.Department, error) {
+	department := &models.Department{Name: dto.Name, Code: dto.Code, ParentID: dto.ParentID}
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.checkDepartment(ctx, department); err != nil {
+			return err
+		}
+		if err := s.departmentRepo.CreateDepartment(ctx, department); err != nil {
+			return fmt.Errorf("failed to create department: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditDepartmentCreated,
+			EntityType: models.AuditEntityDepartment,
+			EntityID:   department.ID,
+			After:      department,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return department, nil
+}
+
+// UpdateDepartment renames a department or moves it elsewhere in the tree,
+// its sub-departments move along with it
+func (s *DepartmentService) UpdateDepartment(
+	ctx context.Context, id uint, dto *models.DepartmentDTO, actorID uint,
+) (*models.Department, error) {
+	var after *models.Department
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetDepartment(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		after = &models.Department{
+			ID:        id,
+			Name:      dto.Name,
+			Code:      dto.Code,
+			ParentID:  dto.ParentID,
+			CreatedAt: before.CreatedAt,
+		}
+		if err = s.checkDepartment(ctx, after); err != nil {
+			return err
+		}
+		if err = s.departmentRepo.UpdateDepartment(ctx, after); err != nil {
+			return fmt.Errorf("failed to update department: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditDepartmentUpdated,
+			EntityType: models.AuditEntityDepartment,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteDepartment deletes a department without sub-departments, members or budgets
+func (s *DepartmentService) DeleteDepartment(ctx context.Context, id, actorID uint) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetDepartment(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		inUse, err := s.departmentRepo.DepartmentInUse(ctx, id)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrDepartmentInUse
+		}
+
+		if err = s.departmentRepo.DeleteDepartment(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditDepartmentDeleted,
+			EntityType: models.AuditEntityDepartment,
+			EntityID:   id,
+			Before:     before,
+		})
+	})
+}
+
+// checkDepartment makes sure the code of a department is free and its parent exists
+// and does not lie below the department itself
+func (s *DepartmentService) checkDepartment(ctx context.Context, department *models.Department) error {
+	existing, err := s.departmentRepo.GetDepartmentByCode(ctx, department.Code)
+	if err == nil && existing.ID != department.ID {
+		return ErrDepartmentExists
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	if department.ParentID == nil {
+		return nil
+	}
+	if _, err = getDepartment(ctx, s.departmentRepo, *department.ParentID); errors.Is(err, ErrDepartmentNotFound) {
+		return fmt.Errorf("%w: unknown parent department", ErrDepartmentNotFound)
+	} else if err != nil {
+		return err
+	}
+
+	// A new department has no ID yet and cannot be anyone's ancestor
+	if department.ID == 0 {
+		return nil
+	}
+	path, err := s.departmentRepo.GetDepartmentPath(ctx, *department.ParentID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(path, department.ID) {
+		return ErrDepartmentCycle
+	}
+	return nil
+}
+
+// getDepartment gets a department, mapping a missing one to ErrDepartmentNotFound
+func getDepartment(ctx context.Context, departmentRepo *repository.DepartmentRepository, id uint) (*models.Department, error) {
+	department, err := departmentRepo.GetDepartment(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrDepartmentNotFound
+	}
+	return department, err
+}
+
+// lockChargedBudgets locks the budgets a request is charged to in a period.
+// They are locked from the company down so concurrent approvals use the same order.
+func (s *ExpenseService) lockChargedBudgets(
+	ctx context.Context, request *models.ExpenseRequest, period models.BudgetPeriod,
+) ([]*models.Budget, error) {
+	if _, err := s.budgetRepo.EnsureBudget(ctx, period, s.budgetConfig.DefaultTotal(period)); err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	company, err := s.budgetRepo.LockBudget(ctx, period, nil)
+	if err != nil {
+		return nil, fmt.Errorf("budget not found: %w", err)
+	}
+	budgets := []*models.Budget{company}
+
+	employee, err := s.userRepo.GetUserByID(ctx, request.EmployeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employee: %w", err)
+	}
+	if employee.DepartmentID == nil {
+		return budgets, nil
+	}
+
+	path, err := s.departmentRepo.GetDepartmentPath(ctx, *employee.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, departmentID := range path {
+		budget, err := s.budgetRepo.LockBudget(ctx, period, &departmentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, nil
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"curswork-trpo/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// titleSimilarity is the share of common words that makes two titles the same
+const titleSimilarity = 0.6
+
+const (
+	defaultSuspiciousPageSize = 20
+	maxSuspiciousPageSize     = 100
+)
+
+// DetectionConfig sets the windows, in days, of duplicate and split purchase detection.
+// Zero turns a check off.
+type DetectionConfig struct {
+	DuplicateDays int
+	SplitDays     int
+}
+
+// NewDetectionConfig validates detection settings
+func NewDetectionConfig(duplicateDays, splitDays int) (DetectionConfig, error) {
+	if duplicateDays < 0 || splitDays < 0 {
+		return DetectionConfig{}, fmt.Errorf("detection windows must not be negative, got %d and %d days", duplicateDays, splitDays)
+	}
+	return DetectionConfig{DuplicateDays: duplicateDays, SplitDays: splitDays}, nil
+}
+
+// splitLimit is an amount a purchase may be split to stay under
+type splitLimit struct {
+	name   string
+	amount float64
+}
+
+// flagRequest compares a request sent to review with recent ones and replaces its flags
+func (s *ExpenseService) flagRequest(ctx context.Context, request *models.ExpenseRequest) error {
+	days := max(s.detection.DuplicateDays, s.detection.SplitDays)
+	if days == 0 {
+		return nil
+	}
+
+	others, err := s.flagRepo.ListComparableRequests(
+		ctx, request, request.ExpenseDate.AddDate(0, 0, -days), request.ExpenseDate.AddDate(0, 0, days),
+	)
+	if err != nil {
+		return err
+	}
+
+	flags := []models.ExpenseFlag{}
+	if flag := s.duplicateFlag(request, others); flag != nil {
+		flags = append(flags, *flag)
+	}
+	flag, err := s.splitFlag(ctx, request, others)
+	if err != nil {
+		return err
+	}
+	if flag != nil {
+		flags = append(flags, *flag)
+	}
+
+	if err = s.flagRepo.ReplaceFlags(ctx, request.ID, flags); err != nil {
+		return err
+	}
+	request.Flags = flags
+	return nil
+}
+
+// duplicateFlag flags a request filed before under another ID
+func (s *ExpenseService) duplicateFlag(request *models.ExpenseRequest, others []models.ExpenseRequest) *models.ExpenseFlag {
+	if s.detection.DuplicateDays == 0 {
+		return nil
+	}
+
+	var related []uint
+	for _, other := range others {
+		if !sameVendor(&other, request) || other.Currency != request.Currency ||
+			!sameAmount(other.OriginalAmount, request.OriginalAmount) ||
+			daysApart(other.ExpenseDate, request.ExpenseDate) > s.detection.DuplicateDays {
+			continue
+		}
+		if other.ExpenseDate.Equal(request.ExpenseDate) || similarTitles(other.Title, request.Title) >= titleSimilarity {
+			related = append(related, other.ID)
+		}
+	}
+	if len(related) == 0 {
+		return nil
+	}
+
+	return &models.ExpenseFlag{
+		Kind: models.FlagDuplicate,
+		Reason: fmt.Sprintf(
+			"same vendor %q and amount %.2f %s as %s with a similar title or the same date",
+			request.Vendor, request.OriginalAmount, request.Currency, requestList(related),
+		),
+		RelatedIDs: related,
+	}
+}
+
+// splitFlag flags recent requests to a vendor that each keep within a limit but exceed it together
+func (s *ExpenseService) splitFlag(
+	ctx context.Context, request *models.ExpenseRequest, others []models.ExpenseRequest,
+) (*models.ExpenseFlag, error) {
+	if s.detection.SplitDays == 0 {
+		return nil, nil
+	}
+
+	var group []models.ExpenseRequest
+	for _, other := range others {
+		if other.EmployeeID == request.EmployeeID && sameVendor(&other, request) &&
+			daysApart(other.ExpenseDate, request.ExpenseDate) <= s.detection.SplitDays {
+			group = append(group, other)
+		}
+	}
+	if len(group) == 0 {
+		return nil, nil
+	}
+
+	limits, err := s.splitLimits(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for _, limit := range limits {
+		if request.Amount > limit.amount {
+			continue
+		}
+
+		total := request.Amount
+		var related []uint
+		for _, other := range group {
+			if other.Amount <= limit.amount {
+				total += other.Amount
+				related = append(related, other.ID)
+			}
+		}
+		if len(related) > 0 && total > limit.amount {
+			return &models.ExpenseFlag{
+				Kind: models.FlagSplitPurchase,
+				Reason: fmt.Sprintf(
+					"together with %s to %q within %d days it makes %.2f, above the %s of %.2f each of them keeps within",
+					requestList(related), request.Vendor, s.detection.SplitDays, total, limit.name, limit.amount,
+				),
+				RelatedIDs: related,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// splitLimits gets the amounts that change how a request is handled, highest first
+func (s *ExpenseService) splitLimits(ctx context.Context, request *models.ExpenseRequest) ([]splitLimit, error) {
+	var limits []splitLimit
+	for _, level := range s.approvalChain {
+		if level.MinAmount > 0 {
+			limits = append(limits, splitLimit{name: fmt.Sprintf("approval level %q", level.Name), amount: level.MinAmount})
+		}
+	}
+	if s.duties.FourEyesAmount > 0 {
+		limits = append(limits, splitLimit{name: "four-eyes amount", amount: s.duties.FourEyesAmount})
+	}
+
+	category, err := s.categoryRepo.GetCategoryByCode(ctx, request.Category)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && category.MaxAmount != nil {
+		limits = append(limits, splitLimit{name: "category limit", amount: *category.MaxAmount})
+	}
+
+	rules, err := s.autoRepo.ListAutoApprovalRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.Category == nil || *rule.Category == request.Category {
+			limits = append(limits, splitLimit{name: fmt.Sprintf("auto-approval rule %q", rule.Name), amount: rule.MaxAmount})
+		}
+	}
+
+	sort.SliceStable(limits, func(i, j int) bool {
+		return limits[i].amount > limits[j].amount
+	})
+	return limits, nil
+}
+
+// ListSuspiciousRequests gets a page of flagged requests, most recently flagged first
+func (s *ExpenseService) ListSuspiciousRequests(ctx context.Context, dto *models.ListSuspiciousDTO) (*models.ExpensePage, error) {
+	limit := dto.Limit
+	if limit <= 0 {
+		limit = defaultSuspiciousPageSize
+	}
+	if limit > maxSuspiciousPageSize {
+		limit = maxSuspiciousPageSize
+	}
+
+	requests, total, err := s.flagRepo.ListFlaggedRequests(ctx, models.FlagKind(dto.Kind), limit, dto.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(requests))
+	for i := range requests {
+		ids[i] = requests[i].ID
+	}
+	flags, err := s.flagRepo.GetFlagsByRequests(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ExpensePage{Items: requests, Total: total}
+	if page.Items == nil {
+		page.Items = []models.ExpenseRequest{}
+	}
+	for i := range page.Items {
+		page.Items[i].Flags = flags[page.Items[i].ID]
+	}
+	return page, nil
+}
+
+func sameVendor(a, b *models.ExpenseRequest) bool {
+	return strings.EqualFold(strings.TrimSpace(a.Vendor), strings.TrimSpace(b.Vendor))
+}
+
+// sameAmount compares amounts to the cent
+func sameAmount(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
+
+func daysApart(a, b time.Time) int {
+	return int(math.Abs(a.Sub(b).Hours()) / 24)
+}
+
+// similarTitles is the share of words two titles have in common
+func similarTitles(a, b string) float64 {
+	wordsA, wordsB := titleWords(a), titleWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	common := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(wordsA)+len(wordsB)-common)
+}
+
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// requestList names requests for flag reasons, e.g. "requests #3, #7"
+func requestList(ids []uint) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = fmt.Sprintf("#%d", id)
+	}
+	if len(ids) == 1 {
+		return "request " + names[0]
+	}
+	return "requests " + strings.Join(names, ", ")
+}
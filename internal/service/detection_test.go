@@ -0,0 +1,150 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+func TestSimilarTitles(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Taxi to airport", "taxi to airport", 1},
+		{"Taxi to airport", "Taxi, to the airport!", 0.75},
+		{"Hotel Moscow", "Flight Moscow", 1.0 / 3},
+		{"Hotel", "Flight", 0},
+		{"", "Hotel", 0},
+		{"Обед с клиентом", "обед с клиентом", 1},
+		{"Room 101", "room 102", 1.0 / 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" / "+tt.b, func(t *testing.T) {
+			if got := similarTitles(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("similarTitles(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameAmount(t *testing.T) {
+	tests := []struct {
+		a, b float64
+		want bool
+	}{
+		{100, 100, true},
+		{100.004, 100, true},
+		{0.1 + 0.2, 0.3, true},
+		{100.01, 100, false},
+		{99.995, 100, true},
+	}
+	for _, tt := range tests {
+		if got := sameAmount(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameAmount(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDaysApart(t *testing.T) {
+	tests := []struct {
+		a, b time.Time
+		want int
+	}{
+		{date(2024, time.March, 1), date(2024, time.March, 1), 0},
+		{date(2024, time.March, 1), date(2024, time.March, 8), 7},
+		{date(2024, time.March, 8), date(2024, time.March, 1), 7},
+		{date(2024, time.February, 28), date(2024, time.March, 1), 2},
+		{date(2023, time.December, 31), date(2024, time.January, 30), 30},
+	}
+	for _, tt := range tests {
+		if got := daysApart(tt.a, tt.b); got != tt.want {
+			t.Errorf("daysApart(%s, %s) = %d, want %d", tt.a.Format(time.DateOnly), tt.b.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestRequestList(t *testing.T) {
+	tests := []struct {
+		ids  []uint
+		want string
+	}{
+		{[]uint{3}, "request #3"},
+		{[]uint{3, 7}, "requests #3, #7"},
+		{[]uint{1, 2, 10}, "requests #1, #2, #10"},
+	}
+	for _, tt := range tests {
+		if got := requestList(tt.ids); got != tt.want {
+			t.Errorf("requestList(%v) = %q, want %q", tt.ids, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicateFlag(t *testing.T) {
+	request := models.ExpenseRequest{
+		ID: 10, Title: "Taxi to airport", Vendor: "Yandex Go", Currency: "RUB",
+		OriginalAmount: 1500, ExpenseDate: date(2024, time.March, 10),
+	}
+	other := func(change func(*models.ExpenseRequest)) models.ExpenseRequest {
+		o := request
+		o.ID = 1
+		change(&o)
+		return o
+	}
+
+	tests := []struct {
+		name   string
+		days   int
+		others []models.ExpenseRequest
+		want   []uint
+	}{
+		{"same title other day", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.ExpenseDate = date(2024, time.March, 2)
+		})}, []uint{1}},
+		{"other title same day", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.Title = "Ride home"
+		})}, []uint{1}},
+		{"vendor in another case", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.Vendor = " yandex go "
+		})}, []uint{1}},
+		{"other title other day", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.Title, o.ExpenseDate = "Ride home", date(2024, time.March, 2)
+		})}, nil},
+		{"other amount", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.OriginalAmount = 1600
+		})}, nil},
+		{"other currency", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.Currency = "USD"
+		})}, nil},
+		{"other vendor", 30, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.Vendor = "Uber"
+		})}, nil},
+		{"outside window", 5, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {
+			o.ExpenseDate = date(2024, time.March, 2)
+		})}, nil},
+		{"check off", 0, []models.ExpenseRequest{other(func(o *models.ExpenseRequest) {})}, nil},
+		{"several", 30, []models.ExpenseRequest{
+			other(func(o *models.ExpenseRequest) {}),
+			other(func(o *models.ExpenseRequest) { o.ID = 2; o.Vendor = "Uber" }),
+			other(func(o *models.ExpenseRequest) { o.ID = 3; o.Title = "Taxi to the airport" }),
+		}, []uint{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ExpenseService{detection: DetectionConfig{DuplicateDays: tt.days}}
+			flag := s.duplicateFlag(&request, tt.others)
+			if tt.want == nil {
+				if flag != nil {
+					t.Errorf("duplicateFlag() = %+v, want none", flag)
+				}
+				return
+			}
+			if flag == nil || flag.Kind != models.FlagDuplicate || !slices.Equal(flag.RelatedIDs, tt.want) {
+				t.Errorf("duplicateFlag() = %+v, want a duplicate of %v", flag, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"curswork-trpo/internal/models"
+)
+
+// Segregation of duties rules, named in DutyViolation and in the audit log
+const (
+	DutySelfApproval = "self_approval"
+	DutyEditor       = "editor_approval"
+	DutyFourEyes     = "four_eyes"
+	DutyRepeat       = "repeat_approval"
+)
+
+// fourEyesStepName is the step added to chains too short for the four-eyes rule
+const fourEyesStepName = "second_approval"
+
+// DutiesPolicy configures segregation of duties on approvals.
+// Above FourEyesAmount a request needs two approvers. Zero turns that off.
+type DutiesPolicy struct {
+	FourEyesAmount float64
+}
+
+// NewDutiesPolicy validates segregation of duties settings
+func NewDutiesPolicy(fourEyesAmount float64) (DutiesPolicy, error) {
+	if fourEyesAmount < 0 {
+		return DutiesPolicy{}, fmt.Errorf("four-eyes amount must not be negative, got %.2f", fourEyesAmount)
+	}
+	return DutiesPolicy{FourEyesAmount: fourEyesAmount}, nil
+}
+
+// needsFourEyes tells whether a request needs two distinct approvers
+func (p DutiesPolicy) needsFourEyes(request *models.ExpenseRequest) bool {
+	return p.FourEyesAmount > 0 && request.Amount > p.FourEyesAmount
+}
+
+// DutyViolation reports an approval that would break segregation of duties
+type DutyViolation struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+func (e *DutyViolation) Error() string {
+	return "segregation of duties: " + e.Reason
+}
+
+// approvalStepsFor builds the approval chain of a request with the four-eyes rule applied
+func (s *ExpenseService) approvalStepsFor(request *models.ExpenseRequest) []models.ApprovalStep {
+	steps := s.approvalChain.StepsFor(request)
+	if len(steps) == 1 && s.duties.needsFourEyes(request) {
+		steps = append(steps, models.ApprovalStep{
+			RequestID: request.ID,
+			StepOrder: 2,
+			Name:      fourEyesStepName,
+			MinAmount: s.duties.FourEyesAmount,
+			Status:    models.ApprovalPending,
+		})
+	}
+	return steps
+}
+
+// checkDuties tells whether the reviewer may approve the current step of the request.
+// No one approves two steps of a request. Under a delegation the rules hold for the delegator too.
+func (s *ExpenseService) checkDuties(
+	ctx context.Context, request *models.ExpenseRequest, reviewerID uint, onBehalfOf *uint,
+) (*DutyViolation, error) {
+	deciders := []uint{reviewerID}
+	if onBehalfOf != nil {
+		deciders = append(deciders, *onBehalfOf)
+	}
+
+	if slices.Contains(deciders, request.EmployeeID) {
+		return &DutyViolation{Rule: DutySelfApproval, Reason: "you cannot approve your own request"}, nil
+	}
+
+	for _, decider := range deciders {
+		edited, err := s.revisionRepo.HasEdited(ctx, request.ID, decider)
+		if err != nil {
+			return nil, err
+		}
+		if edited {
+			return &DutyViolation{Rule: DutyEditor, Reason: "you cannot approve a request you have edited"}, nil
+		}
+	}
+
+	steps, err := s.approvalRepo.GetApprovalSteps(ctx, request.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval steps: %w", err)
+	}
+	for _, step := range steps {
+		if step.Status != models.ApprovalApproved || !approvedBy(step, deciders) {
+			continue
+		}
+		if s.duties.needsFourEyes(request) {
+			return &DutyViolation{
+				Rule: DutyFourEyes,
+				Reason: fmt.Sprintf(
+					"requests above %.2f need two different approvers, you have already approved step %q",
+					s.duties.FourEyesAmount, step.Name,
+				),
+			}, nil
+		}
+		return &DutyViolation{
+			Rule:   DutyRepeat,
+			Reason: fmt.Sprintf("each step needs another approver, you have already approved step %q", step.Name),
+		}, nil
+	}
+	return nil, nil
+}
+
+// approvedBy tells whether one of the users decided the step, themselves or through a delegate
+func approvedBy(step models.ApprovalStep, users []uint) bool {
+	return (step.ReviewerID != nil && slices.Contains(users, *step.ReviewerID)) ||
+		(step.OnBehalfOfID != nil && slices.Contains(users, *step.OnBehalfOfID))
+}
+
+// auditDutyViolation records a refused approval
+func (s *ExpenseService) auditDutyViolation(ctx context.Context, request *models.ExpenseRequest, reviewerID uint, violation *DutyViolation) error {
+	return s.audit.Record(ctx, AuditEvent{
+		ActorID:    &reviewerID,
+		Action:     models.AuditExpenseDutyViolation,
+		EntityType: models.AuditEntityExpense,
+		EntityID:   request.ID,
+		After:      violation,
+	})
+}
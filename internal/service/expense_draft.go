@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"curswork-trpo/internal/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrRequestIncomplete = errors.New("request is incomplete")
+
+// submitValidator checks a request against the binding rules of CreateExpenseRequestDTO,
+// so a submitted draft meets the same requirements as a request created directly
+var submitValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	return v
+}()
+
+// CreateDraftExpenseRequest saves a request with partial data
+// only and gets no approval chain until it is submitted
+func (s *ExpenseService) CreateDraftExpenseRequest(ctx context.Context, dto *models.CreateDraftExpenseDTO, employeeID uint) (*models.ExpenseRequest, error) {
+	// Both DTOs carry the same fields
+	full := models.CreateExpenseRequestDTO(*dto)
+	return s.createExpenseRequest(ctx, &full, employeeID, models.StatusDraft)
+}
+
+// validateSubmission makes sure every field required of a new request is filled in
+func validateSubmission(request *models.ExpenseRequest) error {
+	dto := models.CreateExpenseRequestDTO{
+		Title:       request.Title,
+		Category:    request.Category,
+		Amount:      request.OriginalAmount,
+		Currency:    request.Currency,
+		Vendor:      request.Vendor,
+		Description: request.Description,
+	}
+	if err := submitValidator.Struct(&dto); err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestIncomplete, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateExpenseRequest applies an edit of the owner to their request and keeps a revision
+// of the changed fields. Approvals given so far are discarded and the approval chain starts over.
+// An edit of a pending request refused by the policy rules returns a *PolicyViolation.
+func (s *ExpenseService) UpdateExpenseRequest(ctx context.Context, id, userID uint, dto *models.UpdateExpenseRequestDTO) (*models.ExpenseRequest, error) {
+	var updated *models.ExpenseRequest
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = CheckEdit(before, userID); err != nil {
+			return err
+		}
+
+		after := *before
+		changes, err := applyExpenseEdit(&after, dto)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			updated = before
+			return nil
+		}
+		// A new amount or currency is converted with the rate of today, pending requests need one to exist
+		_, amountChanged := changes["amount"]
+		_, currencyChanged := changes["currency"]
+		if amountChanged || currencyChanged {
+			if err = s.convertAmount(ctx, &after, time.Now(), after.Status == models.StatusPending); err != nil {
+				return err
+			}
+		}
+
+		var evaluation *models.PolicyEvaluation
+		if after.Status == models.StatusPending {
+			if evaluation, err = s.evaluatePolicies(ctx, &after, models.PolicyStageSubmit, userID); err != nil {
+				return err
+			}
+			if evaluation != nil && evaluation.Outcome == models.PolicyBlocked {
+				return &PolicyViolation{Results: evaluation.Results}
+			}
+		}
+
+		if err = s.expenseRepo.UpdateExpenseRequest(ctx, &after); err != nil {
+			return fmt.Errorf("failed to update request: %w", err)
+		}
+		if err = s.revisionRepo.CreateRevision(ctx, &models.ExpenseRevision{
+			RequestID: id,
+			Revision:  after.Revision,
+			ChangedBy: userID,
+			Changes:   changes,
+		}); err != nil {
+			return fmt.Errorf("failed to save revision: %w", err)
+		}
+
+		// The chain depends on the amount, and reviewers have to see the new content anyway.
+		// Drafts and returned requests get theirs on submit with the category check, flags and score.
+		if after.Status == models.StatusPending {
+			if err = s.checkCategory(ctx, &after); err != nil {
+				return err
+			}
+			if evaluation != nil {
+				evaluation.Revision = after.Revision
+				if err = s.policyRepo.CreatePolicyEvaluation(ctx, evaluation); err != nil {
+					return err
+				}
+			}
+			if err = s.flagRequest(ctx, &after); err != nil {
+				return err
+			}
+			if err = s.scoreRequest(ctx, &after); err != nil {
+				return err
+			}
+			if err = s.approvalRepo.DeleteApprovalSteps(ctx, id); err != nil {
+				return err
+			}
+			after.ApprovalSteps = s.approvalStepsFor(&after)
+			if err = s.approvalRepo.CreateApprovalSteps(ctx, after.ApprovalSteps); err != nil {
+				return fmt.Errorf("failed to create approval steps: %w", err)
+			}
+		}
+
+		updated = &after
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditExpenseUpdated,
+			EntityType: models.AuditEntityExpense,
+			EntityID:   id,
+			Before:     before,
+			After:      &after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// WithdrawExpenseRequest lets the owner cancel their request before it is decided
+func (s *ExpenseService) WithdrawExpenseRequest(ctx context.Context, id, userID uint, perms models.PermissionSet) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusWithdrawn, userID, perms); err != nil {
+			return err
+		}
+
+		if err = s.expenseRepo.SetExpenseRequestStatus(ctx, id, models.StatusWithdrawn); err != nil {
+			return fmt.Errorf("failed to withdraw request: %w", err)
+		}
+		if err = s.approvalRepo.SkipPendingApprovalSteps(ctx, id); err != nil {
+			return err
+		}
+
+		return s.auditStatusChange(ctx, userID, models.AuditExpenseWithdrawn, request)
+	})
+}
+
+// GetExpenseRevisions gets the edits of a request newer than since, visible to the owner and holders of expenses.view_all
+func (s *ExpenseService) GetExpenseRevisions(ctx context.Context, id, userID uint, perms models.PermissionSet, since int) ([]models.ExpenseRevision, error) {
+	request, err := s.expenseRepo.GetExpenseRequestByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckView(request, userID, perms); err != nil {
+		return nil, err
+	}
+
+	return s.revisionRepo.GetRevisions(ctx, id, since)
+}
+
+// applyExpenseEdit copies the given fields of dto onto request and returns what actually changed
+func applyExpenseEdit(request *models.ExpenseRequest, dto *models.UpdateExpenseRequestDTO) (map[string]models.FieldChange, error) {
+	changes := make(map[string]models.FieldChange)
+
+	setString := func(field string, target *string, value *string) {
+		if value != nil && *value != *target {
+			changes[field] = models.FieldChange{Old: *target, New: *value}
+			*target = *value
+		}
+	}
+	setString("title", &request.Title, dto.Title)
+	setString("category", &request.Category, dto.Category)
+	setString("vendor", &request.Vendor, dto.Vendor)
+	setString("description", &request.Description, dto.Description)
+
+	setString("currency", &request.Currency, dto.Currency)
+	if dto.Amount != nil && *dto.Amount != request.OriginalAmount {
+		changes["amount"] = models.FieldChange{Old: request.OriginalAmount, New: *dto.Amount}
+		request.OriginalAmount = *dto.Amount
+	}
+
+	if dto.ExpenseDate != nil {
+		expenseDate, err := time.Parse(time.DateOnly, *dto.ExpenseDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expense date: %w", err)
+		}
+		if old := request.ExpenseDate.Format(time.DateOnly); old != *dto.ExpenseDate {
+			changes["expenseDate"] = models.FieldChange{Old: old, New: *dto.ExpenseDate}
+			request.ExpenseDate = expenseDate
+		}
+	}
+
+	return changes, nil
+}
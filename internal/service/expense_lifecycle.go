@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+// ReturnExpenseRequest sends a pending request back to its owner for changes
+func (s *ExpenseService) ReturnExpenseRequest(ctx context.Context, id, reviewerID uint, perms models.PermissionSet, comments string) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusReturned, reviewerID, perms); err != nil {
+			return err
+		}
+
+		if err = s.approvalRepo.SkipPendingApprovalSteps(ctx, id); err != nil {
+			return err
+		}
+		if err = s.expenseRepo.UpdateExpenseRequestStatus(
+			ctx, id, reviewerID, nil, models.StatusReturned, comments,
+		); err != nil {
+			return fmt.Errorf("failed to return request: %w", err)
+		}
+
+		return s.auditStatusChange(ctx, reviewerID, models.AuditExpenseReturned, request)
+	})
+}
+
+// SubmitExpenseRequest sends a draft or returned request of the owner to review with a fresh approval chain.
+// The request has to be complete, keep within its category limits and carry any required receipt.
+// The amount is converted to the base currency with the rate of today before any limit is checked.
+// The policy rules of the submit stage are evaluated last and a refusal returns a *PolicyViolation.
+// Requests covered by an auto-approval rule are approved right away.
+func (s *ExpenseService) SubmitExpenseRequest(ctx context.Context, id, userID uint, perms models.PermissionSet) error {
+	var violation *PolicyViolation
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusPending, userID, perms); err != nil {
+			return err
+		}
+		if err = validateSubmission(request); err != nil {
+			return err
+		}
+		if err = s.convertOnSubmit(ctx, request); err != nil {
+			return err
+		}
+		if err = s.checkCategory(ctx, request); err != nil {
+			return err
+		}
+		if err = s.checkReceipt(ctx, request); err != nil {
+			return err
+		}
+		if violation, err = s.applyPolicies(ctx, request, models.PolicyStageSubmit, userID); err != nil {
+			return err
+		}
+		if violation != nil {
+			return nil
+		}
+		if err = s.flagRequest(ctx, request); err != nil {
+			return err
+		}
+		if err = s.scoreRequest(ctx, request); err != nil {
+			return err
+		}
+
+		if err = s.approvalRepo.DeleteApprovalSteps(ctx, id); err != nil {
+			return err
+		}
+		if err = s.approvalRepo.CreateApprovalSteps(ctx, s.approvalStepsFor(request)); err != nil {
+			return fmt.Errorf("failed to create approval steps: %w", err)
+		}
+		if err = s.expenseRepo.SetExpenseRequestStatus(ctx, id, models.StatusPending); err != nil {
+			return fmt.Errorf("failed to submit request: %w", err)
+		}
+
+		return s.auditStatusChange(ctx, userID, models.AuditExpenseSubmitted, request)
+	})
+	if err != nil {
+		return err
+	}
+	if violation != nil {
+		return violation
+	}
+
+	// The submitted request stays with reviewers when auto-approval fails
+	if _, err = s.autoApprove(ctx, id); err != nil {
+		log.Printf("auto-approval of expense request %d: %v", id, err)
+	}
+	return nil
+}
+
+// SchedulePayment puts an approved request on the payment schedule for a date (YYYY-MM-DD)
+func (s *ExpenseService) SchedulePayment(ctx context.Context, id, userID uint, perms models.PermissionSet, paymentDate string) error {
+	date, err := time.Parse(time.DateOnly, paymentDate)
+	if err != nil {
+		return fmt.Errorf("invalid payment date: %w", err)
+	}
+
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusScheduledPayment, userID, perms); err != nil {
+			return err
+		}
+
+		if err = s.expenseRepo.SchedulePayment(ctx, id, date); err != nil {
+			return fmt.Errorf("failed to schedule payment: %w", err)
+		}
+
+		return s.auditStatusChange(ctx, userID, models.AuditExpenseScheduled, request)
+	})
+}
+
+// MarkExpensePaid records that a scheduled request has been paid out
+func (s *ExpenseService) MarkExpensePaid(ctx context.Context, id, userID uint, perms models.PermissionSet) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusPaid, userID, perms); err != nil {
+			return err
+		}
+
+		if err = s.expenseRepo.MarkPaid(ctx, id); err != nil {
+			return fmt.Errorf("failed to mark request paid: %w", err)
+		}
+
+		return s.auditStatusChange(ctx, userID, models.AuditExpensePaid, request)
+	})
+}
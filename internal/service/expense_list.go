@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+const defaultExpensePageSize = 20
+
+var (
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrInvalidExpenseList = errors.New("invalid expense list query")
+)
+
+// expenseCursor is the encoded form of models.ExpenseCursor
+type expenseCursor struct {
+	Sort  models.ExpenseSort `json:"s"`
+	Desc  bool               `json:"d"`
+	Value string             `json:"v"`
+	ID    uint               `json:"id"`
+}
+
+// ListExpenseRequests gets a page of expense requests.
+// Without expenses.view_all users only see their own requests
+// and may filter by employee.
+func (s *ExpenseService) ListExpenseRequests(ctx context.Context, dto *models.ListExpensesDTO, userID uint, perms models.PermissionSet) (*models.ExpensePage, error) {
+	filter, err := expenseFilter(dto, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !perms.Has(models.PermExpensesViewAll) {
+		filter.EmployeeID = &userID
+	}
+
+	requests, total, err := s.expenseRepo.ListExpenseRequests(ctx, *filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ExpensePage{Items: requests, Total: total}
+	if page.Items == nil {
+		page.Items = []models.ExpenseRequest{}
+	}
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		if page.NextCursor, err = encodeExpenseCursor(filter, &page.Items[filter.Limit-1]); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// expenseFilter validates list parameters, by default the newest requests come first
+func expenseFilter(dto *models.ListExpensesDTO, userID uint) (*models.ExpenseFilter, error) {
+	filter := &models.ExpenseFilter{
+		Status:     dto.Status,
+		Category:   dto.Category,
+		Vendor:     dto.Vendor,
+		EmployeeID: dto.EmployeeID,
+		ReviewerID: dto.ReviewerID,
+		MinAmount:  dto.MinAmount,
+		MaxAmount:  dto.MaxAmount,
+		ViewerID:   userID,
+		Sort:       models.ExpenseSort(dto.Sort),
+		Desc:       dto.Order != "asc",
+		Limit:      dto.Limit,
+	}
+	if filter.Sort == "" {
+		filter.Sort = models.SortByCreated
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultExpensePageSize
+	}
+
+	for _, date := range []struct {
+		value  string
+		target **time.Time
+	}{{dto.From, &filter.From}, {dto.To, &filter.To}} {
+		if date.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, date.value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExpenseList, err)
+		}
+		*date.target = &parsed
+	}
+
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, fmt.Errorf("%w: minAmount is greater than maxAmount", ErrInvalidExpenseList)
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidExpenseList)
+	}
+
+	if dto.Cursor != "" {
+		after, err := decodeExpenseCursor(dto.Cursor, filter)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+	return filter, nil
+}
+
+func encodeExpenseCursor(filter *models.ExpenseFilter, last *models.ExpenseRequest) (string, error) {
+	cursor := expenseCursor{Sort: filter.Sort, Desc: filter.Desc, ID: last.ID}
+
+	switch filter.Sort {
+	case models.SortByAmount:
+		cursor.Value = strconv.FormatFloat(last.Amount, 'f', -1, 64)
+	case models.SortByReviewed:
+		var reviewedAt time.Time
+		if last.ReviewedAt != nil {
+			reviewedAt = *last.ReviewedAt
+		}
+		cursor.Value = reviewedAt.Format(time.RFC3339Nano)
+	case models.SortByAnomaly:
+		// Unscored requests sort as scored -1, see the repository
+		score := -1.0
+		if last.AnomalyScore != nil {
+			score = *last.AnomalyScore
+		}
+		cursor.Value = strconv.FormatFloat(score, 'f', -1, 64)
+	default:
+		cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeExpenseCursor reads a cursor made for the same sort and order
+func decodeExpenseCursor(encoded string, filter *models.ExpenseFilter) (*models.ExpenseCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor expenseCursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+		return nil, fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	after := &models.ExpenseCursor{ID: cursor.ID}
+	if cursor.Sort == models.SortByAmount || cursor.Sort == models.SortByAnomaly {
+		after.Value, err = strconv.ParseFloat(cursor.Value, 64)
+	} else {
+		after.Value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return after, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+func TestExpenseCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 5, 10, 30, 15, 123456789, time.UTC)
+	reviewedAt := time.Date(2024, time.March, 6, 9, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	score := 73.25
+
+	tests := []struct {
+		name      string
+		sort      models.ExpenseSort
+		desc      bool
+		last      models.ExpenseRequest
+		wantValue interface{}
+	}{
+		{"created", models.SortByCreated, true, models.ExpenseRequest{ID: 7, CreatedAt: createdAt}, createdAt},
+		{"amount", models.SortByAmount, false, models.ExpenseRequest{ID: 8, Amount: 1234.56}, 1234.56},
+		{"reviewed", models.SortByReviewed, true, models.ExpenseRequest{ID: 9, ReviewedAt: &reviewedAt}, reviewedAt},
+		{"not reviewed", models.SortByReviewed, false, models.ExpenseRequest{ID: 10}, time.Time{}},
+		{"anomaly", models.SortByAnomaly, true, models.ExpenseRequest{ID: 11, AnomalyScore: &score}, score},
+		{"not scored", models.SortByAnomaly, true, models.ExpenseRequest{ID: 12}, -1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &models.ExpenseFilter{Sort: tt.sort, Desc: tt.desc}
+			encoded, err := encodeExpenseCursor(filter, &tt.last)
+			if err != nil {
+				t.Fatalf("encodeExpenseCursor() = %v", err)
+			}
+
+			after, err := decodeExpenseCursor(encoded, filter)
+			if err != nil {
+				t.Fatalf("decodeExpenseCursor() = %v", err)
+			}
+			if after.ID != tt.last.ID {
+				t.Errorf("ID = %d, want %d", after.ID, tt.last.ID)
+			}
+			switch want := tt.wantValue.(type) {
+			case time.Time:
+				if got, ok := after.Value.(time.Time); !ok || !got.Equal(want) {
+					t.Errorf("Value = %v, want %v", after.Value, want)
+				}
+			default:
+				if after.Value != want {
+					t.Errorf("Value = %v, want %v", after.Value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeExpenseCursorRejects(t *testing.T) {
+	filter := &models.ExpenseFilter{Sort: models.SortByAmount, Desc: true}
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	valid, err := encodeExpenseCursor(filter, &models.ExpenseRequest{ID: 1, Amount: 10})
+	if err != nil {
+		t.Fatalf("encodeExpenseCursor() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		filter  *models.ExpenseFilter
+	}{
+		{"not base64", "!!!", filter},
+		{"not json", encode("amount"), filter},
+		{"other sort", valid, &models.ExpenseFilter{Sort: models.SortByCreated, Desc: true}},
+		{"other order", valid, &models.ExpenseFilter{Sort: models.SortByAmount}},
+		{"bad number", encode(`{"s":"amount","d":true,"v":"ten","id":1}`), filter},
+		{"bad time", encode(`{"s":"created","d":true,"v":"yesterday","id":1}`),
+			&models.ExpenseFilter{Sort: models.SortByCreated, Desc: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeExpenseCursor(tt.encoded, tt.filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeExpenseCursor() = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"curswork-trpo/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrUserExists         = errors.New("user with this email already exists")
+	ErrInvitationRequired = errors.New("registration is by invitation only")
+	ErrInvalidInvitation  = errors.New("invitation is invalid, used or expired")
+)
+
+// RegistrationConfig tells who may register and how long invitations last
+type RegistrationConfig struct {
+	Mode          models.RegistrationMode
+	InvitationTTL time.Duration
+}
+
+// NewRegistrationConfig validates registration settings
+func NewRegistrationConfig(mode string, invitationTTL time.Duration) (RegistrationConfig, error) {
+	config := RegistrationConfig{Mode: models.RegistrationMode(mode), InvitationTTL: invitationTTL}
+	switch config.Mode {
+	case models.RegistrationOpen, models.RegistrationInvite:
+	default:
+		return config, fmt.Errorf("unknown registration mode %q, expected open or invite", mode)
+	}
+	if invitationTTL <= 0 {
+		return config, errors.New("invitation lifetime must be positive")
+	}
+	return config, nil
+}
+
+// CreateInvitation invites an email to register with a role and returns the invitation with its token.
+// invitedBy is nil for invitations made from the command line.
+func (s *UserService) CreateInvitation(ctx context.Context, dto *models.CreateInvitationDTO, invitedBy *uint) (*models.InvitationResponse, error) {
+	if err := checkRoles(ctx, s.roleRepo, dto.Role); err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken(32, base64URL)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{
+		Email:     dto.Email,
+		Role:      dto.Role,
+		TokenHash: hashToken(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().UTC().Add(s.registration.InvitationTTL),
+	}
+
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.invitationRepo.CreateInvitation(ctx, invitation); err != nil {
+			return fmt.Errorf("failed to create invitation: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    invitedBy,
+			Action:     models.AuditInvitationCreated,
+			EntityType: models.AuditEntityInvitation,
+			EntityID:   invitation.ID,
+			After:      invitation,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InvitationResponse{Invitation: *invitation, Token: token}, nil
+}
+
+// ListInvitations gets all invitations, newest first
+func (s *UserService) ListInvitations(ctx context.Context) ([]models.Invitation, error) {
+	return s.invitationRepo.ListInvitations(ctx)
+}
+
+// acceptInvitation locks the invitation of a token for the running transaction
+// and makes sure it is unused, unexpired and made out to the email
+func (s *UserService) acceptInvitation(ctx context.Context, token, email string) (*models.Invitation, error) {
+	invitation, err := s.invitationRepo.LockInvitationByToken(ctx, hashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.UsedAt != nil || time.Now().UTC().After(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+	if !strings.EqualFold(invitation.Email, email) {
+		return nil, fmt.Errorf("%w: it was issued for another email", ErrInvalidInvitation)
+	}
+	return invitation, nil
+}
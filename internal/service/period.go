@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+// ChargeDate tells which date of a request selects the budget period it is charged to
+type ChargeDate string
+
+const (
+	ChargeOnExpenseDate  ChargeDate = "expense"
+	ChargeOnApprovalDate ChargeDate = "approval"
+)
+
+// BudgetConfig describes how budget periods are laid out and which one an expense is charged to
+type BudgetConfig struct {
+	PeriodType          models.BudgetPeriodType
+	FiscalYearStart     time.Month
+	ChargeOn            ChargeDate
+	DefaultMonthlyTotal float64
+}
+
+// NewBudgetConfig validates budget period settings
+func NewBudgetConfig(periodType string, fiscalYearStart int, chargeOn string, defaultMonthlyTotal float64) (BudgetConfig, error) {
+	cfg := BudgetConfig{
+		PeriodType:          models.BudgetPeriodType(periodType),
+		FiscalYearStart:     time.Month(fiscalYearStart),
+		ChargeOn:            ChargeDate(chargeOn),
+		DefaultMonthlyTotal: defaultMonthlyTotal,
+	}
+
+	switch cfg.PeriodType {
+	case models.PeriodMonthly, models.PeriodQuarterly, models.PeriodFiscalYear:
+	default:
+		return cfg, fmt.Errorf("unknown budget period type %q", periodType)
+	}
+
+	if cfg.FiscalYearStart < time.January || cfg.FiscalYearStart > time.December {
+		return cfg, fmt.Errorf("fiscal year start month %d is out of range", fiscalYearStart)
+	}
+
+	switch cfg.ChargeOn {
+	case ChargeOnExpenseDate, ChargeOnApprovalDate:
+	default:
+		return cfg, fmt.Errorf("unknown budget charge date %q", chargeOn)
+	}
+
+	if defaultMonthlyTotal < 0 {
+		return cfg, fmt.Errorf("default monthly budget %.2f is negative", defaultMonthlyTotal)
+	}
+
+	return cfg, nil
+}
+
+// Resolve returns the budget period containing date.
+// Quarters and fiscal years start at FiscalYearStart.
+func (c BudgetConfig) Resolve(date time.Time) models.BudgetPeriod {
+	months := c.PeriodType.Months()
+
+	// Months elapsed since the start of the fiscal year containing date
+	offset := (int(date.Month()) - int(c.FiscalYearStart) + 12) % 12
+	startOffset := offset - offset%months
+
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -offset+startOffset, 0)
+	end := start.AddDate(0, months, -1)
+
+	return models.BudgetPeriod{
+		Type:  c.PeriodType,
+		Year:  start.Year(),
+		Month: int(start.Month()),
+		Start: start,
+		End:   end,
+	}
+}
+
+// ResolveMonth returns the budget period containing the given month
+func (c BudgetConfig) ResolveMonth(year, month int) models.BudgetPeriod {
+	return c.Resolve(time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC))
+}
+
+// ChargePeriod returns the budget period an expense is charged to when approved at approvedAt
+func (c BudgetConfig) ChargePeriod(request *models.ExpenseRequest, approvedAt time.Time) models.BudgetPeriod {
+	if c.ChargeOn == ChargeOnApprovalDate || request.ExpenseDate.IsZero() {
+		return c.Resolve(approvedAt)
+	}
+	return c.Resolve(request.ExpenseDate)
+}
+
+// DefaultTotal returns the total given to a period created without an explicit budget
+func (c BudgetConfig) DefaultTotal(period models.BudgetPeriod) float64 {
+	return c.DefaultMonthlyTotal * float64(period.Type.Months())
+}
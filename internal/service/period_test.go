@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"curswork-trpo/internal/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBudgetConfigResolve(t *testing.T) {
+	tests := []struct {
+		name            string
+		periodType      models.BudgetPeriodType
+		fiscalYearStart time.Month
+		date            time.Time
+		wantStart       time.Time
+		wantEnd         time.Time
+	}{
+		{"month", models.PeriodMonthly, time.January, date(2024, time.March, 15), date(2024, time.March, 1), date(2024, time.March, 31)},
+		{"leap february", models.PeriodMonthly, time.April, date(2024, time.February, 10), date(2024, time.February, 1), date(2024, time.February, 29)},
+		{"last day of month", models.PeriodMonthly, time.January, time.Date(2024, time.April, 30, 23, 59, 0, 0, time.UTC),
+			date(2024, time.April, 1), date(2024, time.April, 30)},
+		{"calendar quarter", models.PeriodQuarterly, time.January, date(2024, time.May, 20), date(2024, time.April, 1), date(2024, time.June, 30)},
+		{"first day of quarter", models.PeriodQuarterly, time.January, date(2024, time.October, 1), date(2024, time.October, 1), date(2024, time.December, 31)},
+		{"fiscal quarter across new year", models.PeriodQuarterly, time.February, date(2025, time.January, 5),
+			date(2024, time.November, 1), date(2025, time.January, 31)},
+		{"fiscal quarter before fiscal start", models.PeriodQuarterly, time.April, date(2024, time.February, 10),
+			date(2024, time.January, 1), date(2024, time.March, 31)},
+		{"calendar year", models.PeriodFiscalYear, time.January, date(2024, time.December, 31), date(2024, time.January, 1), date(2024, time.December, 31)},
+		{"fiscal year started last year", models.PeriodFiscalYear, time.April, date(2024, time.February, 10),
+			date(2023, time.April, 1), date(2024, time.March, 31)},
+		{"fiscal year starts on date", models.PeriodFiscalYear, time.October, date(2024, time.October, 1),
+			date(2024, time.October, 1), date(2025, time.September, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := BudgetConfig{PeriodType: tt.periodType, FiscalYearStart: tt.fiscalYearStart}
+			period := cfg.Resolve(tt.date)
+
+			if !period.Start.Equal(tt.wantStart) || !period.End.Equal(tt.wantEnd) {
+				t.Errorf("Resolve(%s) = %s..%s, want %s..%s", tt.date.Format(time.DateOnly),
+					period.Start.Format(time.DateOnly), period.End.Format(time.DateOnly),
+					tt.wantStart.Format(time.DateOnly), tt.wantEnd.Format(time.DateOnly))
+			}
+			if period.Type != tt.periodType || period.Year != tt.wantStart.Year() || period.Month != int(tt.wantStart.Month()) {
+				t.Errorf("Resolve(%s) = %s %d-%02d, want %s %d-%02d", tt.date.Format(time.DateOnly),
+					period.Type, period.Year, period.Month, tt.periodType, tt.wantStart.Year(), tt.wantStart.Month())
+			}
+		})
+	}
+}
+
+func TestBudgetConfigChargePeriod(t *testing.T) {
+	approvedAt := date(2024, time.July, 2)
+	tests := []struct {
+		name        string
+		chargeOn    ChargeDate
+		expenseDate time.Time
+		wantStart   time.Time
+	}{
+		{"expense date", ChargeOnExpenseDate, date(2024, time.June, 28), date(2024, time.June, 1)},
+		{"approval date", ChargeOnApprovalDate, date(2024, time.June, 28), date(2024, time.July, 1)},
+		{"no expense date", ChargeOnExpenseDate, time.Time{}, date(2024, time.July, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := BudgetConfig{PeriodType: models.PeriodMonthly, FiscalYearStart: time.January, ChargeOn: tt.chargeOn}
+			period := cfg.ChargePeriod(&models.ExpenseRequest{ExpenseDate: tt.expenseDate}, approvedAt)
+			if !period.Start.Equal(tt.wantStart) {
+				t.Errorf("ChargePeriod() starts %s, want %s",
+					period.Start.Format(time.DateOnly), tt.wantStart.Format(time.DateOnly))
+			}
+		})
+	}
+}
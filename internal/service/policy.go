@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrPolicyRuleNotFound = errors.New("policy rule not found")
+	ErrInvalidPolicyRule  = errors.New("invalid policy rule")
+)
+
+// Request fields policy conditions compare, by the kind of their values
+var (
+	policyStringFields = []string{"title", "category", "vendor", "description", "currency"}
+	policyNumberFields = []string{"amount", "originalAmount", "descriptionLength", "attachmentCount"}
+	policyStringOps    = []string{"eq", "ne", "in", "notIn", "contains"}
+	policyNumberOps    = []string{"eq", "ne", "gt", "gte", "lt", "lte"}
+)
+
+// PolicyService manages the policy rules evaluated against expense requests
+type PolicyService struct {
+	db         *postgres.Client
+	policyRepo *repository.PolicyRepository
+	audit      *AuditService
+}
+
+func NewPolicyService(db *postgres.Client, policyRepo *repository.PolicyRepository, audit *AuditService) *PolicyService {
+	return &PolicyService{db: db, policyRepo: policyRepo, audit: audit}
+}
+
+// ListPolicyRules gets the active rules, or all of them with includeInactive
+func (s *PolicyService) ListPolicyRules(ctx context.Context, includeInactive bool) ([]models.PolicyRule, error) {
+	return s.policyRepo.ListPolicyRules(ctx, includeInactive)
+}
+
+// GetPolicyRule gets the current version of a rule
+func (s *PolicyService) GetPolicyRule(ctx context.Context, id uint) (*models.PolicyRule, error) {
+	rule, err := s.policyRepo.GetPolicyRule(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPolicyRuleNotFound
+	}
+	return rule, err
+}
+
+// GetPolicyRuleVersions gets every version of a rule, latest first
+func (s *PolicyService) GetPolicyRuleVersions(ctx context.Context, id uint) ([]models.PolicyRuleVersion, error) {
+	if _, err := s.GetPolicyRule(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.policyRepo.GetPolicyRuleVersions(ctx, id)
+}
+
+// CreatePolicyRule adds a rule
+func (s *PolicyService) CreatePolicyRule(ctx context.Context, dto *models.PolicyRuleDTO, actorID uint) (*models.PolicyRule, error) {
+	rule := &models.PolicyRule{}
+	applyPolicyRuleDTO(rule, dto, actorID)
+	if err := validatePolicyRule(rule); err != nil {
+		return nil, err
+	}
+
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.policyRepo.CreatePolicyRule(ctx, rule); err != nil {
+			return fmt.Errorf("failed to create policy rule: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditPolicyRuleCreated,
+			EntityType: models.AuditEntityPolicyRule,
+			EntityID:   rule.ID,
+			After:      rule,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdatePolicyRule changes a rule into its next version and keeps the earlier ones
+func (s *PolicyService) UpdatePolicyRule(
+	ctx context.Context, id uint, dto *models.PolicyRuleDTO, actorID uint,
+) (*models.PolicyRule, error) {
+	var after *models.PolicyRule
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetPolicyRule(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updated := *before
+		applyPolicyRuleDTO(&updated, dto, actorID)
+		if err = validatePolicyRule(&updated); err != nil {
+			return err
+		}
+		if err = s.policyRepo.UpdatePolicyRule(ctx, &updated); err != nil {
+			return fmt.Errorf("failed to update policy rule: %w", err)
+		}
+
+		after = &updated
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditPolicyRuleUpdated,
+			EntityType: models.AuditEntityPolicyRule,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// applyPolicyRuleDTO copies the editable fields onto a rule
+func applyPolicyRuleDTO(rule *models.PolicyRule, dto *models.PolicyRuleDTO, actorID uint) {
+	rule.Name = dto.Name
+	rule.Description = dto.Description
+	rule.Stage = dto.Stage
+	rule.When = dto.When
+	rule.Effect = dto.Effect
+	rule.Require = dto.Require
+	rule.Message = dto.Message
+	rule.IsActive = dto.IsActive == nil || *dto.IsActive
+	rule.UpdatedBy = &actorID
+}
+
+// validatePolicyRule makes sure a rule can be evaluated
+func validatePolicyRule(rule *models.PolicyRule) error {
+	if (rule.Effect == models.PolicyRequire) != (rule.Require != nil) {
+		return fmt.Errorf("%w: require rules and only they need a require condition", ErrInvalidPolicyRule)
+	}
+	if err := validatePolicyCondition(rule.When, "when"); err != nil {
+		return err
+	}
+	return validatePolicyCondition(rule.Require, "require")
+}
+
+func validatePolicyCondition(condition *models.PolicyCondition, path string) error {
+	if condition == nil {
+		return nil
+	}
+
+	kinds := 0
+	for _, set := range []bool{condition.All != nil, condition.Any != nil, condition.Not != nil, condition.Field != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("%w: %s has to be exactly one of all, any, not or a field comparison", ErrInvalidPolicyRule, path)
+	}
+
+	switch {
+	case condition.All != nil, condition.Any != nil:
+		nested, name := condition.All, "all"
+		if condition.Any != nil {
+			nested, name = condition.Any, "any"
+		}
+		if len(nested) == 0 {
+			return fmt.Errorf("%w: %s.%s is empty", ErrInvalidPolicyRule, path, name)
+		}
+		for i := range nested {
+			if err := validatePolicyCondition(&nested[i], fmt.Sprintf("%s.%s[%d]", path, name, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case condition.Not != nil:
+		return validatePolicyCondition(condition.Not, path+".not")
+	}
+
+	switch {
+	case slices.Contains(policyStringFields, condition.Field):
+		if !slices.Contains(policyStringOps, condition.Op) {
+			return fmt.Errorf("%w: %s compares %s with unknown operator %q", ErrInvalidPolicyRule, path, condition.Field, condition.Op)
+		}
+		if condition.Op == "in" || condition.Op == "notIn" {
+			values, ok := condition.Value.([]interface{})
+			if !ok || len(values) == 0 {
+				return fmt.Errorf("%w: %s needs a non-empty list of strings", ErrInvalidPolicyRule, path)
+			}
+			for _, value := range values {
+				if _, ok = value.(string); !ok {
+					return fmt.Errorf("%w: %s needs a non-empty list of strings", ErrInvalidPolicyRule, path)
+				}
+			}
+		} else if _, ok := condition.Value.(string); !ok {
+			return fmt.Errorf("%w: %s needs a string value", ErrInvalidPolicyRule, path)
+		}
+	case slices.Contains(policyNumberFields, condition.Field):
+		if !slices.Contains(policyNumberOps, condition.Op) {
+			return fmt.Errorf("%w: %s compares %s with unknown operator %q", ErrInvalidPolicyRule, path, condition.Field, condition.Op)
+		}
+		if _, ok := condition.Value.(float64); !ok {
+			return fmt.Errorf("%w: %s needs a number value", ErrInvalidPolicyRule, path)
+		}
+	default:
+		return fmt.Errorf("%w: %s refers to unknown field %q", ErrInvalidPolicyRule, path, condition.Field)
+	}
+	return nil
+}
+
+// PolicyViolation reports a request refused by policy rules
+type PolicyViolation struct {
+	Results []models.PolicyResult `json:"results"`
+}
+
+func (e *PolicyViolation) Error() string {
+	messages := make([]string, 0, len(e.Results))
+	for _, result := range e.Results {
+		if result.Effect != models.PolicyWarn {
+			messages = append(messages, result.Message)
+		}
+	}
+	return "policy violation: " + strings.Join(messages, "; ")
+}
+
+// evaluatePolicies evaluates the active rules of a stage against a request.
+// It returns nil when there are no rules to evaluate.
+// A request not stored yet has no attachments, rules on attachmentCount see zero.
+func (s *ExpenseService) evaluatePolicies(
+	ctx context.Context, request *models.ExpenseRequest, stage models.PolicyStage, actorID uint,
+) (*models.PolicyEvaluation, error) {
+	rules, err := s.policyRepo.ListActivePolicyRules(ctx, stage)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var attachments []models.Attachment
+	if request.ID != 0 {
+		if attachments, err = s.attachmentRepo.GetAttachmentsByRequest(ctx, request.ID); err != nil {
+			return nil, fmt.Errorf("failed to get attachments: %w", err)
+		}
+	}
+
+	evaluation := &models.PolicyEvaluation{
+		RequestID: request.ID,
+		Stage:     stage,
+		Revision:  request.Revision,
+		ActorID:   &actorID,
+	}
+	judgePolicies(evaluation, rules, policyFacts(request, len(attachments)))
+	return evaluation, nil
+}
+
+// policyFacts gets the values of the request fields policy conditions compare
+func policyFacts(request *models.ExpenseRequest, attachmentCount int) map[string]interface{} {
+	return map[string]interface{}{
+		"title":             request.Title,
+		"category":          request.Category,
+		"vendor":            request.Vendor,
+		"description":       request.Description,
+		"amount":            request.Amount,
+		"currency":          request.Currency,
+		"originalAmount":    request.OriginalAmount,
+		"descriptionLength": float64(utf8.RuneCountInString(strings.TrimSpace(request.Description))),
+		"attachmentCount":   float64(attachmentCount),
+	}
+}
+
+// judgePolicies records the outcome of the rules on the facts of a request in the evaluation
+func judgePolicies(evaluation *models.PolicyEvaluation, rules []models.PolicyRule, facts map[string]interface{}) {
+	evaluation.Outcome = models.PolicyPassed
+	evaluation.Results = []models.PolicyResult{}
+	for _, rule := range rules {
+		if !policyMatches(rule.When, facts) {
+			continue
+		}
+		if rule.Effect == models.PolicyRequire && policyMatches(rule.Require, facts) {
+			continue
+		}
+
+		evaluation.Results = append(evaluation.Results, models.PolicyResult{
+			RuleID:  rule.ID,
+			Version: rule.Version,
+			Name:    rule.Name,
+			Effect:  rule.Effect,
+			Message: rule.Message,
+		})
+		switch {
+		case rule.Effect != models.PolicyWarn:
+			evaluation.Outcome = models.PolicyBlocked
+		case evaluation.Outcome == models.PolicyPassed:
+			evaluation.Outcome = models.PolicyWarned
+		}
+	}
+}
+
+// applyPolicies evaluates the rules of a stage against a request and stores the evaluation.
+// A refused request is reported with a *PolicyViolation that the caller still commits.
+func (s *ExpenseService) applyPolicies(
+	ctx context.Context, request *models.ExpenseRequest, stage models.PolicyStage, actorID uint,
+) (*PolicyViolation, error) {
+	evaluation, err := s.evaluatePolicies(ctx, request, stage, actorID)
+	if err != nil || evaluation == nil {
+		return nil, err
+	}
+	if err = s.policyRepo.CreatePolicyEvaluation(ctx, evaluation); err != nil {
+		return nil, err
+	}
+	if evaluation.Outcome == models.PolicyBlocked {
+		return &PolicyViolation{Results: evaluation.Results}, nil
+	}
+	return nil, nil
+}
+
+// policyMatches tells whether a condition holds for the facts of a request
+func policyMatches(condition *models.PolicyCondition, facts map[string]interface{}) bool {
+	switch {
+	case condition == nil:
+		return true
+	case condition.All != nil:
+		for i := range condition.All {
+			if !policyMatches(&condition.All[i], facts) {
+				return false
+			}
+		}
+		return true
+	case condition.Any != nil:
+		for i := range condition.Any {
+			if policyMatches(&condition.Any[i], facts) {
+				return true
+			}
+		}
+		return false
+	case condition.Not != nil:
+		return !policyMatches(condition.Not, facts)
+	}
+
+	switch fact := facts[condition.Field].(type) {
+	case string:
+		return compareStrings(fact, condition.Op, condition.Value)
+	case float64:
+		value, ok := condition.Value.(float64)
+		return ok && compareNumbers(fact, condition.Op, value)
+	}
+	return false
+}
+
+func compareStrings(fact, op string, value interface{}) bool {
+	if op == "in" || op == "notIn" {
+		values, _ := value.([]interface{})
+		found := slices.ContainsFunc(values, func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && strings.EqualFold(strings.TrimSpace(fact), strings.TrimSpace(s))
+		})
+		return found == (op == "in")
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	switch op {
+	case "eq":
+		return strings.EqualFold(strings.TrimSpace(fact), strings.TrimSpace(s))
+	case "ne":
+		return !strings.EqualFold(strings.TrimSpace(fact), strings.TrimSpace(s))
+	case "contains":
+		return strings.Contains(strings.ToLower(fact), strings.ToLower(s))
+	}
+	return false
+}
+
+func compareNumbers(fact float64, op string, value float64) bool {
+	switch op {
+	case "eq":
+		return fact == value
+	case "ne":
+		return fact != value
+	case "gt":
+		return fact > value
+	case "gte":
+		return fact >= value
+	case "lt":
+		return fact < value
+	case "lte":
+		return fact <= value
+	}
+	return false
+}
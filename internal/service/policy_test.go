@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"curswork-trpo/internal/models"
+)
+
+// parseCondition decodes a condition the way rules arrive in the API
+func parseCondition(t *testing.T, text string) *models.PolicyCondition {
+	t.Helper()
+	if text == "" {
+		return nil
+	}
+	var condition models.PolicyCondition
+	if err := json.Unmarshal([]byte(text), &condition); err != nil {
+		t.Fatalf("bad condition %s: %v", text, err)
+	}
+	return &condition
+}
+
+func TestPolicyMatches(t *testing.T) {
+	facts := map[string]interface{}{
+		"title":             "Flight to Moscow",
+		"category":          "travel",
+		"vendor":            "Aeroflot",
+		"description":       "",
+		"currency":          "RUB",
+		"amount":            45000.0,
+		"originalAmount":    45000.0,
+		"descriptionLength": 0.0,
+		"attachmentCount":   1.0,
+	}
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+	}{
+		{"no condition", "", true},
+		{"string eq ignores case and spaces", `{"field": "category", "op": "eq", "value": " TRAVEL "}`, true},
+		{"string ne", `{"field": "category", "op": "ne", "value": "travel"}`, false},
+		{"string contains", `{"field": "title", "op": "contains", "value": "moscow"}`, true},
+		{"string in", `{"field": "vendor", "op": "in", "value": ["S7", "aeroflot"]}`, true},
+		{"string notIn", `{"field": "vendor", "op": "notIn", "value": ["S7", "aeroflot"]}`, false},
+		{"number gt", `{"field": "amount", "op": "gt", "value": 30000}`, true},
+		{"number lte", `{"field": "amount", "op": "lte", "value": 30000}`, false},
+		{"number eq", `{"field": "attachmentCount", "op": "eq", "value": 1}`, true},
+		{"number against string value", `{"field": "amount", "op": "gt", "value": "30000"}`, false},
+		{"unknown field", `{"field": "project", "op": "eq", "value": "x"}`, false},
+		{"all holds", `{"all": [{"field": "category", "op": "eq", "value": "travel"},
+			{"field": "amount", "op": "gt", "value": 30000}]}`, true},
+		{"all fails on one", `{"all": [{"field": "category", "op": "eq", "value": "travel"},
+			{"field": "descriptionLength", "op": "gte", "value": 100}]}`, false},
+		{"any holds on one", `{"any": [{"field": "category", "op": "eq", "value": "meals"},
+			{"field": "amount", "op": "gt", "value": 30000}]}`, true},
+		{"any fails", `{"any": [{"field": "category", "op": "eq", "value": "meals"},
+			{"field": "amount", "op": "lt", "value": 100}]}`, false},
+		{"not", `{"not": {"field": "currency", "op": "eq", "value": "RUB"}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policyMatches(parseCondition(t, tt.condition), facts); got != tt.want {
+				t.Errorf("policyMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	tests := []struct {
+		fact  string
+		op    string
+		value interface{}
+		want  bool
+	}{
+		{"Travel", "eq", "travel", true},
+		{"Travel", "ne", "travel", false},
+		{"Taxi to airport", "contains", "AIRPORT", true},
+		{"Taxi", "contains", "bus", false},
+		{"meals", "in", []interface{}{"travel", "Meals"}, true},
+		{"meals", "in", []interface{}{"travel"}, false},
+		{"meals", "notIn", []interface{}{"travel"}, true},
+		{"meals", "in", "meals", false},
+		{"meals", "eq", 1.0, false},
+		{"meals", "gt", "a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op+" "+tt.fact, func(t *testing.T) {
+			if got := compareStrings(tt.fact, tt.op, tt.value); got != tt.want {
+				t.Errorf("compareStrings(%q, %q, %v) = %v, want %v", tt.fact, tt.op, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareNumbers(t *testing.T) {
+	tests := []struct {
+		fact  float64
+		op    string
+		value float64
+		want  bool
+	}{
+		{10, "eq", 10, true},
+		{10, "ne", 10, false},
+		{10, "gt", 10, false},
+		{10, "gte", 10, true},
+		{9.99, "lt", 10, true},
+		{10, "lte", 10, true},
+		{10, "contains", 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op, func(t *testing.T) {
+			if got := compareNumbers(tt.fact, tt.op, tt.value); got != tt.want {
+				t.Errorf("compareNumbers(%v, %q, %v) = %v, want %v", tt.fact, tt.op, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePolicyRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		effect  models.PolicyEffect
+		when    string
+		require string
+		valid   bool
+	}{
+		{"reject without condition", models.PolicyReject, "", "", true},
+		{"warn on amount", models.PolicyWarn, `{"field": "amount", "op": "gt", "value": 1000}`, "", true},
+		{"require with requirement", models.PolicyRequire, `{"field": "category", "op": "eq", "value": "travel"}`,
+			`{"field": "attachmentCount", "op": "gte", "value": 1}`, true},
+		{"nested conditions", models.PolicyReject, `{"all": [{"not": {"field": "vendor", "op": "in", "value": ["a"]}},
+			{"any": [{"field": "title", "op": "contains", "value": "x"}]}]}`, "", true},
+		{"require without requirement", models.PolicyRequire, "", "", false},
+		{"requirement on reject", models.PolicyReject, "", `{"field": "amount", "op": "gt", "value": 1}`, false},
+		{"unknown field", models.PolicyReject, `{"field": "project", "op": "eq", "value": "x"}`, "", false},
+		{"number operator on string", models.PolicyReject, `{"field": "vendor", "op": "gt", "value": "x"}`, "", false},
+		{"string operator on number", models.PolicyReject, `{"field": "amount", "op": "contains", "value": 1}`, "", false},
+		{"string value for number", models.PolicyReject, `{"field": "amount", "op": "gt", "value": "1"}`, "", false},
+		{"empty in list", models.PolicyReject, `{"field": "vendor", "op": "in", "value": []}`, "", false},
+		{"number in list", models.PolicyReject, `{"field": "vendor", "op": "in", "value": ["a", 1]}`, "", false},
+		{"empty all", models.PolicyReject, `{"all": []}`, "", false},
+		{"two kinds at once", models.PolicyReject, `{"field": "amount", "op": "gt", "value": 1,
+			"not": {"field": "amount", "op": "lt", "value": 1}}`, "", false},
+		{"bad nested condition", models.PolicyReject, `{"any": [{"field": "amount", "op": "gt", "value": 1},
+			{"field": "amount", "op": "in", "value": [1]}]}`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &models.PolicyRule{
+				Effect:  tt.effect,
+				When:    parseCondition(t, tt.when),
+				Require: parseCondition(t, tt.require),
+			}
+			err := validatePolicyRule(rule)
+			if tt.valid && err != nil {
+				t.Errorf("validatePolicyRule() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidPolicyRule) {
+				t.Errorf("validatePolicyRule() = %v, want ErrInvalidPolicyRule", err)
+			}
+		})
+	}
+}
+
+func TestJudgePoliciesCountsAttachments(t *testing.T) {
+	rules := []models.PolicyRule{{
+		ID:      1,
+		Version: 1,
+		Name:    "receipts above 5000",
+		Effect:  models.PolicyRequire,
+		When:    parseCondition(t, `{"field": "amount", "op": "gt", "value": 5000}`),
+		Require: parseCondition(t, `{"field": "attachmentCount", "op": "gte", "value": 1}`),
+		Message: "attach a receipt",
+	}}
+	// A request created directly is evaluated before it is stored and has no attachments yet
+	unstored := &models.ExpenseRequest{Title: "Hotel", Amount: 8000, Currency: "RUB"}
+
+	tests := []struct {
+		name        string
+		attachments int
+		want        models.PolicyOutcome
+	}{
+		{"not stored yet", 0, models.PolicyBlocked},
+		{"with a receipt", 1, models.PolicyPassed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluation := &models.PolicyEvaluation{Stage: models.PolicyStageSubmit}
+			judgePolicies(evaluation, rules, policyFacts(unstored, tt.attachments))
+			if evaluation.Outcome != tt.want {
+				t.Errorf("outcome = %s, want %s (results %+v)", evaluation.Outcome, tt.want, evaluation.Results)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role with this name already exists")
+	ErrRoleBuiltIn       = errors.New("built-in roles cannot be deleted")
+	ErrRoleInUse         = errors.New("role is still held by users or open invitations")
+	ErrUnknownRole       = errors.New("unknown role")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrAdminLockout      = errors.New("the admin role has to keep users.manage")
+)
+
+// RoleService manages roles as sets of permissions stored in the database.
+// Permissions are read on every request, so a change to a role applies to its holders at once.
+type RoleService struct {
+	db       *postgres.Client
+	roleRepo *repository.RoleRepository
+	audit    *AuditService
+}
+
+func NewRoleService(db *postgres.Client, roleRepo *repository.RoleRepository, audit *AuditService) *RoleService {
+	return &RoleService{db: db, roleRepo: roleRepo, audit: audit}
+}
+
+// PermissionsOf gets the permissions a user holds through all of their roles
+func (s *RoleService) PermissionsOf(ctx context.Context, userID uint) (models.PermissionSet, error) {
+	return s.roleRepo.GetUserPermissions(ctx, userID)
+}
+
+// ListPermissions gets every permission a role may grant
+func (s *RoleService) ListPermissions(ctx context.Context) ([]models.PermissionInfo, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+// ListRoles gets all roles with their permissions
+func (s *RoleService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.ListRoles(ctx)
+}
+
+// GetRole gets a role with its permissions
+func (s *RoleService) GetRole(ctx context.Context, name models.UserRole) (*models.Role, error) {
+	role, err := s.roleRepo.GetRole(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRoleNotFound
+	}
+	return role, err
+}
+
+// CreateRole defines a new role, e.g. an accountant or an auditor
+func (s *RoleService) CreateRole(ctx context.Context, dto *models.CreateRoleDTO, actorID uint) (*models.Role, error) {
+	var role *models.Role
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		_, err := s.GetRole(ctx, dto.Name)
+		if err == nil {
+			return ErrRoleExists
+		}
+		if !errors.Is(err, ErrRoleNotFound) {
+			return err
+		}
+		if err = s.checkPermissions(ctx, dto.Permissions); err != nil {
+			return err
+		}
+
+		role = &models.Role{Name: dto.Name, Description: dto.Description}
+		if err = s.roleRepo.CreateRole(ctx, role); err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+		if err = s.roleRepo.SetRolePermissions(ctx, role.Name, dto.Permissions); err != nil {
+			return err
+		}
+
+		if role, err = s.GetRole(ctx, role.Name); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditRoleCreated,
+			EntityType: models.AuditEntityRole,
+			EntityID:   role.ID,
+			After:      role,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole changes the description and replaces the permissions of a role
+func (s *RoleService) UpdateRole(
+	ctx context.Context, name models.UserRole, dto *models.UpdateRoleDTO, actorID uint,
+) (*models.Role, error) {
+	// Without users.manage on admin nobody could hand out roles anymore
+	if name == models.RoleAdmin && !slices.Contains(dto.Permissions, models.PermUsersManage) {
+		return nil, ErrAdminLockout
+	}
+
+	var after *models.Role
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.GetRole(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err = s.checkPermissions(ctx, dto.Permissions); err != nil {
+			return err
+		}
+
+		if err = s.roleRepo.UpdateRoleDescription(ctx, name, dto.Description); err != nil {
+			return err
+		}
+		if err = s.roleRepo.SetRolePermissions(ctx, name, dto.Permissions); err != nil {
+			return err
+		}
+
+		if after, err = s.GetRole(ctx, name); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditRoleUpdated,
+			EntityType: models.AuditEntityRole,
+			EntityID:   before.ID,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteRole deletes a custom role nobody holds anymore
+func (s *RoleService) DeleteRole(ctx context.Context, name models.UserRole, actorID uint) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		role, err := s.GetRole(ctx, name)
+		if err != nil {
+			return err
+		}
+		if role.BuiltIn {
+			return ErrRoleBuiltIn
+		}
+
+		holders, err := s.roleRepo.CountRoleHolders(ctx, name)
+		if err != nil {
+			return err
+		}
+		if holders > 0 {
+			return ErrRoleInUse
+		}
+
+		if err = s.roleRepo.DeleteRole(ctx, name); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &actorID,
+			Action:     models.AuditRoleDeleted,
+			EntityType: models.AuditEntityRole,
+			EntityID:   role.ID,
+			Before:     role,
+		})
+	})
+}
+
+// checkPermissions makes sure every permission exists
+func (s *RoleService) checkPermissions(ctx context.Context, permissions []models.Permission) error {
+	known, err := s.roleRepo.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !slices.ContainsFunc(known, func(info models.PermissionInfo) bool { return info.Name == permission }) {
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+	}
+	return nil
+}
+
+// checkRoles makes sure every role exists
+func checkRoles(ctx context.Context, roleRepo *repository.RoleRepository, roles ...models.UserRole) error {
+	for _, role := range roles {
+		_, err := roleRepo.GetRole(ctx, role)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrUnknownRole, role)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
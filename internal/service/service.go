@@ -4,159 +4,598 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"curswork-trpo/internal/models"
 	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
 
+	"github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type ExpenseService struct {
-	expenseRepo *repository.ExpenseRepository
-	budgetRepo  *repository.BudgetRepository
-	userRepo    *repository.UserRepository
+	db             *postgres.Client
+	expenseRepo    *repository.ExpenseRepository
+	budgetRepo     *repository.BudgetRepository
+	userRepo       *repository.UserRepository
+	approvalRepo   *repository.ApprovalRepository
+	attachmentRepo *repository.AttachmentRepository
+	revisionRepo   *repository.RevisionRepository
+	delegationRepo *repository.DelegationRepository
+	roleRepo       *repository.RoleRepository
+	departmentRepo *repository.DepartmentRepository
+	categoryRepo   *repository.CategoryRepository
+	policyRepo     *repository.PolicyRepository
+	autoRepo       *repository.AutoApprovalRepository
+	flagRepo       *repository.FlagRepository
+	rateRepo       *repository.ExchangeRateRepository
+	audit          *AuditService
+	approvalChain  ApprovalChain
+	budgetConfig   BudgetConfig
+	duties         DutiesPolicy
+	detection      DetectionConfig
+	currency       CurrencyConfig
 }
 
-func NewExpenseService(
-	expenseRepo *repository.ExpenseRepository,
-	budgetRepo *repository.BudgetRepository,
-	userRepo *repository.UserRepository,
-) *ExpenseService {
+// ExpenseServiceDeps are the repositories, services and settings an ExpenseService works with
+type ExpenseServiceDeps struct {
+	DB             *postgres.Client
+	ExpenseRepo    *repository.ExpenseRepository
+	BudgetRepo     *repository.BudgetRepository
+	UserRepo       *repository.UserRepository
+	ApprovalRepo   *repository.ApprovalRepository
+	AttachmentRepo *repository.AttachmentRepository
+	RevisionRepo   *repository.RevisionRepository
+	DelegationRepo *repository.DelegationRepository
+	RoleRepo       *repository.RoleRepository
+	DepartmentRepo *repository.DepartmentRepository
+	CategoryRepo   *repository.CategoryRepository
+	PolicyRepo     *repository.PolicyRepository
+	AutoRepo       *repository.AutoApprovalRepository
+	FlagRepo       *repository.FlagRepository
+	RateRepo       *repository.ExchangeRateRepository
+	Audit          *AuditService
+	ApprovalChain  ApprovalChain
+	BudgetConfig   BudgetConfig
+	Duties         DutiesPolicy
+	Detection      DetectionConfig
+	Currency       CurrencyConfig
+}
+
+func NewExpenseService(deps ExpenseServiceDeps) *ExpenseService {
 	return &ExpenseService{
-		expenseRepo: expenseRepo,
-		budgetRepo:  budgetRepo,
-		userRepo:    userRepo,
+		db:             deps.DB,
+		expenseRepo:    deps.ExpenseRepo,
+		budgetRepo:     deps.BudgetRepo,
+		userRepo:       deps.UserRepo,
+		approvalRepo:   deps.ApprovalRepo,
+		attachmentRepo: deps.AttachmentRepo,
+		revisionRepo:   deps.RevisionRepo,
+		delegationRepo: deps.DelegationRepo,
+		roleRepo:       deps.RoleRepo,
+		departmentRepo: deps.DepartmentRepo,
+		categoryRepo:   deps.CategoryRepo,
+		policyRepo:     deps.PolicyRepo,
+		autoRepo:       deps.AutoRepo,
+		flagRepo:       deps.FlagRepo,
+		rateRepo:       deps.RateRepo,
+		audit:          deps.Audit,
+		approvalChain:  deps.ApprovalChain,
+		budgetConfig:   deps.BudgetConfig,
+		duties:         deps.Duties,
+		detection:      deps.Detection,
+		currency:       deps.Currency,
 	}
 }
 
-// CreateExpenseRequest creates a new expense request
+// CreateExpenseRequest creates a new expense request and sends it to review,
+// requests covered by an auto-approval rule come back approved
 func (s *ExpenseService) CreateExpenseRequest(ctx context.Context, dto *models.CreateExpenseRequestDTO, employeeID uint) (*models.ExpenseRequest, error) {
+	request, err := s.createExpenseRequest(ctx, dto, employeeID, models.StatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed auto-approval is not fatal: the request is already stored and in review
+	rule, err := s.autoApprove(ctx, request.ID)
+	if err != nil {
+		log.Printf("auto-approval of expense request %d: %v", request.ID, err)
+		return request, nil
+	}
+	if rule == nil {
+		return request, nil
+	}
+
+	approved, err := s.expenseRepo.GetExpenseRequestByID(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if approved.ApprovalSteps, err = s.approvalRepo.GetApprovalSteps(ctx, request.ID); err != nil {
+		return nil, fmt.Errorf("failed to get approval steps: %w", err)
+	}
+	if approved.PolicyEvaluations, err = s.policyRepo.GetPolicyEvaluations(ctx, request.ID); err != nil {
+		return nil, fmt.Errorf("failed to get policy evaluations: %w", err)
+	}
+	return approved, nil
+}
+
+// createExpenseRequest stores a request in its initial status.
+// Drafts get their approval chain, checks, flags and score on submit.
+// A request refused by the policy rules is not stored.
+// Drafts in a currency without a rate yet are stored unconverted.
+func (s *ExpenseService) createExpenseRequest(ctx context.Context, dto *models.CreateExpenseRequestDTO, employeeID uint, status models.RequestStatus) (*models.ExpenseRequest, error) {
 	// Validate employee exists
 	_, err := s.userRepo.GetUserByID(ctx, employeeID)
 	if err != nil {
 		return nil, fmt.Errorf("employee not found: %w", err)
 	}
 
+	expenseDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if dto.ExpenseDate != "" {
+		if expenseDate, err = time.Parse(time.DateOnly, dto.ExpenseDate); err != nil {
+			return nil, fmt.Errorf("invalid expense date: %w", err)
+		}
+	}
+
 	request := &models.ExpenseRequest{
-		Title:       dto.Title,
-		Category:    dto.Category,
-		Amount:      dto.Amount,
-		Vendor:      dto.Vendor,
-		Description: dto.Description,
-		Status:      models.StatusPending,
-		EmployeeID:  employeeID,
+		Title:          dto.Title,
+		Category:       dto.Category,
+		Currency:       dto.Currency,
+		OriginalAmount: dto.Amount,
+		Vendor:         dto.Vendor,
+		Description:    dto.Description,
+		Status:         status,
+		EmployeeID:     employeeID,
+		ExpenseDate:    expenseDate,
+	}
+	if request.Currency == "" {
+		request.Currency = models.BaseCurrency
+	}
+	if err = s.convertAmount(ctx, request, time.Now(), status != models.StatusDraft); err != nil {
+		return nil, err
 	}
 
-	if err = s.expenseRepo.CreateExpenseRequest(ctx, request); err != nil {
-		return nil, fmt.Errorf("failed to create expense request: %w", err)
+	var evaluation *models.PolicyEvaluation
+	if status != models.StatusDraft {
+		if err = s.checkCategory(ctx, request); err != nil {
+			return nil, err
+		}
+		if evaluation, err = s.evaluatePolicies(ctx, request, models.PolicyStageSubmit, employeeID); err != nil {
+			return nil, err
+		}
+		if evaluation != nil && evaluation.Outcome == models.PolicyBlocked {
+			return nil, &PolicyViolation{Results: evaluation.Results}
+		}
+	}
+
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.expenseRepo.CreateExpenseRequest(ctx, request); err != nil {
+			return fmt.Errorf("failed to create expense request: %w", err)
+		}
+
+		if status != models.StatusDraft {
+			request.ApprovalSteps = s.approvalStepsFor(request)
+			if err := s.approvalRepo.CreateApprovalSteps(ctx, request.ApprovalSteps); err != nil {
+				return fmt.Errorf("failed to create approval steps: %w", err)
+			}
+		}
+		if evaluation != nil {
+			evaluation.RequestID, evaluation.Revision = request.ID, request.Revision
+			if err := s.policyRepo.CreatePolicyEvaluation(ctx, evaluation); err != nil {
+				return err
+			}
+			request.PolicyEvaluations = []models.PolicyEvaluation{*evaluation}
+		}
+		if status != models.StatusDraft {
+			if err := s.flagRequest(ctx, request); err != nil {
+				return err
+			}
+			if err := s.scoreRequest(ctx, request); err != nil {
+				return err
+			}
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &employeeID,
+			Action:     models.AuditExpenseCreated,
+			EntityType: models.AuditEntityExpense,
+			EntityID:   request.ID,
+			After:      request,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 	return request, nil
 }
 
-// GetExpenseRequest gets an expense request by ID
-func (s *ExpenseService) GetExpenseRequest(ctx context.Context, id uint) (*models.ExpenseRequest, error) {
-	return s.expenseRepo.GetExpenseRequestByID(ctx, id)
-}
+// GetExpenseRequest gets an expense request by ID for its owner or holders of expenses.view_all.
+// Drafts are found by their owner only.
+// Views of anyone but the owner are remembered, so a reviewer can tell which revisions are new to them.
+func (s *ExpenseService) GetExpenseRequest(
+	ctx context.Context, id uint, viewerID uint, perms models.PermissionSet,
+) (*models.ExpenseRequest, error) {
+	request, err := s.expenseRepo.GetExpenseRequestByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = CheckView(request, viewerID, perms); err != nil {
+		return nil, err
+	}
 
-// GetExpenseRequestsByEmployee gets expense requests for an employee
-func (s *ExpenseService) GetExpenseRequestsByEmployee(ctx context.Context, employeeID uint, status string) ([]models.ExpenseRequest, error) {
-	return s.expenseRepo.GetExpenseRequestsByEmployee(ctx, employeeID, status)
-}
+	if request.EmployeeID != viewerID {
+		if request.LastViewedRevision, err = s.revisionRepo.GetLastViewedRevision(ctx, id, viewerID); err != nil {
+			return nil, err
+		}
+		if err = s.revisionRepo.MarkViewed(ctx, id, viewerID, request.Revision); err != nil {
+			return nil, err
+		}
+	}
 
-// GetAllExpenseRequests gets all expense requests
-func (s *ExpenseService) GetAllExpenseRequests(ctx context.Context, status string) ([]models.ExpenseRequest, error) {
-	return s.expenseRepo.GetAllExpenseRequests(ctx, status)
+	if request.ApprovalSteps, err = s.approvalRepo.GetApprovalSteps(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get approval steps: %w", err)
+	}
+	if request.Attachments, err = s.attachmentRepo.GetAttachmentsByRequest(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	if request.PolicyEvaluations, err = s.policyRepo.GetPolicyEvaluations(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get policy evaluations: %w", err)
+	}
+	flags, err := s.flagRepo.GetFlagsByRequests(ctx, []uint{id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flags: %w", err)
+	}
+	request.Flags = flags[id]
+	return request, nil
 }
 
 // GetTopExpenses gets top 3 expenses
 func (s *ExpenseService) GetTopExpenses(ctx context.Context) ([]models.TopExpenseRequest, error) {
 	return s.expenseRepo.GetTopExpenses(ctx)
 }
+
+// GetExpensesByCategory sums the requests of every category in the base currency
 func (s *ExpenseService) GetExpensesByCategory(ctx context.Context) ([]models.CategoryExpenseRequest, error) {
 	return s.expenseRepo.GetExpensesByCategory(ctx)
 }
 
-// ApproveExpenseRequest approves an expense request
-func (s *ExpenseService) ApproveExpenseRequest(ctx context.Context, id uint, reviewerID uint, comments string) error {
-	// Get the request
-	request, err := s.expenseRepo.GetExpenseRequestByID(ctx, id)
+// ApproveExpenseRequest approves the current approval step of an expense request.
+// The request itself becomes approved only when its last step is approved.
+// The whole approval runs in one transaction holding row locks on the request and its budgets.
+// The amount is debited from the company budget and from the budgets of the department
+// of the employee and the departments above it.
+// A step bound to a role fails with ErrStepRoleRequired for reviewers without it.
+// An approval breaking segregation of duties is refused with a *DutyViolation and audited.
+// Requests of a category requiring receipts cannot be approved without an attachment.
+// Budgets are charged in the base currency at the rate CurrencyConfig picks.
+// A step refused by the policy rules of the approve stage returns a *PolicyViolation.
+// onBehalfOf names the reviewer who delegated the decision.
+func (s *ExpenseService) ApproveExpenseRequest(
+	ctx context.Context, id uint, reviewerID uint, perms models.PermissionSet, onBehalfOf *uint, comments string,
+) error {
+	var violation *DutyViolation
+	var policyViolation *PolicyViolation
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if perms, err = s.actingPermissions(ctx, request, reviewerID, perms, onBehalfOf); err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusApproved, reviewerID, perms); err != nil {
+			return err
+		}
+
+		step, last, err := s.currentStep(ctx, request)
+		if err != nil {
+			return err
+		}
+		if err = s.checkStepRole(ctx, step, reviewerID, onBehalfOf); err != nil {
+			return err
+		}
+
+		if violation, err = s.checkDuties(ctx, request, reviewerID, onBehalfOf); err != nil {
+			return err
+		}
+		if violation != nil {
+			return s.auditDutyViolation(ctx, request, reviewerID, violation)
+		}
+		if err = s.checkReceipt(ctx, request); err != nil {
+			return err
+		}
+		if policyViolation, err = s.applyPolicies(ctx, request, models.PolicyStageApprove, reviewerID); err != nil {
+			return err
+		}
+		if policyViolation != nil {
+			return nil
+		}
+
+		if err = s.approvalRepo.DecideApprovalStep(
+			ctx, step.ID, reviewerID, onBehalfOf, models.ApprovalApproved, comments,
+		); err != nil {
+			return fmt.Errorf("failed to approve step %q: %w", step.Name, err)
+		}
+
+		if !last {
+			return s.audit.Record(ctx, AuditEvent{
+				ActorID:    &reviewerID,
+				Action:     models.AuditExpenseStepApproved,
+				EntityType: models.AuditEntityExpense,
+				EntityID:   request.ID,
+				Before:     step,
+				After: map[string]interface{}{
+					"step": step.Name, "status": models.ApprovalApproved, "comments": comments, "onBehalfOf": onBehalfOf,
+				},
+			})
+		}
+
+		// Charge the budget period of the expense, at the company level and in the department of the employee,
+		// with the amount converted today when conversion happens on approval
+		charged := *request
+		if err = s.convertOnApproval(ctx, &charged, reviewerID); err != nil {
+			return err
+		}
+		period := s.budgetConfig.ChargePeriod(&charged, time.Now().UTC())
+		budgets, err := s.lockChargedBudgets(ctx, &charged, period)
+		if err != nil {
+			return err
+		}
+
+		if err = s.debitBudgets(ctx, &charged, budgets, reviewerID); err != nil {
+			return err
+		}
+
+		// Update request status
+		if err = s.expenseRepo.UpdateExpenseRequestStatus(
+			ctx, id, reviewerID, onBehalfOf, models.StatusApproved, comments,
+		); err != nil {
+			return fmt.Errorf("failed to approve request: %w", err)
+		}
+
+		return s.auditStatusChange(ctx, reviewerID, models.AuditExpenseApproved, request)
+	})
 	if err != nil {
-		return fmt.Errorf("request not found: %w", err)
+		return err
 	}
-
-	if request.Status != models.StatusPending {
-		return errors.New("request is not pending")
+	// The violation is reported only after its audit record has been committed
+	if violation != nil {
+		return violation
+	}
+	if policyViolation != nil {
+		return policyViolation
 	}
+	return nil
+}
 
+// debitBudgets charges an approved request to the budgets locked for it,
+// none of them may go below zero
+func (s *ExpenseService) debitBudgets(ctx context.Context, request *models.ExpenseRequest, budgets []*models.Budget, actorID uint) error {
 	// Check remaining for greater zero after expense
-	budget, err := s.budgetRepo.GetBudgetByMonth(ctx, 2026, 1)
-	if err != nil {
-		return fmt.Errorf("budget not found: %w", err)
+	for _, budget := range budgets {
+		if budget.Remaining-request.Amount < 0 {
+			return &BudgetExceeded{DepartmentID: budget.DepartmentID, Remaining: budget.Remaining, Amount: request.Amount}
+		}
 	}
-	if budget.Remaining-request.Amount < 0 {
-		return fmt.Errorf("budget remaining %d < 0", request.Amount)
+
+	// Update budgets
+	for _, budget := range budgets {
+		if err := s.budgetRepo.UpdateBudgetSpent(ctx, budget.ID, request.Amount, request.ID); err != nil {
+			return fmt.Errorf("failed to update budget: %w", err)
+		}
+		if err := s.auditBudgetChange(ctx, &actorID, models.AuditBudgetSpent, budget); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// BudgetExceeded reports an approval the remaining amount of a budget cannot cover
+type BudgetExceeded struct {
+	DepartmentID *uint
+	Remaining    float64
+	Amount       float64
+}
 
-	// Update budget
-	now := time.Now()
-	if err = s.budgetRepo.UpdateBudgetSpent(ctx, now.Year(), int(now.Month()), request.Amount); err != nil {
-		return fmt.Errorf("failed to update budget: %w", err)
+func (e *BudgetExceeded) Error() string {
+	if e.DepartmentID != nil {
+		return fmt.Sprintf("department %d budget remaining %.2f < %.2f", *e.DepartmentID, e.Remaining, e.Amount)
 	}
+	return fmt.Sprintf("budget remaining %.2f < %.2f", e.Remaining, e.Amount)
+}
+
+// RejectExpenseRequest rejects the current approval step and the whole request,
+// onBehalfOf works as in ApproveExpenseRequest
+func (s *ExpenseService) RejectExpenseRequest(
+	ctx context.Context, id uint, reviewerID uint, perms models.PermissionSet, onBehalfOf *uint, comments string,
+) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		request, err := s.lockRequest(ctx, id)
+		if err != nil {
+			return err
+		}
+		if perms, err = s.actingPermissions(ctx, request, reviewerID, perms, onBehalfOf); err != nil {
+			return err
+		}
+		if err = CheckTransition(request, models.StatusRejected, reviewerID, perms); err != nil {
+			return err
+		}
+
+		step, _, err := s.currentStep(ctx, request)
+		if err != nil {
+			return err
+		}
+		if err = s.checkStepRole(ctx, step, reviewerID, onBehalfOf); err != nil {
+			return err
+		}
+
+		if err = s.approvalRepo.DecideApprovalStep(
+			ctx, step.ID, reviewerID, onBehalfOf, models.ApprovalRejected, comments,
+		); err != nil {
+			return fmt.Errorf("failed to reject step %q: %w", step.Name, err)
+		}
+		if err = s.approvalRepo.SkipPendingApprovalSteps(ctx, id); err != nil {
+			return err
+		}
+
+		// Update request status
+		if err = s.expenseRepo.UpdateExpenseRequestStatus(
+			ctx, id, reviewerID, onBehalfOf, models.StatusRejected, comments,
+		); err != nil {
+			return fmt.Errorf("failed to reject request: %w", err)
+		}
+
+		return s.auditStatusChange(ctx, reviewerID, models.AuditExpenseRejected, request)
+	})
+}
 
-	// Update request status
-	if err = s.expenseRepo.UpdateExpenseRequestStatus(
-		ctx, id, reviewerID, models.StatusApproved, comments,
-	); err != nil {
-		return fmt.Errorf("failed to approve request: %w", err)
+// auditStatusChange records the status change of a request, before holds the state read under lock
+func (s *ExpenseService) auditStatusChange(ctx context.Context, actorID uint, action models.AuditAction, before *models.ExpenseRequest) error {
+	after, err := s.expenseRepo.GetExpenseRequestByID(ctx, before.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get request: %w", err)
 	}
 
-	return nil
+	return s.audit.Record(ctx, AuditEvent{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: models.AuditEntityExpense,
+		EntityID:   before.ID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// auditBudgetChange records a budget change, before holds the state read under lock
+func (s *ExpenseService) auditBudgetChange(ctx context.Context, actorID *uint, action models.AuditAction, before *models.Budget) error {
+	after, err := s.budgetRepo.GetBudgetByID(ctx, before.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get budget: %w", err)
+	}
+
+	return s.audit.Record(ctx, AuditEvent{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: models.AuditEntityBudget,
+		EntityID:   before.ID,
+		Before:     before,
+		After:      after,
+	})
 }
 
-// RejectExpenseRequest rejects an expense request
-func (s *ExpenseService) RejectExpenseRequest(ctx context.Context, id uint, reviewerID uint, comments string) error {
-	// Get the request
+// lockRequest locks the request row for the running transaction and reads the request,
+// the caller checks what may be done with it through the state machine
+func (s *ExpenseService) lockRequest(ctx context.Context, id uint) (*models.ExpenseRequest, error) {
+	if err := s.expenseRepo.LockExpenseRequest(ctx, id); errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrRequestNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
 	request, err := s.expenseRepo.GetExpenseRequestByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("request not found: %w", err)
+		return nil, fmt.Errorf("request not found: %w", err)
 	}
+	return request, nil
+}
 
-	fmt.Println(request.Status)
-	if request.Status != models.StatusPending {
-		return errors.New("request is not pending")
+// currentStep returns the step awaiting a decision and whether it is the last one.
+// Requests created before approval chains existed get their chain built on demand.
+func (s *ExpenseService) currentStep(ctx context.Context, request *models.ExpenseRequest) (*models.ApprovalStep, bool, error) {
+	steps, err := s.approvalRepo.GetApprovalSteps(ctx, request.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get approval steps: %w", err)
 	}
 
-	// Update request status
-	if err = s.expenseRepo.UpdateExpenseRequestStatus(
-		ctx, id, reviewerID, models.StatusRejected, comments,
-	); err != nil {
-		return fmt.Errorf("failed to reject request: %w", err)
+	if len(steps) == 0 {
+		steps = s.approvalStepsFor(request)
+		if err = s.approvalRepo.CreateApprovalSteps(ctx, steps); err != nil {
+			return nil, false, fmt.Errorf("failed to create approval steps: %w", err)
+		}
 	}
 
-	return nil
+	step, last := currentApprovalStep(steps)
+	if step == nil {
+		return nil, false, errors.New("request has no pending approval steps")
+	}
+	return step, last, nil
 }
 
-// GetStatistics gets expense statistics
-func (s *ExpenseService) GetStatistics(ctx context.Context) (*models.StatsResponse, error) {
-	return s.expenseRepo.GetStatistics(ctx)
+// GetStatistics gets expense statistics in the base currency with the anomaly scores of pending requests,
+// broken down by department when asked for
+func (s *ExpenseService) GetStatistics(ctx context.Context, byDepartment bool) (*models.StatsResponse, error) {
+	period := s.budgetConfig.Resolve(time.Now().UTC())
+	stats, err := s.expenseRepo.GetStatistics(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies, err := s.expenseRepo.GetAnomalyStatistics(ctx, anomalyTopRequests)
+	if err != nil {
+		return nil, err
+	}
+	stats.Anomalies = *anomalies
+	stats.Currency = models.BaseCurrency
+	if !byDepartment {
+		return stats, nil
+	}
+
+	if stats.Departments, err = s.expenseRepo.GetDepartmentStatistics(ctx, period); err != nil {
+		return nil, err
+	}
+	return stats, nil
 }
 
 // UserService handles user operations
 type UserService struct {
-	userRepo *repository.UserRepository
+	db             *postgres.Client
+	userRepo       *repository.UserRepository
+	invitationRepo *repository.InvitationRepository
+	sessionRepo    *repository.SessionRepository
+	roleRepo       *repository.RoleRepository
+	departmentRepo *repository.DepartmentRepository
+	audit          *AuditService
+	registration   RegistrationConfig
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(
+	db *postgres.Client,
+	userRepo *repository.UserRepository,
+	invitationRepo *repository.InvitationRepository,
+	sessionRepo *repository.SessionRepository,
+	roleRepo *repository.RoleRepository,
+	departmentRepo *repository.DepartmentRepository,
+	audit *AuditService,
+	registration RegistrationConfig,
+) *UserService {
+	return &UserService{
+		db:             db,
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		sessionRepo:    sessionRepo,
+		roleRepo:       roleRepo,
+		departmentRepo: departmentRepo,
+		audit:          audit,
+		registration:   registration,
+	}
 }
 
-// RegisterUser registers a new user
+// RegisterUser registers a new user. With an invitation token the role and email come from the invitation,
+// without one the user becomes an employee, provided registration is open.
 func (s *UserService) RegisterUser(ctx context.Context, dto *models.RegisterUserDTO) (*models.User, error) {
+	if dto.InvitationToken == "" && s.registration.Mode != models.RegistrationOpen {
+		return nil, ErrInvitationRequired
+	}
+
 	// Check if user already exists
 	existingUser, err := s.userRepo.GetUserByEmail(ctx, dto.Email)
 	if err == nil && existingUser != nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrUserExists
 	}
 
 	// Hash password
@@ -170,11 +609,37 @@ func (s *UserService) RegisterUser(ctx context.Context, dto *models.RegisterUser
 		Password:  string(hashedPassword),
 		FirstName: dto.FirstName,
 		LastName:  dto.LastName,
-		Role:      dto.Role,
+		Role:      models.RoleEmployee,
 	}
 
-	if err := s.userRepo.CreateUser(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		var invitation *models.Invitation
+		if dto.InvitationToken != "" {
+			if invitation, err = s.acceptInvitation(ctx, dto.InvitationToken, dto.Email); err != nil {
+				return err
+			}
+			user.Role = invitation.Role
+		}
+
+		if err := s.userRepo.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if invitation != nil {
+			if err := s.invitationRepo.MarkInvitationUsed(ctx, invitation.ID, user.ID); err != nil {
+				return err
+			}
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &user.ID,
+			Action:     models.AuditUserRegistered,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      user,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return user, nil
@@ -184,13 +649,43 @@ func (s *UserService) RegisterUser(ctx context.Context, dto *models.RegisterUser
 func (s *UserService) AuthenticateUser(ctx context.Context, email, password string) (*models.User, error) {
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
+		s.audit.RecordBestEffort(ctx, AuditEvent{
+			Action:     models.AuditUserLoginFailed,
+			EntityType: models.AuditEntityUser,
+			After:      map[string]string{"email": email},
+		})
 		return nil, errors.New("invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.audit.RecordBestEffort(ctx, AuditEvent{
+			ActorID:    &user.ID,
+			Action:     models.AuditUserLoginFailed,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      map[string]string{"email": email},
+		})
+		return nil, errors.New("invalid credentials")
+	}
+
+	// The password is checked first to not reveal deactivated accounts
+	if !user.IsActive {
+		s.audit.RecordBestEffort(ctx, AuditEvent{
+			ActorID:    &user.ID,
+			Action:     models.AuditUserLoginFailed,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			After:      map[string]string{"email": email, "reason": "deactivated"},
+		})
 		return nil, errors.New("invalid credentials")
 	}
 
+	s.audit.RecordBestEffort(ctx, AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditUserLogin,
+		EntityType: models.AuditEntityUser,
+		EntityID:   user.ID,
+	})
 	return user, nil
 }
 
@@ -201,19 +696,228 @@ func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, e
 
 // BudgetService handles budget operations
 type BudgetService struct {
-	budgetRepo *repository.BudgetRepository
+	db             *postgres.Client
+	budgetRepo     *repository.BudgetRepository
+	departmentRepo *repository.DepartmentRepository
+	audit          *AuditService
+	config         BudgetConfig
+}
+
+func NewBudgetService(
+	db *postgres.Client,
+	budgetRepo *repository.BudgetRepository,
+	departmentRepo *repository.DepartmentRepository,
+	audit *AuditService,
+	config BudgetConfig,
+) *BudgetService {
+	return &BudgetService{
+		db:             db,
+		budgetRepo:     budgetRepo,
+		departmentRepo: departmentRepo,
+		audit:          audit,
+		config:         config,
+	}
+}
+
+var (
+	ErrBudgetNotFound       = errors.New("budget not found")
+	ErrBudgetExists         = errors.New("budget for this period already exists")
+	ErrBudgetBelowSpent     = errors.New("budget total cannot be less than the spent amount")
+	ErrInvalidBudgetDate    = errors.New("invalid budget year or month")
+	ErrParentBudgetMissing  = errors.New("the parent department has no budget for this period")
+	ErrBudgetOverAllocated  = errors.New("department budgets cannot exceed the budget of the level above")
+	ErrBudgetBelowAllocated = errors.New("budget total cannot be less than the amount allocated to departments")
+)
+
+// GetCurrentBudget gets the budget of the current period: the company budget, created if missing,
+// or the budget of a department. With breakdown the department budgets below it are filled in.
+func (s *BudgetService) GetCurrentBudget(ctx context.Context, departmentID *uint, breakdown bool) (*models.Budget, error) {
+	period := s.config.Resolve(time.Now().UTC())
+
+	var budget *models.Budget
+	var err error
+	if departmentID == nil {
+		budget, err = s.budgetRepo.EnsureBudget(ctx, period, s.config.DefaultTotal(period))
+	} else {
+		budget, err = s.getBudget(ctx, period, departmentID)
+	}
+	if err != nil || !breakdown {
+		return budget, err
+	}
+
+	if budget.Departments, err = s.budgetRepo.ListDepartmentBudgets(ctx, period, departmentID); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// GetBudgetByMonth gets the budget of the period containing a month,
+// the company budget when departmentID is nil
+func (s *BudgetService) GetBudgetByMonth(ctx context.Context, year, month int, departmentID *uint) (*models.Budget, error) {
+	if month < 1 || month > 12 {
+		return nil, ErrInvalidBudgetDate
+	}
+	return s.getBudget(ctx, s.config.ResolveMonth(year, month), departmentID)
+}
+
+// getBudget gets the budget of a period, telling a missing department from a missing budget
+func (s *BudgetService) getBudget(ctx context.Context, period models.BudgetPeriod, departmentID *uint) (*models.Budget, error) {
+	if departmentID != nil {
+		if _, err := getDepartment(ctx, s.departmentRepo, *departmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	budget, err := s.budgetRepo.GetBudget(ctx, period, departmentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBudgetNotFound
+	}
+	return budget, err
 }
 
-func NewBudgetService(budgetRepo *repository.BudgetRepository) *BudgetService {
-	return &BudgetService{budgetRepo: budgetRepo}
+// ListBudgets gets all budgets whose period starts in a year
+func (s *BudgetService) ListBudgets(ctx context.Context, year int) ([]models.Budget, error) {
+	return s.budgetRepo.ListBudgetsByYear(ctx, year)
 }
 
-// GetCurrentBudget gets or creates current month's budget
-func (s *BudgetService) GetCurrentBudget(ctx context.Context) (*models.Budget, error) {
-	return s.budgetRepo.GetOrCreateCurrentBudget(ctx)
+// CreateBudget creates a budget for the period containing a month that has none yet.
+// A department budget has to fit into the budget of the level above it.
+func (s *BudgetService) CreateBudget(ctx context.Context, dto *models.CreateBudgetDTO, userID uint) (*models.Budget, error) {
+	_, err := s.GetBudgetByMonth(ctx, dto.Year, dto.Month, dto.DepartmentID)
+	if err == nil {
+		return nil, ErrBudgetExists
+	}
+	if !errors.Is(err, ErrBudgetNotFound) {
+		return nil, err
+	}
+
+	period := s.config.ResolveMonth(dto.Year, dto.Month)
+	var budget *models.Budget
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if dto.DepartmentID != nil {
+			if err := s.checkAllocation(ctx, period, *dto.DepartmentID, 0, dto.Total); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		budget, err = s.budgetRepo.CreateBudget(ctx, period, dto.DepartmentID, dto.Total, &userID)
+		if err != nil {
+			return fmt.Errorf("failed to create budget: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditBudgetCreated,
+			EntityType: models.AuditEntityBudget,
+			EntityID:   budget.ID,
+			After:      budget,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// UpdateBudgetTotal changes the total of the budget of the period containing a month.
+// The total must cover what is spent and allocated below.
+func (s *BudgetService) UpdateBudgetTotal(
+	ctx context.Context, year, month int, departmentID *uint, total float64, userID uint,
+) (*models.Budget, error) {
+	current, err := s.GetBudgetByMonth(ctx, year, month, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	if total < current.Spent {
+		return nil, fmt.Errorf("%w: spent %.2f, requested total %.2f", ErrBudgetBelowSpent, current.Spent, total)
+	}
+
+	period := s.config.ResolveMonth(year, month)
+	var budget *models.Budget
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		// Budgets are locked from the top down like in approvals
+		if departmentID != nil {
+			if err := s.checkAllocation(ctx, period, *departmentID, current.ID, total); err != nil {
+				return err
+			}
+		}
+		if _, err := s.budgetRepo.LockBudget(ctx, period, departmentID); err != nil {
+			return fmt.Errorf("failed to lock budget: %w", err)
+		}
+
+		allocated, err := s.budgetRepo.GetAllocatedTotal(ctx, period, departmentID, 0)
+		if err != nil {
+			return err
+		}
+		if total < allocated {
+			return fmt.Errorf("%w: allocated %.2f, requested total %.2f", ErrBudgetBelowAllocated, allocated, total)
+		}
+
+		budget, err = s.budgetRepo.UpdateBudgetTotal(ctx, current.ID, total, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Spending grew between the check and the update
+			return ErrBudgetBelowSpent
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update budget: %w", err)
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditBudgetUpdated,
+			EntityType: models.AuditEntityBudget,
+			EntityID:   budget.ID,
+			Before:     current,
+			After:      budget,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
 }
 
-// GetBudgetByMonth gets budget for specific month
-func (s *BudgetService) GetBudgetByMonth(ctx context.Context, year, month int) (*models.Budget, error) {
-	return s.budgetRepo.GetBudgetByMonth(ctx, year, month)
+// GetBudgetHistory gets the change history of the budget of the period containing a month
+func (s *BudgetService) GetBudgetHistory(ctx context.Context, year, month int, departmentID *uint) ([]models.BudgetHistoryEntry, error) {
+	budget, err := s.GetBudgetByMonth(ctx, year, month, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.budgetRepo.GetBudgetHistory(ctx, budget.ID)
+}
+
+// checkAllocation makes sure a department budget fits into the locked budget of the level above.
+// The budget budgetID being changed is left out of the allocated sum.
+func (s *BudgetService) checkAllocation(
+	ctx context.Context, period models.BudgetPeriod, departmentID, budgetID uint, total float64,
+) error {
+	department, err := getDepartment(ctx, s.departmentRepo, departmentID)
+	if err != nil {
+		return err
+	}
+
+	if department.ParentID == nil {
+		if _, err = s.budgetRepo.EnsureBudget(ctx, period, s.config.DefaultTotal(period)); err != nil {
+			return fmt.Errorf("failed to get budget: %w", err)
+		}
+	}
+	parent, err := s.budgetRepo.LockBudget(ctx, period, department.ParentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrParentBudgetMissing
+	}
+	if err != nil {
+		return err
+	}
+
+	allocated, err := s.budgetRepo.GetAllocatedTotal(ctx, period, department.ParentID, budgetID)
+	if err != nil {
+		return err
+	}
+	if allocated+total > parent.Total {
+		return fmt.Errorf(
+			"%w: %.2f of %.2f already allocated, requested %.2f", ErrBudgetOverAllocated, allocated, parent.Total, total,
+		)
+	}
+	return nil
 }
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"curswork-trpo/internal/models"
+	"curswork-trpo/internal/repository"
+	"curswork-trpo/pkg/adapters/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, the session has been revoked")
+)
+
+// SessionService keeps login sessions server-side so that tokens can be revoked.
+// Reusing a spent refresh token revokes the whole session.
+type SessionService struct {
+	db          *postgres.Client
+	sessionRepo *repository.SessionRepository
+	userRepo    *repository.UserRepository
+	audit       *AuditService
+	refreshTTL  time.Duration
+}
+
+func NewSessionService(
+	db *postgres.Client,
+	sessionRepo *repository.SessionRepository,
+	userRepo *repository.UserRepository,
+	audit *AuditService,
+	refreshTTL time.Duration,
+) *SessionService {
+	return &SessionService{
+		db:          db,
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		audit:       audit,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// SessionTokens is the state of a session after login or refresh.
+// RefreshToken is the only copy of the plain token.
+type SessionTokens struct {
+	SessionID    string
+	User         *models.User
+	RefreshToken string
+}
+
+// StartSession opens a session for an authenticated user
+func (s *SessionService) StartSession(ctx context.Context, user *models.User) (*SessionTokens, error) {
+	sessionID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(s.refreshTTL),
+	}
+
+	var refreshToken string
+	err = s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		refreshToken, err = s.issueRefreshToken(ctx, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionTokens{SessionID: session.ID, User: user, RefreshToken: refreshToken}, nil
+}
+
+// Refresh spends a refresh token and issues the next one of the same session.
+// The user is read again, so a deactivation takes effect on the next refresh.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*SessionTokens, error) {
+	var tokens *SessionTokens
+	reused := false
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		token, err := s.sessionRepo.LockRefreshToken(ctx, hashToken(refreshToken))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		session, err := s.sessionRepo.GetSession(ctx, token.SessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+
+		// A spent token coming back means it has been copied, nobody in the family can be trusted
+		if token.UsedAt != nil {
+			reused = true
+			if session.RevokedAt != nil {
+				return nil
+			}
+			if err = s.sessionRepo.RevokeSession(ctx, session.ID); err != nil {
+				return err
+			}
+			return s.audit.Record(ctx, AuditEvent{
+				ActorID:    &session.UserID,
+				Action:     models.AuditTokenReused,
+				EntityType: models.AuditEntityUser,
+				EntityID:   session.UserID,
+				After:      map[string]string{"session": session.ID},
+			})
+		}
+
+		now := time.Now().UTC()
+		if session.RevokedAt != nil || now.After(session.ExpiresAt) || now.After(token.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if !user.IsActive {
+			return ErrInvalidRefreshToken
+		}
+
+		if err = s.sessionRepo.MarkRefreshTokenUsed(ctx, token.Hash); err != nil {
+			return err
+		}
+		next, err := s.issueRefreshToken(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		tokens = &SessionTokens{SessionID: session.ID, User: user, RefreshToken: next}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The revocation above has to be committed before the reuse is reported
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return tokens, nil
+}
+
+// Logout revokes a session of the user together with every token issued for it
+func (s *SessionService) Logout(ctx context.Context, sessionID string, userID uint) error {
+	return s.db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.RevokeSession(ctx, sessionID); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &userID,
+			Action:     models.AuditUserLogout,
+			EntityType: models.AuditEntityUser,
+			EntityID:   userID,
+			After:      map[string]string{"session": sessionID},
+		})
+	})
+}
+
+// IsSessionActive tells whether access tokens of a session are still accepted
+func (s *SessionService) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	session, err := s.sessionRepo.GetSession(ctx, sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return session.RevokedAt == nil && time.Now().UTC().Before(session.ExpiresAt), nil
+}
+
+// issueRefreshToken stores a new refresh token of the session
+func (s *SessionService) issueRefreshToken(ctx context.Context, session *models.Session) (string, error) {
+	plain, err := randomToken(32, base64URL)
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.sessionRepo.CreateRefreshToken(ctx, &models.RefreshToken{
+		Hash:      hashToken(plain),
+		SessionID: session.ID,
+		ExpiresAt: session.ExpiresAt,
+	}); err != nil {
+		return "", fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return plain, nil
+}
+
+// randomToken returns size random bytes in the given encoding
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return encode(random), nil
+}
+
+func base64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// hashToken is how secret tokens are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"curswork-trpo/internal/models"
+)
+
+// Actor is the part a user plays for a particular request
+type Actor string
+
+const (
+	ActorOwner    Actor = "owner"
+	ActorReviewer Actor = "reviewer"
+	ActorPayer    Actor = "payer"
+)
+
+// TransitionError reports a status change the request lifecycle does not allow
+type TransitionError struct {
+	From models.RequestStatus
+	To   models.RequestStatus // empty when the request content was to be edited
+}
+
+func (e *TransitionError) Error() string {
+	if e.To == "" {
+		return fmt.Sprintf("a %s request cannot be edited", e.From)
+	}
+	return fmt.Sprintf("a %s request cannot become %s", e.From, e.To)
+}
+
+type transition struct {
+	from models.RequestStatus
+	to   models.RequestStatus
+}
+
+// requestTransitions is the request lifecycle: every allowed status change and who may make it.
+//
+//	draft → pending (submitted) → approved → scheduled_for_payment → paid
+//	pending → rejected | returned_for_changes | withdrawn
+//	returned_for_changes → pending | withdrawn, draft → withdrawn
+var requestTransitions = map[transition][]Actor{
+	{models.StatusDraft, models.StatusPending}:             {ActorOwner},
+	{models.StatusDraft, models.StatusWithdrawn}:           {ActorOwner},
+	{models.StatusPending, models.StatusApproved}:          {ActorReviewer},
+	{models.StatusPending, models.StatusRejected}:          {ActorReviewer},
+	{models.StatusPending, models.StatusReturned}:          {ActorReviewer},
+	{models.StatusPending, models.StatusWithdrawn}:         {ActorOwner},
+	{models.StatusReturned, models.StatusPending}:          {ActorOwner},
+	{models.StatusReturned, models.StatusWithdrawn}:        {ActorOwner},
+	{models.StatusApproved, models.StatusScheduledPayment}: {ActorPayer},
+	{models.StatusScheduledPayment, models.StatusPaid}:     {ActorPayer},
+}
+
+// editableStatuses are the statuses in which the owner may change the content of a request
+var editableStatuses = []models.RequestStatus{models.StatusDraft, models.StatusPending, models.StatusReturned}
+
+// actorsOf returns the parts a user plays for a request
+func actorsOf(request *models.ExpenseRequest, userID uint, perms models.PermissionSet) []Actor {
+	var actors []Actor
+	if request.EmployeeID == userID {
+		actors = append(actors, ActorOwner)
+	}
+	if perms.Has(models.PermExpensesApprove) {
+		actors = append(actors, ActorReviewer)
+	}
+	if perms.Has(models.PermExpensesPay) {
+		actors = append(actors, ActorPayer)
+	}
+	return actors
+}
+
+// CheckTransition tells whether the user may move the request to a status.
+// It returns a *TransitionError when the lifecycle has no such step and
+// ErrRequestAccessDenied when the step belongs to someone else.
+// Someone else's draft is reported as ErrRequestNotFound.
+func CheckTransition(request *models.ExpenseRequest, to models.RequestStatus, userID uint, perms models.PermissionSet) error {
+	if isHiddenDraft(request, userID) {
+		return ErrRequestNotFound
+	}
+	allowed, ok := requestTransitions[transition{request.Status, to}]
+	if !ok {
+		return &TransitionError{From: request.Status, To: to}
+	}
+
+	for _, actor := range actorsOf(request, userID, perms) {
+		if slices.Contains(allowed, actor) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: only the %s can move a %s request to %s", ErrRequestAccessDenied, allowed[0], request.Status, to)
+}
+
+// CheckView tells whether the user may look at the request: the owner always,
+// holders of expenses.view_all any request but someone else's draft, which stays hidden as not found
+func CheckView(request *models.ExpenseRequest, userID uint, perms models.PermissionSet) error {
+	switch {
+	case request.EmployeeID == userID:
+		return nil
+	case isHiddenDraft(request, userID):
+		return ErrRequestNotFound
+	case !perms.Has(models.PermExpensesViewAll):
+		return ErrRequestAccessDenied
+	}
+	return nil
+}
+
+// CheckEdit tells whether the user may change the content or attachments of the request
+func CheckEdit(request *models.ExpenseRequest, userID uint) error {
+	if isHiddenDraft(request, userID) {
+		return ErrRequestNotFound
+	}
+	if request.EmployeeID != userID {
+		return ErrRequestNotEditable
+	}
+	if !slices.Contains(editableStatuses, request.Status) {
+		return &TransitionError{From: request.Status}
+	}
+	return nil
+}
+
+// isHiddenDraft tells whether the request is a draft of someone other than the user
+func isHiddenDraft(request *models.ExpenseRequest, userID uint) bool {
+	return request.Status == models.StatusDraft && request.EmployeeID != userID
+}
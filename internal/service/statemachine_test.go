@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"curswork-trpo/internal/models"
+)
+
+const (
+	ownerID = 1
+	otherID = 2
+)
+
+var (
+	reviewerPerms = models.PermissionSet{models.PermExpensesApprove}
+	payerPerms    = models.PermissionSet{models.PermExpensesPay}
+	viewAllPerms  = models.PermissionSet{models.PermExpensesViewAll}
+)
+
+func TestRequestTransitions(t *testing.T) {
+	statuses := []models.RequestStatus{
+		models.StatusDraft, models.StatusPending, models.StatusApproved, models.StatusScheduledPayment,
+		models.StatusPaid, models.StatusRejected, models.StatusWithdrawn, models.StatusReturned,
+	}
+	final := map[models.RequestStatus]bool{
+		models.StatusPaid: true, models.StatusRejected: true, models.StatusWithdrawn: true,
+	}
+
+	for step, actors := range requestTransitions {
+		if len(actors) == 0 {
+			t.Errorf("%s → %s has no actor", step.from, step.to)
+		}
+		if final[step.from] {
+			t.Errorf("%s is final but can become %s", step.from, step.to)
+		}
+	}
+	for _, status := range statuses {
+		if final[status] {
+			continue
+		}
+		leaves := false
+		for step := range requestTransitions {
+			if step.from == status {
+				leaves = true
+			}
+		}
+		if !leaves {
+			t.Errorf("%s is not final but has no way out", status)
+		}
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  models.RequestStatus
+		to      models.RequestStatus
+		userID  uint
+		perms   models.PermissionSet
+		wantErr error
+	}{
+		{"owner submits draft", models.StatusDraft, models.StatusPending, ownerID, nil, nil},
+		{"owner withdraws pending", models.StatusPending, models.StatusWithdrawn, ownerID, nil, nil},
+		{"owner resubmits returned", models.StatusReturned, models.StatusPending, ownerID, nil, nil},
+		{"reviewer approves", models.StatusPending, models.StatusApproved, otherID, reviewerPerms, nil},
+		{"reviewer returns", models.StatusPending, models.StatusReturned, otherID, reviewerPerms, nil},
+		{"payer schedules", models.StatusApproved, models.StatusScheduledPayment, otherID, payerPerms, nil},
+		{"payer pays", models.StatusScheduledPayment, models.StatusPaid, otherID, payerPerms, nil},
+		{"owner cannot approve", models.StatusPending, models.StatusApproved, ownerID, nil, ErrRequestAccessDenied},
+		{"reviewer cannot withdraw", models.StatusPending, models.StatusWithdrawn, otherID, reviewerPerms, ErrRequestAccessDenied},
+		{"reviewer cannot pay", models.StatusScheduledPayment, models.StatusPaid, otherID, reviewerPerms, ErrRequestAccessDenied},
+		{"draft of someone else is hidden", models.StatusDraft, models.StatusPending, otherID, reviewerPerms, ErrRequestNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &models.ExpenseRequest{EmployeeID: ownerID, Status: tt.status}
+			err := CheckTransition(request, tt.to, tt.userID, tt.perms)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckTransition() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTransitionNotInLifecycle(t *testing.T) {
+	tests := []struct {
+		from models.RequestStatus
+		to   models.RequestStatus
+	}{
+		{models.StatusDraft, models.StatusApproved},
+		{models.StatusPending, models.StatusPaid},
+		{models.StatusApproved, models.StatusPending},
+		{models.StatusPaid, models.StatusWithdrawn},
+		{models.StatusRejected, models.StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"→"+string(tt.to), func(t *testing.T) {
+			request := &models.ExpenseRequest{EmployeeID: ownerID, Status: tt.from}
+			perms := models.PermissionSet{models.PermExpensesApprove, models.PermExpensesPay}
+			err := CheckTransition(request, tt.to, ownerID, perms)
+
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Fatalf("CheckTransition() = %v, want *TransitionError", err)
+			}
+			if transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("TransitionError = %+v, want %s → %s", transitionErr, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestCheckView(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  models.RequestStatus
+		userID  uint
+		perms   models.PermissionSet
+		wantErr error
+	}{
+		{"owner sees draft", models.StatusDraft, ownerID, nil, nil},
+		{"owner sees pending", models.StatusPending, ownerID, nil, nil},
+		{"view_all sees pending", models.StatusPending, otherID, viewAllPerms, nil},
+		{"view_all sees paid", models.StatusPaid, otherID, viewAllPerms, nil},
+		{"draft hidden even with view_all", models.StatusDraft, otherID, viewAllPerms, ErrRequestNotFound},
+		{"reviewer without view_all", models.StatusPending, otherID, reviewerPerms, ErrRequestAccessDenied},
+		{"no permissions", models.StatusApproved, otherID, nil, ErrRequestAccessDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &models.ExpenseRequest{EmployeeID: ownerID, Status: tt.status}
+			if err := CheckView(request, tt.userID, tt.perms); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckView() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckEdit(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         models.RequestStatus
+		userID         uint
+		wantErr        error
+		wantTransition bool
+	}{
+		{"owner edits draft", models.StatusDraft, ownerID, nil, false},
+		{"owner edits pending", models.StatusPending, ownerID, nil, false},
+		{"owner edits returned", models.StatusReturned, ownerID, nil, false},
+		{"owner cannot edit approved", models.StatusApproved, ownerID, nil, true},
+		{"owner cannot edit withdrawn", models.StatusWithdrawn, ownerID, nil, true},
+		{"someone else's draft is hidden", models.StatusDraft, otherID, ErrRequestNotFound, false},
+		{"someone else's pending", models.StatusPending, otherID, ErrRequestNotEditable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &models.ExpenseRequest{EmployeeID: ownerID, Status: tt.status}
+			err := CheckEdit(request, tt.userID)
+
+			var transitionErr *TransitionError
+			if tt.wantTransition {
+				if !errors.As(err, &transitionErr) || transitionErr.To != "" {
+					t.Errorf("CheckEdit() = %v, want an edit *TransitionError", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckEdit() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
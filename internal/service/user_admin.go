@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"curswork-trpo/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrSelfAdministration = errors.New("administrators cannot change their own roles or deactivate themselves")
+	ErrSystemUser         = errors.New("the system user cannot be changed")
+)
+
+// ListUsers gets a page of users matching the filter
+func (s *UserService) ListUsers(ctx context.Context, dto *models.ListUsersDTO) (*models.UserPage, error) {
+	filter := models.UserFilter{
+		Search: dto.Search,
+		Role:   models.UserRole(dto.Role),
+		Active: dto.Active,
+		Limit:  dto.Limit,
+		Offset: dto.Offset,
+
+		Department: dto.Department,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+
+	users, total, err := s.userRepo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserPage{Items: users, Total: total}, nil
+}
+
+// GetUser gets a user for administration
+func (s *UserService) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// getChangeableUser gets a user other than the system user
+func (s *UserService) getChangeableUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSystem {
+		return nil, ErrSystemUser
+	}
+	return user, nil
+}
+
+// ChangeRole gives a user another primary role. Their sessions are revoked,
+// so they start over under the new role.
+func (s *UserService) ChangeRole(ctx context.Context, id, adminID uint, role models.UserRole) (*models.User, error) {
+	if id == adminID {
+		return nil, ErrSelfAdministration
+	}
+	if err := checkRoles(ctx, s.roleRepo, role); err != nil {
+		return nil, err
+	}
+
+	return s.changeUser(ctx, id, adminID, models.AuditUserRoleChanged, func(ctx context.Context) error {
+		return s.userRepo.UpdateUserRole(ctx, id, role)
+	})
+}
+
+// SetRoles replaces the roles a user holds on top of the primary one
+func (s *UserService) SetRoles(ctx context.Context, id, adminID uint, roles []models.UserRole) (*models.User, error) {
+	if id == adminID {
+		return nil, ErrSelfAdministration
+	}
+	if err := checkRoles(ctx, s.roleRepo, roles...); err != nil {
+		return nil, err
+	}
+
+	return s.changeUser(ctx, id, adminID, models.AuditUserRolesChanged, func(ctx context.Context) error {
+		return s.roleRepo.SetUserRoles(ctx, id, roles)
+	})
+}
+
+// SetDepartment moves a user to a department or out of any with nil
+func (s *UserService) SetDepartment(ctx context.Context, id, adminID uint, departmentID *uint) (*models.User, error) {
+	var after *models.User
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.getChangeableUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		if departmentID != nil {
+			if _, err = getDepartment(ctx, s.departmentRepo, *departmentID); err != nil {
+				return err
+			}
+		}
+
+		if err = s.userRepo.SetUserDepartment(ctx, id, departmentID); err != nil {
+			return err
+		}
+
+		if after, err = s.userRepo.GetUserByID(ctx, id); err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &adminID,
+			Action:     models.AuditUserDepartmentSet,
+			EntityType: models.AuditEntityUser,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// SetTrustLevel changes how far a user is trusted, auto-approval rules may ask for a minimum.
+// Nobody raises their own trust level.
+func (s *UserService) SetTrustLevel(ctx context.Context, id, adminID uint, trustLevel int) (*models.User, error) {
+	if id == adminID {
+		return nil, ErrSelfAdministration
+	}
+
+	var after *models.User
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.getChangeableUser(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = s.userRepo.SetUserTrustLevel(ctx, id, trustLevel); err != nil {
+			return err
+		}
+
+		if after, err = s.userRepo.GetUserByID(ctx, id); err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &adminID,
+			Action:     models.AuditUserTrustLevelSet,
+			EntityType: models.AuditEntityUser,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// SetActive deactivates or reactivates a user. A deactivated user cannot log in
+// and their sessions are revoked at once.
+func (s *UserService) SetActive(ctx context.Context, id, adminID uint, active bool) (*models.User, error) {
+	if id == adminID {
+		return nil, ErrSelfAdministration
+	}
+
+	action := models.AuditUserDeactivated
+	if active {
+		action = models.AuditUserActivated
+	}
+	return s.changeUser(ctx, id, adminID, action, func(ctx context.Context) error {
+		return s.userRepo.SetUserActive(ctx, id, active)
+	})
+}
+
+// ResetPassword sets a new password for a user and logs them out everywhere
+func (s *UserService) ResetPassword(ctx context.Context, id, adminID uint, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = s.changeUser(ctx, id, adminID, models.AuditUserPasswordReset, func(ctx context.Context) error {
+		return s.userRepo.UpdateUserPassword(ctx, id, string(hashedPassword))
+	})
+	return err
+}
+
+// changeUser applies an administrative change to a user in one transaction,
+// revokes their sessions and records the change in the audit log
+func (s *UserService) changeUser(
+	ctx context.Context, id, adminID uint, action models.AuditAction, change func(ctx context.Context) error,
+) (*models.User, error) {
+	var after *models.User
+	err := s.db.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := s.getChangeableUser(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = change(ctx); err != nil {
+			return err
+		}
+		if err = s.sessionRepo.RevokeUserSessions(ctx, id); err != nil {
+			return err
+		}
+
+		if after, err = s.userRepo.GetUserByID(ctx, id); err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		return s.audit.Record(ctx, AuditEvent{
+			ActorID:    &adminID,
+			Action:     action,
+			EntityType: models.AuditEntityUser,
+			EntityID:   id,
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
@@ -16,6 +16,21 @@ type Client struct {
 	pool *pgxpool.Pool
 }
 
+// querier is the query API shared by the pool and transactions
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+type txKey struct{}
+
+// txState is the transaction bound to a context and the work to do right before it commits
+type txState struct {
+	tx           pgx.Tx
+	beforeCommit []func(ctx context.Context) error
+}
+
 func NewClient(ctx context.Context) (*Client, error) {
 	const fn = "NewClient"
 
@@ -93,19 +108,81 @@ func (c *Client) Close(ctx context.Context) error {
 	return nil
 }
 
+// RunInTx runs work as a single unit inside a database transaction.
+// Every Query, QueryRow and Exec made with the context passed to work joins
+// the transaction. It is committed when work returns nil and rolled back
+// otherwise. Nested calls reuse the outer transaction.
+func (c *Client) RunInTx(ctx context.Context, work func(ctx context.Context) error) (err error) {
+	const fn = "RunInTx"
+
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return work(ctx)
+	}
+
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s : %w", fn, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	state := &txState{tx: tx}
+	txCtx := context.WithValue(ctx, txKey{}, state)
+	if err = work(txCtx); err != nil {
+		return err
+	}
+	// Hooks may register further hooks
+	for i := 0; i < len(state.beforeCommit); i++ {
+		if err = state.beforeCommit[i](txCtx); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s : %w", fn, err)
+	}
+	return nil
+}
+
+// BeforeCommit runs fn in the transaction bound to ctx right before it commits.
+// Outside of RunInTx fn runs at once in a transaction of its own.
+func (c *Client) BeforeCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.beforeCommit = append(state.beforeCommit, fn)
+		return nil
+	}
+	return c.RunInTx(ctx, fn)
+}
+
+// conn returns the transaction bound to ctx, or the pool outside of RunInTx
+func (c *Client) conn(ctx context.Context) querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return c.pool
+}
+
 // Query executes a query that returns rows
 func (c *Client) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
-	return c.pool.Query(ctx, query, args...)
+	return c.conn(ctx).Query(ctx, query, args...)
 }
 
 // QueryRow executes a query that returns a single row
 func (c *Client) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
-	return c.pool.QueryRow(ctx, query, args...)
+	return c.conn(ctx).QueryRow(ctx, query, args...)
 }
 
 // Exec executes a query that doesn't return rows
 func (c *Client) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
-	return c.pool.Exec(ctx, query, args...)
+	return c.conn(ctx).Exec(ctx, query, args...)
 }
 
 // Ping checks the database connection
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotFound is returned when an object does not exist in the storage
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores binary objects such as receipts and invoices by key
+type Storage interface {
+	// Put stores size bytes read from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key, missing objects are ignored
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage creates the backend selected by STORAGE_BACKEND: "local" (default) or "s3"
+func NewStorage(ctx context.Context) (Storage, error) {
+	const fn = "NewStorage"
+
+	switch backend := getEnv("STORAGE_BACKEND", "local"); backend {
+	case "local":
+		return NewLocalStorage(getEnv("STORAGE_LOCAL_DIR", "./data/attachments"))
+	case "s3":
+		return NewS3Storage(ctx)
+	default:
+		return nil, fmt.Errorf("%s : unknown storage backend %q", fn, backend)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}